@@ -5,17 +5,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/chatext"
 	"github.com/dmars8047/broterm/internal/config"
+	"github.com/dmars8047/broterm/internal/keymap"
+	"github.com/dmars8047/broterm/internal/logging"
 	"github.com/dmars8047/broterm/internal/state"
+	"github.com/dmars8047/broterm/internal/theme"
+	"github.com/dmars8047/broterm/internal/transport"
 	"github.com/dmars8047/broterm/internal/ui"
 	"github.com/dmars8047/idamlib/idam"
-	"github.com/gorilla/websocket"
+	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
@@ -51,27 +57,56 @@ func main() {
 		}
 	}
 
+	// Load the config settings
+	settings, err := provisionConfigFile()
+
+	if err != nil {
+		log.Fatalf("Broterm Version - %s\n\nFatal error: the config file could not be loaded - %v", applicationVersion, err)
+	}
+
+	// Apply the proxy and certificate settings before anything connects to the server
+	network, err := transport.NewNetwork(settings.Network.WithEnvironment())
+
+	if err != nil {
+		log.Fatalf("Broterm Version - %s\n\nFatal error: the network settings could not be applied - %v", applicationVersion, err)
+	}
+
 	// Configure logging
-	config, file, err := provisionConfigFile()
+	logsDir, err := config.LogsDirectoryPath()
 
 	if err != nil {
 		log.Fatalf("Broterm Version - %s\n\nFatal error: log files could not be configured - %v", applicationVersion, err)
 	}
 
-	defer file.Close()
-
-	if config.LoggingEnabled {
-		log.Printf("Broterm Version - %s\n\nBroterm logging is enabled. Writing logs to %s\n", applicationVersion, file.Name())
-		log.SetOutput(file)
+	if settings.LoggingEnabled {
+		log.Printf("Broterm Version - %s\n\nBroterm logging is enabled. Writing logs to %s\n", applicationVersion, logsDir)
 	} else {
 		log.Printf("Broterm Version - %s\n\nBroterm logging is disabled.\n", applicationVersion)
-		// supress logging
-		log.SetOutput(&NullWriter{})
 	}
 
-	// Setup the http client
+	logFile, err := logging.Setup(logsDir, settings.LoggingEnabled, settings.LogLevel)
+
+	if err != nil {
+		log.Fatalf("Broterm Version - %s\n\nFatal error: log files could not be configured - %v", applicationVersion, err)
+	}
+
+	defer logFile.Close()
+
+	slog.Info("Broterm started", "version", applicationVersion)
+
+	// Custom themes must be loaded before the configured theme is resolved
+	loadCustomThemes()
+
+	// Setup the application context. Requests still in flight are cancelled when the application exits.
+	context, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Setup the http client. The transport bounds each request by an overall deadline, including its retries, and retries and circuit breaks it,
+	// so the client has no timeout of its own. Requests which carry a context, such as those made by the chatext client, are also cancelled with it.
+	httpTransport := transport.New(context, network.HTTPTransport())
+
 	httpClient := &http.Client{
-		Timeout: 10 * time.Second,
+		Transport: httpTransport,
 	}
 
 	const hostAddr = "dev.marshall-labs.com"
@@ -81,21 +116,35 @@ func main() {
 
 	brochatClient := chat.NewBroChatClient(httpClient, "https://"+hostAddr)
 
+	chatextClient := chatext.NewClient(httpClient, "https://"+hostAddr)
+
 	// Configure the application
 	app := tview.NewApplication()
 
-	// Setup the application context
-	context, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// The terminal's color depth is only known once the screen has been initialised when the application runs.
+	// Themes are applied unmapped until then and mapped to the color depth on the first draw.
+	// Setting TCELL_TRUECOLOR=disable forces the 256 color palette on terminals which misreport true color support.
+	appContext := state.NewApplicationContext(context, settings.Theme, theme.TRUE_COLOR_DEPTH)
+
+	colorDepthDetected := false
 
-	appContext := state.NewApplicationContext(context, config.Theme)
+	app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
+		if !colorDepthDetected {
+			colorDepthDetected = true
+			appContext.GetThemeManager().SetColorDepth(screen.Colors())
+		}
+
+		return false
+	})
+
+	appContext.SetKeymap(loadKeymap())
+	appContext.SetVimModeEnabled(settings.VimMode)
 
 	// Setup the page navigator
-	nav := ui.NewNavigator(appContext)
+	nav := ui.NewNavigator(app, appContext)
+	nav.SetCircuitBreaker(httpTransport.Breaker)
 
-	dialer := &websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
+	dialer := network.WebsocketDialer(10 * time.Second)
 
 	feedClient := state.NewFeedClient(dialer, hostAddr, brochatClient, appContext)
 
@@ -104,7 +153,7 @@ func main() {
 	welcomePage.Setup(app, appContext, nav)
 
 	// Setup the app settings page
-	appSettingsPage := ui.NewAppSettingsPage(config.LoggingEnabled)
+	appSettingsPage := ui.NewAppSettingsPage(*settings)
 	appSettingsPage.Setup(app, appContext, nav)
 
 	// Setup the registration page
@@ -112,7 +161,7 @@ func main() {
 	registrationPage.Setup(app, appContext, nav)
 
 	// Setup the login page
-	loginPage := ui.NewLoginPage(userAuthClient, brochatClient, feedClient)
+	loginPage := ui.NewLoginPage(userAuthClient, brochatClient, chatextClient, feedClient)
 	loginPage.Setup(app, appContext, nav)
 
 	// Setup the forgot password page
@@ -128,7 +177,7 @@ func main() {
 	homePage.Setup(app, appContext, nav)
 
 	// Setup the friends list page
-	friendsListPage := ui.NewFriendsListPage(brochatClient, feedClient)
+	friendsListPage := ui.NewFriendsListPage(brochatClient, chatextClient, feedClient)
 	friendsListPage.Setup(app, appContext, nav)
 
 	// Setup the find a friend page
@@ -136,11 +185,19 @@ func main() {
 	findAFriendPage.Setup(app, appContext, nav)
 
 	// Setup the accept friend request page
-	acceptFriendRequestPage := ui.NewAcceptFriendRequestPage(brochatClient, feedClient)
+	acceptFriendRequestPage := ui.NewAcceptFriendRequestPage(brochatClient, chatextClient, feedClient)
 	acceptFriendRequestPage.Setup(app, appContext, nav)
 
+	// Setup the blocked users page
+	blockedUsersPage := ui.NewBlockedUsersPage(chatextClient)
+	blockedUsersPage.Setup(app, appContext, nav)
+
+	// Setup the profile page
+	profilePage := ui.NewProfilePage(chatextClient)
+	profilePage.Setup(app, appContext, nav)
+
 	// Setup the room list page
-	roomListPage := ui.NewRoomListPage(brochatClient, feedClient)
+	roomListPage := ui.NewRoomListPage(brochatClient, chatextClient, feedClient)
 	roomListPage.Setup(app, appContext, nav)
 
 	// Setup the room editor page
@@ -151,10 +208,16 @@ func main() {
 	roomFinderPage := ui.NewRoomFinderPage(brochatClient)
 	roomFinderPage.Setup(app, appContext, nav)
 
-	// Set the background color of the navs pages
-	theme := appContext.GetTheme()
-	nav.Pages.SetBackgroundColor(theme.BackgroundColor)
-	theme.ApplyGlobals()
+	// Setup the room admin page
+	roomAdminPage := ui.NewRoomAdminPage(brochatClient, chatextClient, feedClient)
+	roomAdminPage.Setup(app, appContext, nav)
+
+	// The history keys work on every page
+	app.SetInputCapture(nav.InputCapture)
+
+	// Setup the command palette which can be opened on any page once the user has logged in
+	commandPalette := ui.NewCommandPalette(userAuthClient)
+	commandPalette.Setup(app, appContext, nav)
 
 	// Start the application.
 	err = app.SetRoot(nav.Pages, true).Run()
@@ -164,30 +227,49 @@ func main() {
 	}
 }
 
-// IO.Writer that does nothing
-type NullWriter struct{}
+// loadCustomThemes loads the user defined themes from the themes directory.
+// Theme files which cannot be loaded are logged and skipped.
+func loadCustomThemes() {
+	themesDir, err := config.ThemesDirectoryPath()
 
-// Write does nothing
-func (NullWriter) Write(p []byte) (int, error) {
-	return len(p), nil
+	if err != nil {
+		slog.Warn("The themes directory could not be located", "err", err)
+		return
+	}
 
+	for _, err := range theme.LoadCustomThemes(themesDir) {
+		slog.Warn("A custom theme could not be loaded", "err", err)
+	}
 }
 
-// provisionConfigFile creates a config directory in the user's home directory if it does not already exist
-// It will read the config.json file in the config directory and return a ConfigSettings struct with the values from the file
-// It also creates a log file in the user's home directory and returns a file handle to it
-// If the log file already exists, it will be opened and the file handle will be returned
-// If the log file does not exist, it will be created and the file handle will be returned
-// If the log file cannot be created or opened, an error will be returned
-// This function also administers the log directory by deleting the oldest log file if the number of log files exceeds MAX_NUM_LOG_FILES.
-func provisionConfigFile() (*config.ConfigSettings, *os.File, error) {
-	const maxNumLogFiles = 10
+// loadKeymap loads the keymap with any overrides from the keybindings file.
+// Overrides which cannot be applied are logged and the default keys are used instead.
+func loadKeymap() *keymap.Keymap {
+	keybindingsFilePath, err := config.KeybindingsFilePath()
+
+	if err != nil {
+		slog.Warn("The keybindings file could not be located", "err", err)
+		return keymap.Default()
+	}
+
+	km, errs := keymap.Load(keybindingsFilePath)
+
+	for _, err := range errs {
+		slog.Warn("A keybinding could not be loaded", "err", err)
+	}
 
+	return km
+}
+
+// provisionConfigFile creates the config directory in the user's home directory if it does not already exist.
+// It reads the config.json file in the config directory and returns a ConfigSettings struct with the values from the file.
+// If the config file does not exist it is created with the default settings.
+func provisionConfigFile() (*config.ConfigSettings, error) {
 	// Get the user's home directory
 	homeDir, err := os.UserHomeDir()
 
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	// Create the path to the config directory
@@ -198,17 +280,10 @@ func provisionConfigFile() (*config.ConfigSettings, *os.File, error) {
 		err = os.Mkdir(configDir, os.ModePerm)
 
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 	}
 
-	// Check if there are too many log dirEntries and delete the oldest one
-	dirEntries, err := os.ReadDir(configDir)
-
-	if err != nil {
-		return nil, nil, err
-	}
-
 	// Get the config.json file and read it into a new ConfigSettings struct
 	configFilePath := filepath.Join(configDir, config.CONFIG_FILE_NAME)
 
@@ -219,63 +294,27 @@ func provisionConfigFile() (*config.ConfigSettings, *os.File, error) {
 		bytesToSave, err := json.Marshal(configSettings)
 
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
 		err = os.WriteFile(configFilePath, bytesToSave, 0644)
 
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 	} else {
 		configBytes, err := os.ReadFile(configFilePath)
 
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
 		err = json.Unmarshal(configBytes, configSettings)
 
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 	}
 
-	if !configSettings.LoggingEnabled {
-		return configSettings, nil, nil
-	}
-
-	if len(dirEntries) >= maxNumLogFiles {
-		oldestFile, err := dirEntries[0].Info()
-
-		if err != nil {
-			return nil, nil, err
-		}
-
-		for _, dirEntry := range dirEntries {
-			file, err := dirEntry.Info()
-
-			if err != nil {
-				return nil, nil, err
-			}
-
-			if file.ModTime().Before(oldestFile.ModTime()) {
-				oldestFile = file
-			}
-		}
-
-		err = os.Remove(fmt.Sprintf("%s/%s", configDir, oldestFile.Name()))
-
-		if err != nil {
-			return nil, nil, err
-		}
-	}
-
-	logfileName := fmt.Sprintf("broterm_%s.log", time.Now().Format("2006_01_02"))
-
-	logFilePath := filepath.Join(configDir, logfileName)
-
-	file, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-
-	return configSettings, file, err
+	return configSettings, nil
 }
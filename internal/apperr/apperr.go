@@ -0,0 +1,267 @@
+// Package apperr classifies the errors returned by the BroChat and IDAM clients and by the local
+// file system so that the UI can decide how to present them and whether the operation can be retried.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/chatext"
+	"github.com/dmars8047/broterm/internal/transport"
+	"github.com/dmars8047/idamlib/idam"
+)
+
+// Kind is the classification of an error
+type Kind int
+
+const (
+	// KIND_UNKNOWN is an error which could not be classified
+	KIND_UNKNOWN Kind = iota
+	// KIND_NETWORK is an error reaching the server. The operation can be retried.
+	KIND_NETWORK
+	// KIND_AUTH is an authentication error, for example invalid credentials or an expired session
+	KIND_AUTH
+	// KIND_VALIDATION is a request which the server rejected as invalid
+	KIND_VALIDATION
+	// KIND_FORBIDDEN is an operation the user does not have permission to perform
+	KIND_FORBIDDEN
+	// KIND_NOT_FOUND is a request for something which does not exist
+	KIND_NOT_FOUND
+	// KIND_CONFLICT is a request which conflicts with the current state of the server, for example an existing relationship
+	KIND_CONFLICT
+	// KIND_SERVER is an unexpected error on the server or an unexpected response from it. The operation can be retried.
+	KIND_SERVER
+	// KIND_LOCAL is an error reading or writing local state such as the config files. The operation can be retried.
+	KIND_LOCAL
+	// KIND_UNAVAILABLE is a request which was not sent because the server has been failing repeatedly. The operation can be retried.
+	KIND_UNAVAILABLE
+	// KIND_UNSUPPORTED is an operation the server does not implement
+	KIND_UNSUPPORTED
+)
+
+// String returns a short description of the kind of error
+func (kind Kind) String() string {
+	switch kind {
+	case KIND_NETWORK:
+		return "network"
+	case KIND_AUTH:
+		return "auth"
+	case KIND_VALIDATION:
+		return "validation"
+	case KIND_FORBIDDEN:
+		return "forbidden"
+	case KIND_NOT_FOUND:
+		return "not found"
+	case KIND_CONFLICT:
+		return "conflict"
+	case KIND_SERVER:
+		return "server"
+	case KIND_LOCAL:
+		return "local"
+	case KIND_UNAVAILABLE:
+		return "unavailable"
+	case KIND_UNSUPPORTED:
+		return "unsupported"
+	default:
+		return "unknown"
+	}
+}
+
+// Error is a classified error
+type Error struct {
+	Kind Kind
+	// Details are the messages returned by the server which describe the error, for example the validation failures
+	Details []string
+	// Err is the error which was classified
+	Err error
+}
+
+// Error returns the message of the classified error
+func (err *Error) Error() string {
+	if err.Err == nil {
+		return fmt.Sprintf("%s error", err.Kind)
+	}
+
+	return err.Err.Error()
+}
+
+// Unwrap returns the error which was classified
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+// Retryable returns true if the same operation may succeed if it is tried again
+func (err *Error) Retryable() bool {
+	switch err.Kind {
+	case KIND_NETWORK, KIND_SERVER, KIND_LOCAL, KIND_UNAVAILABLE:
+		return true
+	}
+
+	return false
+}
+
+// Summary returns a short user facing description of the error
+func (err *Error) Summary() string {
+	switch err.Kind {
+	case KIND_NETWORK:
+		return "The server could not be reached. Check your connection and try again."
+	case KIND_AUTH:
+		return "Authentication failed."
+	case KIND_VALIDATION:
+		return "The request was invalid."
+	case KIND_FORBIDDEN:
+		return "Warning! A forbidden operation was attempted."
+	case KIND_NOT_FOUND:
+		return "The requested item could not be found."
+	case KIND_CONFLICT:
+		return "The request conflicts with existing data."
+	case KIND_SERVER:
+		return "The server encountered an unexpected error. Try again later."
+	case KIND_LOCAL:
+		return "Local settings could not be read or written."
+	case KIND_UNAVAILABLE:
+		return "The server is unavailable. Try again in a moment."
+	case KIND_UNSUPPORTED:
+		return "This feature is not supported by this server."
+	default:
+		return "An unexpected error occurred."
+	}
+}
+
+// Local classifies an error reading or writing local state
+func Local(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{Kind: KIND_LOCAL, Err: err}
+}
+
+// FromChatResult classifies the result of a BroChat client call. Returns nil if the result is a success.
+func FromChatResult(result chat.BroChatClientResult) *Error {
+	err := result.Err()
+
+	if err == nil {
+		return nil
+	}
+
+	if result.ResponseCode == chatext.RESPONSE_CODE_UNSUPPORTED_OPERATION {
+		return &Error{Kind: KIND_UNSUPPORTED, Err: errors.New("operation not supported by the server")}
+	}
+
+	return &Error{
+		Kind:    chatResponseCodeKind(result.ResponseCode),
+		Details: result.ErrorDetails,
+		Err:     err,
+	}
+}
+
+// Classify classifies an error returned by the BroChat or IDAM clients. Returns nil if err is nil.
+// IDAM error responses are classified by their error code and transport errors are classified as network errors,
+// unless the request was not sent because the circuit is open.
+func Classify(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var classified *Error
+
+	if errors.As(err, &classified) {
+		return classified
+	}
+
+	var idamErr *idam.ErrorResponse
+
+	if errors.As(err, &idamErr) {
+		return &Error{
+			Kind:    idamErrorCodeKind(idamErr.Code),
+			Details: idamErr.Details,
+			Err:     err,
+		}
+	}
+
+	if errors.Is(err, transport.ErrCircuitOpen) {
+		return &Error{Kind: KIND_UNAVAILABLE, Err: err}
+	}
+
+	var urlErr *url.Error
+	var netErr net.Error
+
+	if errors.As(err, &urlErr) || errors.As(err, &netErr) {
+		return &Error{Kind: KIND_NETWORK, Err: err}
+	}
+
+	return &Error{Kind: KIND_UNKNOWN, Err: err}
+}
+
+// KindOf returns the kind of the error. Returns KIND_UNKNOWN if err is nil.
+func KindOf(err error) Kind {
+	classified := Classify(err)
+
+	if classified == nil {
+		return KIND_UNKNOWN
+	}
+
+	return classified.Kind
+}
+
+// chatResponseCodeKind returns the kind of error a BroChat response code represents
+func chatResponseCodeKind(code chat.BroChatResponseCode) Kind {
+	switch code {
+	case chat.BROCHAT_RESPONSE_CODE_FORBIDDEN_ERROR:
+		return KIND_FORBIDDEN
+	case chat.BROCHAT_RESPONSE_CODE_UNAUTHORIZED_ERROR:
+		return KIND_AUTH
+	case chat.BROCHAT_RESPONSE_CODE_VALIDATION_ERROR,
+		chat.BROCHAT_RESPONSE_CODE_REQUEST_PARSE_ERROR,
+		chat.BROCHAT_RESPONSE_CODE_INVALID_OPERATION:
+		return KIND_VALIDATION
+	case chat.BROCHAT_RESPONSE_CODE_NOT_FOUND_ERROR:
+		return KIND_NOT_FOUND
+	case chat.BROCHAT_RESPONSE_CODE_DATA_CONFLICT_ERROR:
+		return KIND_CONFLICT
+	case chat.BROCHAT_RESPONSE_CODE_CONNECTION_TIMEOUT_ERROR,
+		chat.BROCHAT_RESPONSE_CODE_GENERIC_CONNECTION_ERROR,
+		chat.BROCHAT_RESPONSE_CODE_INVALID_HOST_ADDRESS:
+		return KIND_NETWORK
+	case chat.BROCHAT_RESPONSE_CODE_UNHANDLED_ERROR,
+		chat.BROCHAT_RESPONSE_CODE_UNEXEPECTED_RESPONSE_ERROR,
+		chat.BROCHAT_RESPONSE_CODE_GENERIC_REQUEST_ERROR,
+		chat.BROCHAT_RESPONSE_CODE_REQUEST_FORMATTING_ERROR:
+		return KIND_SERVER
+	}
+
+	return KIND_UNKNOWN
+}
+
+// idamErrorCodeKind returns the kind of error an IDAM error code represents
+func idamErrorCodeKind(code uint16) Kind {
+	switch code {
+	case idam.InvalidCredentials,
+		idam.UserNotVerified,
+		idam.InvalidAuthToken,
+		idam.AuthTokenExpired,
+		idam.UserAccountLockout:
+		return KIND_AUTH
+	case idam.RequestPayloadInvalid,
+		idam.RequestValidationFailure,
+		idam.InvalidUserVerficationToken,
+		idam.InvalidPasswordResetToken,
+		idam.InvalidPasswordResetVerificationCode,
+		idam.InvalidRequestHeaders:
+		return KIND_VALIDATION
+	case idam.AccessDenied:
+		return KIND_FORBIDDEN
+	case idam.ApplicationNotFound, idam.UserNotFound:
+		return KIND_NOT_FOUND
+	case idam.DataConflict:
+		return KIND_CONFLICT
+	case idam.UnhandledError:
+		return KIND_SERVER
+	}
+
+	return KIND_UNKNOWN
+}
@@ -0,0 +1,187 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/chatext"
+	"github.com/dmars8047/broterm/internal/transport"
+	"github.com/dmars8047/idamlib/idam"
+)
+
+func TestFromChatResult(t *testing.T) {
+	tests := []struct {
+		code chat.BroChatResponseCode
+		want Kind
+	}{
+		{code: chat.BROCHAT_RESPONSE_CODE_UNHANDLED_ERROR, want: KIND_SERVER},
+		{code: chat.BROCHAT_RESPONSE_CODE_FORBIDDEN_ERROR, want: KIND_FORBIDDEN},
+		{code: chat.BROCHAT_RESPONSE_CODE_VALIDATION_ERROR, want: KIND_VALIDATION},
+		{code: chat.BROCHAT_RESPONSE_CODE_REQUEST_PARSE_ERROR, want: KIND_VALIDATION},
+		{code: chat.BROCHAT_RESPONSE_CODE_NOT_FOUND_ERROR, want: KIND_NOT_FOUND},
+		{code: chat.BROCHAT_RESPONSE_CODE_DATA_CONFLICT_ERROR, want: KIND_CONFLICT},
+		{code: chat.BROCHAT_RESPONSE_CODE_INVALID_OPERATION, want: KIND_VALIDATION},
+		{code: chat.BROCHAT_RESPONSE_CODE_UNAUTHORIZED_ERROR, want: KIND_AUTH},
+		{code: chat.BROCHAT_RESPONSE_CODE_INVALID_HOST_ADDRESS, want: KIND_NETWORK},
+		{code: chat.BROCHAT_RESPONSE_CODE_CONNECTION_TIMEOUT_ERROR, want: KIND_NETWORK},
+		{code: chat.BROCHAT_RESPONSE_CODE_REQUEST_FORMATTING_ERROR, want: KIND_SERVER},
+		{code: chat.BROCHAT_RESPONSE_CODE_UNEXEPECTED_RESPONSE_ERROR, want: KIND_SERVER},
+		{code: chat.BROCHAT_RESPONSE_CODE_GENERIC_REQUEST_ERROR, want: KIND_SERVER},
+		{code: chat.BROCHAT_RESPONSE_CODE_GENERIC_CONNECTION_ERROR, want: KIND_NETWORK},
+		{code: chatext.RESPONSE_CODE_UNSUPPORTED_OPERATION, want: KIND_UNSUPPORTED},
+		{code: 20, want: KIND_UNKNOWN},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("code %d", test.code), func(t *testing.T) {
+			result := chat.BroChatClientResult{ResponseCode: test.code, ErrorDetails: []string{"detail"}}
+
+			got := FromChatResult(result)
+
+			if got == nil {
+				t.Fatalf("FromChatResult() = nil, want %s", test.want)
+			}
+
+			if got.Kind != test.want {
+				t.Fatalf("FromChatResult() kind = %s, want %s", got.Kind, test.want)
+			}
+
+			if test.want != KIND_UNSUPPORTED && (len(got.Details) != 1 || got.Details[0] != "detail") {
+				t.Fatalf("FromChatResult() details = %v, want the result's details", got.Details)
+			}
+		})
+	}
+}
+
+func TestFromChatResultSuccess(t *testing.T) {
+	for _, code := range []chat.BroChatResponseCode{chat.BROCHAT_RESPONSE_CODE_SUCCESS, chat.BROCHAT_RESPONSE_CODE_NO_CONTENT} {
+		if got := FromChatResult(chat.BroChatClientResult{ResponseCode: code}); got != nil {
+			t.Errorf("FromChatResult() = %v for success code %d, want nil", got, code)
+		}
+	}
+}
+
+func TestClassifyIdamErrors(t *testing.T) {
+	tests := []struct {
+		code uint16
+		want Kind
+	}{
+		{code: idam.InvalidCredentials, want: KIND_AUTH},
+		{code: idam.UserNotVerified, want: KIND_AUTH},
+		{code: idam.InvalidAuthToken, want: KIND_AUTH},
+		{code: idam.AuthTokenExpired, want: KIND_AUTH},
+		{code: idam.UserAccountLockout, want: KIND_AUTH},
+		{code: idam.RequestPayloadInvalid, want: KIND_VALIDATION},
+		{code: idam.RequestValidationFailure, want: KIND_VALIDATION},
+		{code: idam.InvalidUserVerficationToken, want: KIND_VALIDATION},
+		{code: idam.InvalidPasswordResetToken, want: KIND_VALIDATION},
+		{code: idam.InvalidPasswordResetVerificationCode, want: KIND_VALIDATION},
+		{code: idam.InvalidRequestHeaders, want: KIND_VALIDATION},
+		{code: idam.AccessDenied, want: KIND_FORBIDDEN},
+		{code: idam.ApplicationNotFound, want: KIND_NOT_FOUND},
+		{code: idam.UserNotFound, want: KIND_NOT_FOUND},
+		{code: idam.DataConflict, want: KIND_CONFLICT},
+		{code: idam.UnhandledError, want: KIND_SERVER},
+		{code: 65000, want: KIND_UNKNOWN},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("code %d", test.code), func(t *testing.T) {
+			idamErr := &idam.ErrorResponse{Code: test.code, Message: "failed", Details: []string{"detail"}}
+
+			// The IDAM client returns its errors as they are, callers may wrap them
+			for _, err := range []error{idamErr, fmt.Errorf("logging in: %w", idamErr)} {
+				got := Classify(err)
+
+				if got.Kind != test.want {
+					t.Fatalf("Classify(%v) kind = %s, want %s", err, got.Kind, test.want)
+				}
+
+				if len(got.Details) != 1 || got.Details[0] != "detail" {
+					t.Fatalf("Classify(%v) details = %v, want the response's details", err, got.Details)
+				}
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	classified := &Error{Kind: KIND_CONFLICT, Err: errors.New("exists")}
+
+	tests := []struct {
+		name string
+		err  error
+		want Kind
+	}{
+		{name: "circuit open", err: transport.ErrCircuitOpen, want: KIND_UNAVAILABLE},
+		{name: "circuit open returned by the http client", err: &url.Error{Op: "Get", URL: "https://example.com", Err: transport.ErrCircuitOpen}, want: KIND_UNAVAILABLE},
+		{name: "url error", err: &url.Error{Op: "Get", URL: "https://example.com", Err: errors.New("connection refused")}, want: KIND_NETWORK},
+		{name: "net error", err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("no route to host")}, want: KIND_NETWORK},
+		{name: "dns error", err: fmt.Errorf("resolving: %w", &net.DNSError{Err: "no such host", Name: "example.com"}), want: KIND_NETWORK},
+		{name: "already classified", err: fmt.Errorf("wrapped: %w", classified), want: KIND_CONFLICT},
+		{name: "unknown", err: errors.New("something else"), want: KIND_UNKNOWN},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Classify(test.err)
+
+			if got.Kind != test.want {
+				t.Fatalf("Classify(%v) kind = %s, want %s", test.err, got.Kind, test.want)
+			}
+
+			if !errors.Is(got, test.err) && !errors.Is(test.err, got) {
+				t.Fatalf("Classify(%v) does not wrap the classified error", test.err)
+			}
+		})
+	}
+
+	if got := Classify(nil); got != nil {
+		t.Fatalf("Classify(nil) = %v, want nil", got)
+	}
+
+	if got := KindOf(nil); got != KIND_UNKNOWN {
+		t.Fatalf("KindOf(nil) = %s, want %s", got, KIND_UNKNOWN)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		kind Kind
+		want bool
+	}{
+		{kind: KIND_UNKNOWN, want: false},
+		{kind: KIND_NETWORK, want: true},
+		{kind: KIND_AUTH, want: false},
+		{kind: KIND_VALIDATION, want: false},
+		{kind: KIND_FORBIDDEN, want: false},
+		{kind: KIND_NOT_FOUND, want: false},
+		{kind: KIND_CONFLICT, want: false},
+		{kind: KIND_SERVER, want: true},
+		{kind: KIND_LOCAL, want: true},
+		{kind: KIND_UNAVAILABLE, want: true},
+		{kind: KIND_UNSUPPORTED, want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.kind.String(), func(t *testing.T) {
+			if got := (&Error{Kind: test.kind}).Retryable(); got != test.want {
+				t.Fatalf("Retryable() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLocal(t *testing.T) {
+	if got := Local(nil); got != nil {
+		t.Fatalf("Local(nil) = %v, want nil", got)
+	}
+
+	if got := Local(errors.New("permission denied")); got.Kind != KIND_LOCAL || !got.Retryable() {
+		t.Fatalf("Local() = %s, retryable %v, want a retryable local error", got.Kind, got.Retryable())
+	}
+}
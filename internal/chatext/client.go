@@ -0,0 +1,249 @@
+// Package chatext implements BroChat API operations which are not yet exposed by brolib's chat.BroChatClient.
+// Results use the chat package result types so that callers can handle them in the same way as chat.BroChatClient results.
+//
+// brolib v0.1.8 does not define these endpoints, so a BroChat server may not implement them. The routes follow the
+// conventions of the routes brolib does define. A server which does not have a route answers with 404 Not Found without a
+// BroChat error body, or with 405 Method Not Allowed or 501 Not Implemented. Those responses are reported as
+// RESPONSE_CODE_UNSUPPORTED_OPERATION rather than as a missing resource, so the UI can say the feature is not available.
+package chatext
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dmars8047/brolib/chat"
+)
+
+const (
+	UPDATE_ROOM_URL_SUFFIX             = "/api/brochat/rooms/:roomId"
+	DELETE_ROOM_URL_SUFFIX             = "/api/brochat/rooms/:roomId"
+	INVITE_USER_TO_ROOM_URL_SUFFIX     = "/api/brochat/rooms/:roomId/invite"
+	REMOVE_ROOM_MEMBER_URL_SUFFIX      = "/api/brochat/rooms/:roomId/members/:userId"
+	TRANSFER_ROOM_OWNERSHIP_URL_SUFFIX = "/api/brochat/rooms/:roomId/owner"
+	LEAVE_ROOM_URL_SUFFIX              = "/api/brochat/rooms/:roomId/leave"
+)
+
+// RESPONSE_CODE_UNSUPPORTED_OPERATION is returned when the server does not implement the endpoint of an operation.
+// It is outside the ranges brolib uses for server and client error codes.
+const RESPONSE_CODE_UNSUPPORTED_OPERATION chat.BroChatResponseCode = 100
+
+// The default token type used for authorization.
+const defaultTokenType = "Bearer"
+
+// Client is a client for the BroChat API operations not covered by chat.BroChatClient.
+type Client struct {
+	httpClient *http.Client
+	baseUrl    string
+}
+
+// NewClient creates a new Client with the given http client and base url.
+func NewClient(httpClient *http.Client, baseUrl string) *Client {
+	return &Client{
+		httpClient: httpClient,
+		baseUrl:    baseUrl,
+	}
+}
+
+// UpdateRoomRequest is a request to change the settings of a room.
+type UpdateRoomRequest struct {
+	// The new name of the room
+	Name string `json:"name"`
+	// The new membership model of the room
+	MembershipModel chat.RoomMembershipModel `json:"membership_model"`
+}
+
+// TransferRoomOwnershipRequest is a request to make another member the owner of a room.
+type TransferRoomOwnershipRequest struct {
+	// The ID of the member who will become the owner
+	NewOwnerUserId string `json:"new_owner_user_id"`
+}
+
+// UpdateRoom changes the name and membership model of a room. Only the room owner may update a room.
+func (c *Client) UpdateRoom(ctx context.Context, accessToken string, roomId string, request UpdateRoomRequest) chat.BroChatClientContentResult[chat.Room] {
+	suffix := strings.Replace(UPDATE_ROOM_URL_SUFFIX, ":roomId", roomId, 1)
+	return doWithContent[chat.Room](ctx, c, http.MethodPut, suffix, accessToken, request, http.StatusOK)
+}
+
+// DeleteRoom deletes a room. Only the room owner may delete a room.
+func (c *Client) DeleteRoom(ctx context.Context, accessToken string, roomId string) chat.BroChatClientResult {
+	suffix := strings.Replace(DELETE_ROOM_URL_SUFFIX, ":roomId", roomId, 1)
+	return c.do(ctx, http.MethodDelete, suffix, accessToken, nil, http.StatusNoContent)
+}
+
+// InviteUserToRoom invites a user to a room.
+func (c *Client) InviteUserToRoom(ctx context.Context, accessToken string, request chat.InviteUserToRoomRequest) chat.BroChatClientResult {
+	suffix := strings.Replace(INVITE_USER_TO_ROOM_URL_SUFFIX, ":roomId", request.RoomId, 1)
+	return c.do(ctx, http.MethodPost, suffix, accessToken, request, http.StatusNoContent)
+}
+
+// RemoveRoomMember removes a member from a room. Only the room owner may remove members.
+func (c *Client) RemoveRoomMember(ctx context.Context, accessToken string, roomId string, userId string) chat.BroChatClientResult {
+	suffix := strings.Replace(REMOVE_ROOM_MEMBER_URL_SUFFIX, ":roomId", roomId, 1)
+	suffix = strings.Replace(suffix, ":userId", userId, 1)
+	return c.do(ctx, http.MethodDelete, suffix, accessToken, nil, http.StatusNoContent)
+}
+
+// TransferRoomOwnership makes another member of the room its owner. Only the room owner may transfer ownership.
+func (c *Client) TransferRoomOwnership(ctx context.Context, accessToken string, roomId string, request TransferRoomOwnershipRequest) chat.BroChatClientResult {
+	suffix := strings.Replace(TRANSFER_ROOM_OWNERSHIP_URL_SUFFIX, ":roomId", roomId, 1)
+	return c.do(ctx, http.MethodPut, suffix, accessToken, request, http.StatusNoContent)
+}
+
+// LeaveRoom removes the user from a room. The owner of a room may not leave it.
+func (c *Client) LeaveRoom(ctx context.Context, accessToken string, roomId string) chat.BroChatClientResult {
+	suffix := strings.Replace(LEAVE_ROOM_URL_SUFFIX, ":roomId", roomId, 1)
+	return c.do(ctx, http.MethodPost, suffix, accessToken, nil, http.StatusNoContent)
+}
+
+// do sends a request which has no response content.
+func (c *Client) do(ctx context.Context, method, suffix, accessToken string, body interface{}, expectedStatusCode int) chat.BroChatClientResult {
+	res, result, ok := c.send(ctx, method, suffix, accessToken, body)
+
+	if !ok {
+		return result
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != expectedStatusCode {
+		return handleUnsuccessfulStatusCode(res)
+	}
+
+	return chat.BroChatClientResult{ResponseCode: chat.BROCHAT_RESPONSE_CODE_SUCCESS}
+}
+
+// doWithContent sends a request and decodes the response content.
+func doWithContent[T any](ctx context.Context, c *Client, method, suffix, accessToken string, body interface{}, expectedStatusCode int) chat.BroChatClientContentResult[T] {
+	var content T
+
+	res, result, ok := c.send(ctx, method, suffix, accessToken, body)
+
+	if !ok {
+		return chat.BroChatClientContentResult[T]{BroChatClientResult: result, Content: content}
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != expectedStatusCode {
+		return chat.BroChatClientContentResult[T]{BroChatClientResult: handleUnsuccessfulStatusCode(res), Content: content}
+	}
+
+	err := json.NewDecoder(res.Body).Decode(&content)
+
+	if err != nil {
+		return chat.BroChatClientContentResult[T]{
+			BroChatClientResult: chat.BroChatClientResult{ResponseCode: chat.BROCHAT_RESPONSE_CODE_UNEXEPECTED_RESPONSE_ERROR},
+			Content:             content,
+		}
+	}
+
+	return chat.BroChatClientContentResult[T]{
+		BroChatClientResult: chat.BroChatClientResult{ResponseCode: chat.BROCHAT_RESPONSE_CODE_SUCCESS},
+		Content:             content,
+	}
+}
+
+// send builds and sends an authorized request which is cancelled when ctx is done. If the request could not be sent the returned bool will be false
+// and the returned result will describe the failure.
+func (c *Client) send(ctx context.Context, method, suffix, accessToken string, body interface{}) (*http.Response, chat.BroChatClientResult, bool) {
+	requestUrl, err := buildUrl(c.baseUrl, suffix)
+
+	if err != nil {
+		return nil, chat.BroChatClientResult{ResponseCode: chat.BROCHAT_RESPONSE_CODE_INVALID_HOST_ADDRESS}, false
+	}
+
+	var bodyReader *bytes.Reader
+
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+
+		if err != nil {
+			return nil, chat.BroChatClientResult{ResponseCode: chat.BROCHAT_RESPONSE_CODE_REQUEST_FORMATTING_ERROR}, false
+		}
+
+		bodyReader = bytes.NewReader(bodyBytes)
+	} else {
+		bodyReader = bytes.NewReader(nil)
+	}
+
+	// Create a new request using http
+	req, err := http.NewRequestWithContext(ctx, method, requestUrl, bodyReader)
+
+	if err != nil {
+		return nil, chat.BroChatClientResult{ResponseCode: chat.BROCHAT_RESPONSE_CODE_REQUEST_FORMATTING_ERROR}, false
+	}
+
+	// Set authorization header to the req
+	req.Header.Set("Authorization", fmt.Sprintf("%s %s", defaultTokenType, accessToken))
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	// Send req using http Client
+	res, err := c.httpClient.Do(req)
+
+	if err != nil {
+		if err, ok := err.(net.Error); ok && err.Timeout() {
+			// If it was a timeout error
+			return nil, chat.BroChatClientResult{ResponseCode: chat.BROCHAT_RESPONSE_CODE_CONNECTION_TIMEOUT_ERROR}, false
+		}
+
+		return nil, chat.BroChatClientResult{ResponseCode: chat.BROCHAT_RESPONSE_CODE_GENERIC_CONNECTION_ERROR}, false
+	}
+
+	return res, chat.BroChatClientResult{}, true
+}
+
+// buildUrl is a helper function that builds a url from a base url and a suffix.
+func buildUrl(baseUrl, suffix string) (string, error) {
+	base, err := url.Parse(baseUrl)
+
+	if err != nil {
+		return "", err
+	}
+
+	suffixUrl, err := url.Parse(suffix)
+
+	if err != nil {
+		return "", err
+	}
+
+	return base.ResolveReference(suffixUrl).String(), nil
+}
+
+// handleUnsuccessfulStatusCode is a helper function that handles the response from the server when the response is not successful.
+func handleUnsuccessfulStatusCode(res *http.Response) chat.BroChatClientResult {
+	switch res.StatusCode {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return chat.BroChatClientResult{ResponseCode: RESPONSE_CODE_UNSUPPORTED_OPERATION}
+	}
+
+	var serverSideErr chat.BroChatError
+
+	err := json.NewDecoder(res.Body).Decode(&serverSideErr)
+
+	if err != nil {
+		switch res.StatusCode {
+		case http.StatusUnauthorized:
+			return chat.BroChatClientResult{ResponseCode: chat.BROCHAT_RESPONSE_CODE_UNAUTHORIZED_ERROR}
+		case http.StatusForbidden:
+			return chat.BroChatClientResult{ResponseCode: chat.BROCHAT_RESPONSE_CODE_FORBIDDEN_ERROR}
+		case http.StatusNotFound:
+			// The BroChat API describes missing resources with an error body, so a bare 404 means the route does not exist
+			return chat.BroChatClientResult{ResponseCode: RESPONSE_CODE_UNSUPPORTED_OPERATION}
+		case http.StatusBadRequest:
+			return chat.BroChatClientResult{ResponseCode: chat.BROCHAT_RESPONSE_CODE_VALIDATION_ERROR}
+		default:
+			return chat.BroChatClientResult{ResponseCode: chat.BROCHAT_RESPONSE_CODE_UNHANDLED_ERROR}
+		}
+	}
+
+	return chat.BroChatClientResult{ResponseCode: serverSideErr.Code, ErrorDetails: serverSideErr.ErrorDetails}
+}
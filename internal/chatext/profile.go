@@ -0,0 +1,39 @@
+package chatext
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/dmars8047/brolib/chat"
+)
+
+const (
+	GET_PROFILE_URL_SUFFIX    = "/api/brochat/users/me/profile"
+	UPDATE_PROFILE_URL_SUFFIX = "/api/brochat/users/me/profile"
+)
+
+// UserProfile is the part of a user's profile which the user can change.
+type UserProfile struct {
+	// A short message shown to the user's friends
+	StatusMessage string `json:"status_message"`
+	// True if the user has marked themselves as away
+	IsAway bool `json:"is_away"`
+}
+
+// UpdateProfileRequest is a request to change the user's profile.
+type UpdateProfileRequest struct {
+	// The new status message. An empty string clears the status message.
+	StatusMessage string `json:"status_message"`
+	// The new away state
+	IsAway bool `json:"is_away"`
+}
+
+// GetProfile returns the profile of the user making the request.
+func (c *Client) GetProfile(ctx context.Context, accessToken string) chat.BroChatClientContentResult[UserProfile] {
+	return doWithContent[UserProfile](ctx, c, http.MethodGet, GET_PROFILE_URL_SUFFIX, accessToken, nil, http.StatusOK)
+}
+
+// UpdateProfile changes the profile of the user making the request.
+func (c *Client) UpdateProfile(ctx context.Context, accessToken string, request UpdateProfileRequest) chat.BroChatClientResult {
+	return c.do(ctx, http.MethodPut, UPDATE_PROFILE_URL_SUFFIX, accessToken, request, http.StatusNoContent)
+}
@@ -0,0 +1,53 @@
+package chatext
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/dmars8047/brolib/chat"
+)
+
+const (
+	REMOVE_FRIEND_URL_SUFFIX          = "/api/brochat/users/friends/:userId"
+	DECLINE_FRIEND_REQUEST_URL_SUFFIX = "/api/brochat/users/friends/requests/received/:userId"
+	CANCEL_FRIEND_REQUEST_URL_SUFFIX  = "/api/brochat/users/friends/requests/sent/:userId"
+	GET_BLOCKED_USERS_URL_SUFFIX      = "/api/brochat/users/blocked"
+	BLOCK_USER_URL_SUFFIX             = "/api/brochat/users/blocked/:userId"
+	UNBLOCK_USER_URL_SUFFIX           = "/api/brochat/users/blocked/:userId"
+)
+
+// RemoveFriend ends the friendship between the user and one of their friends.
+func (c *Client) RemoveFriend(ctx context.Context, accessToken string, userId string) chat.BroChatClientResult {
+	suffix := strings.Replace(REMOVE_FRIEND_URL_SUFFIX, ":userId", userId, 1)
+	return c.do(ctx, http.MethodDelete, suffix, accessToken, nil, http.StatusNoContent)
+}
+
+// DeclineFriendRequest declines a friend request the user has received from the initiating user.
+func (c *Client) DeclineFriendRequest(ctx context.Context, accessToken string, initiatingUserId string) chat.BroChatClientResult {
+	suffix := strings.Replace(DECLINE_FRIEND_REQUEST_URL_SUFFIX, ":userId", initiatingUserId, 1)
+	return c.do(ctx, http.MethodDelete, suffix, accessToken, nil, http.StatusNoContent)
+}
+
+// CancelFriendRequest withdraws a friend request the user has sent to the requested user.
+func (c *Client) CancelFriendRequest(ctx context.Context, accessToken string, requestedUserId string) chat.BroChatClientResult {
+	suffix := strings.Replace(CANCEL_FRIEND_REQUEST_URL_SUFFIX, ":userId", requestedUserId, 1)
+	return c.do(ctx, http.MethodDelete, suffix, accessToken, nil, http.StatusNoContent)
+}
+
+// GetBlockedUsers returns the users the user has blocked.
+func (c *Client) GetBlockedUsers(ctx context.Context, accessToken string) chat.BroChatClientContentResult[[]chat.UserInfo] {
+	return doWithContent[[]chat.UserInfo](ctx, c, http.MethodGet, GET_BLOCKED_USERS_URL_SUFFIX, accessToken, nil, http.StatusOK)
+}
+
+// BlockUser blocks a user. Any relationship with the user is ended and they can no longer send the user friend requests.
+func (c *Client) BlockUser(ctx context.Context, accessToken string, userId string) chat.BroChatClientResult {
+	suffix := strings.Replace(BLOCK_USER_URL_SUFFIX, ":userId", userId, 1)
+	return c.do(ctx, http.MethodPut, suffix, accessToken, nil, http.StatusNoContent)
+}
+
+// UnblockUser unblocks a previously blocked user.
+func (c *Client) UnblockUser(ctx context.Context, accessToken string, userId string) chat.BroChatClientResult {
+	suffix := strings.Replace(UNBLOCK_USER_URL_SUFFIX, ":userId", userId, 1)
+	return c.do(ctx, http.MethodDelete, suffix, accessToken, nil, http.StatusNoContent)
+}
@@ -1,16 +1,117 @@
 package config
 
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/dmars8047/broterm/internal/logging"
+)
+
 const DEFAULT_CONFIG_DIRECTORY_NAME = ".broterm"
 const CONFIG_FILE_NAME = "config.json"
+const EXPORT_DIRECTORY_NAME = "exports"
+const THEMES_DIRECTORY_NAME = "themes"
+const KEYBINDINGS_FILE_NAME = "keybindings.json"
+const LOGS_DIRECTORY_NAME = "logs"
 
 type ConfigSettings struct {
-	Theme          string `json:"theme"`
-	LoggingEnabled bool   `json:"logging_enabled"`
+	Theme          string          `json:"theme"`
+	LoggingEnabled bool            `json:"logging_enabled"`
+	LogLevel       string          `json:"log_level"`
+	VimMode        bool            `json:"vim_mode"`
+	Network        NetworkSettings `json:"network"`
+}
+
+// NetworkSettings configure how the application connects to the server, for networks which require a proxy or a private certificate authority.
+// Each setting which is left empty falls back to its environment variable.
+type NetworkSettings struct {
+	// ProxyURL is the http, socks5 or socks5h proxy every connection is made through.
+	// Falls back to BROTERM_PROXY and then to the standard HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+	ProxyURL string `json:"proxy_url,omitempty"`
+	// CABundlePaths are PEM files of certificate authorities which are trusted along with the system's. Falls back to BROTERM_CA_BUNDLE,
+	// which is a list of paths separated by the OS path list separator.
+	CABundlePaths []string `json:"ca_bundle_paths,omitempty"`
+	// ClientCertPath is the PEM certificate presented to the server for mutual TLS. Falls back to BROTERM_CLIENT_CERT.
+	ClientCertPath string `json:"client_cert_path,omitempty"`
+	// ClientKeyPath is the PEM private key of the client certificate. Falls back to BROTERM_CLIENT_KEY.
+	ClientKeyPath string `json:"client_key_path,omitempty"`
+}
+
+const (
+	PROXY_ENV_VAR       = "BROTERM_PROXY"
+	CA_BUNDLE_ENV_VAR   = "BROTERM_CA_BUNDLE"
+	CLIENT_CERT_ENV_VAR = "BROTERM_CLIENT_CERT"
+	CLIENT_KEY_ENV_VAR  = "BROTERM_CLIENT_KEY"
+)
+
+// WithEnvironment returns the settings with each empty setting filled in from its environment variable
+func (settings NetworkSettings) WithEnvironment() NetworkSettings {
+	if settings.ProxyURL == "" {
+		settings.ProxyURL = os.Getenv(PROXY_ENV_VAR)
+	}
+
+	if len(settings.CABundlePaths) == 0 {
+		for _, path := range filepath.SplitList(os.Getenv(CA_BUNDLE_ENV_VAR)) {
+			if path != "" {
+				settings.CABundlePaths = append(settings.CABundlePaths, path)
+			}
+		}
+	}
+
+	if settings.ClientCertPath == "" {
+		settings.ClientCertPath = os.Getenv(CLIENT_CERT_ENV_VAR)
+	}
+
+	if settings.ClientKeyPath == "" {
+		settings.ClientKeyPath = os.Getenv(CLIENT_KEY_ENV_VAR)
+	}
+
+	return settings
 }
 
 func NewConfigSettings() *ConfigSettings {
 	return &ConfigSettings{
 		Theme:          "default",
 		LoggingEnabled: true,
+		LogLevel:       logging.DEFAULT_LEVEL,
+	}
+}
+
+// Save writes the settings to the config file in the config directory
+func (settings *ConfigSettings) Save() error {
+	bytesToSave, err := json.Marshal(settings)
+
+	if err != nil {
+		return err
+	}
+
+	filePath, err := configFilePath(CONFIG_FILE_NAME)
+
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+
+	if err != nil {
+		return err
 	}
+
+	return os.WriteFile(filePath, bytesToSave, 0644)
+}
+
+// ThemesDirectoryPath returns the path of the directory custom theme files are loaded from
+func ThemesDirectoryPath() (string, error) {
+	return configFilePath(THEMES_DIRECTORY_NAME)
+}
+
+// LogsDirectoryPath returns the path of the directory log files are written to
+func LogsDirectoryPath() (string, error) {
+	return configFilePath(LOGS_DIRECTORY_NAME)
+}
+
+// KeybindingsFilePath returns the path of the file keybinding overrides are loaded from
+func KeybindingsFilePath() (string, error) {
+	return configFilePath(KEYBINDINGS_FILE_NAME)
 }
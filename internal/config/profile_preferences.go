@@ -0,0 +1,116 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const PROFILE_PREFERENCES_FILE_NAME = "profile_preferences.json"
+
+// ChatColors are the colors a user can choose for their chat label. Values are tcell color names.
+var ChatColors = []string{"red", "orange", "yellow", "lime", "green", "aqua", "blue", "fuchsia", "purple", "pink", "white", "silver"}
+
+// ProfilePreferences are the client side profile preferences of each user who has logged in on this machine.
+// Preferences are keyed by user id and stored in the config directory.
+type ProfilePreferences struct {
+	// ChatColors is the preferred chat label color of each user. Users without a preference use the automatic color.
+	ChatColors map[string]string `json:"chat_colors"`
+	mu         sync.RWMutex
+}
+
+// NewProfilePreferences creates an empty set of profile preferences
+func NewProfilePreferences() *ProfilePreferences {
+	return &ProfilePreferences{
+		ChatColors: make(map[string]string),
+	}
+}
+
+// LoadProfilePreferences reads the profile preferences from the config directory.
+// If the preferences file does not exist an empty set of preferences is returned.
+func LoadProfilePreferences() (*ProfilePreferences, error) {
+	prefs := NewProfilePreferences()
+
+	filePath, err := configFilePath(PROFILE_PREFERENCES_FILE_NAME)
+
+	if err != nil {
+		return prefs, err
+	}
+
+	prefBytes, err := os.ReadFile(filePath)
+
+	if os.IsNotExist(err) {
+		return prefs, nil
+	} else if err != nil {
+		return prefs, err
+	}
+
+	err = json.Unmarshal(prefBytes, prefs)
+
+	if err != nil {
+		return NewProfilePreferences(), err
+	}
+
+	if prefs.ChatColors == nil {
+		prefs.ChatColors = make(map[string]string)
+	}
+
+	return prefs, nil
+}
+
+// Save writes the profile preferences to the config directory
+func (prefs *ProfilePreferences) Save() error {
+	prefs.mu.RLock()
+	bytesToSave, err := json.Marshal(prefs)
+	prefs.mu.RUnlock()
+
+	if err != nil {
+		return err
+	}
+
+	filePath, err := configFilePath(PROFILE_PREFERENCES_FILE_NAME)
+
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, bytesToSave, 0644)
+}
+
+// ChatColor returns the preferred chat label color of the user. An empty string is returned if the user has no preference.
+func (prefs *ProfilePreferences) ChatColor(userId string) string {
+	prefs.mu.RLock()
+	defer prefs.mu.RUnlock()
+	return prefs.ChatColors[userId]
+}
+
+// SetChatColor sets the preferred chat label color of the user. An empty color removes the preference.
+func (prefs *ProfilePreferences) SetChatColor(userId string, color string) {
+	prefs.mu.Lock()
+	defer prefs.mu.Unlock()
+
+	if color == "" {
+		delete(prefs.ChatColors, userId)
+		return
+	}
+
+	prefs.ChatColors[userId] = color
+}
+
+// configFilePath returns the path of a file in the config directory
+func configFilePath(fileName string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(homeDir, DEFAULT_CONFIG_DIRECTORY_NAME, fileName), nil
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const ROOM_PREFERENCES_FILE_NAME = "room_preferences.json"
+
+// RoomPreferences are the client side preferences a user has for the rooms they are a member of.
+// Preferences are keyed by room id and stored in the config directory.
+type RoomPreferences struct {
+	Muted    map[string]bool `json:"muted"`
+	Pinned   map[string]bool `json:"pinned"`
+	Archived map[string]bool `json:"archived"`
+	mu       sync.RWMutex
+}
+
+// NewRoomPreferences creates an empty set of room preferences
+func NewRoomPreferences() *RoomPreferences {
+	return &RoomPreferences{
+		Muted:    make(map[string]bool),
+		Pinned:   make(map[string]bool),
+		Archived: make(map[string]bool),
+	}
+}
+
+// LoadRoomPreferences reads the room preferences from the config directory.
+// If the preferences file does not exist an empty set of preferences is returned.
+func LoadRoomPreferences() (*RoomPreferences, error) {
+	prefs := NewRoomPreferences()
+
+	filePath, err := configFilePath(ROOM_PREFERENCES_FILE_NAME)
+
+	if err != nil {
+		return prefs, err
+	}
+
+	prefBytes, err := os.ReadFile(filePath)
+
+	if os.IsNotExist(err) {
+		return prefs, nil
+	} else if err != nil {
+		return prefs, err
+	}
+
+	err = json.Unmarshal(prefBytes, prefs)
+
+	if err != nil {
+		return NewRoomPreferences(), err
+	}
+
+	// Files written by older versions may be missing a section
+	if prefs.Muted == nil {
+		prefs.Muted = make(map[string]bool)
+	}
+
+	if prefs.Pinned == nil {
+		prefs.Pinned = make(map[string]bool)
+	}
+
+	if prefs.Archived == nil {
+		prefs.Archived = make(map[string]bool)
+	}
+
+	return prefs, nil
+}
+
+// Save writes the room preferences to the config directory
+func (prefs *RoomPreferences) Save() error {
+	prefs.mu.RLock()
+	bytesToSave, err := json.Marshal(prefs)
+	prefs.mu.RUnlock()
+
+	if err != nil {
+		return err
+	}
+
+	filePath, err := configFilePath(ROOM_PREFERENCES_FILE_NAME)
+
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, bytesToSave, 0644)
+}
+
+// IsMuted returns true if the room is muted. Muted rooms are not highlighted in the room list when they receive messages.
+func (prefs *RoomPreferences) IsMuted(roomId string) bool {
+	prefs.mu.RLock()
+	defer prefs.mu.RUnlock()
+	return prefs.Muted[roomId]
+}
+
+// IsPinned returns true if the room is pinned
+func (prefs *RoomPreferences) IsPinned(roomId string) bool {
+	prefs.mu.RLock()
+	defer prefs.mu.RUnlock()
+	return prefs.Pinned[roomId]
+}
+
+// IsArchived returns true if the room is archived
+func (prefs *RoomPreferences) IsArchived(roomId string) bool {
+	prefs.mu.RLock()
+	defer prefs.mu.RUnlock()
+	return prefs.Archived[roomId]
+}
+
+// ToggleMuted mutes or unmutes the room. Returns the new muted state.
+func (prefs *RoomPreferences) ToggleMuted(roomId string) bool {
+	return prefs.toggle(prefs.Muted, roomId)
+}
+
+// TogglePinned pins or unpins the room. Returns the new pinned state.
+func (prefs *RoomPreferences) TogglePinned(roomId string) bool {
+	return prefs.toggle(prefs.Pinned, roomId)
+}
+
+// ToggleArchived archives or unarchives the room. Returns the new archived state.
+func (prefs *RoomPreferences) ToggleArchived(roomId string) bool {
+	return prefs.toggle(prefs.Archived, roomId)
+}
+
+// Forget removes all preferences for the room. Used when the user is no longer a member of the room.
+func (prefs *RoomPreferences) Forget(roomId string) {
+	prefs.mu.Lock()
+	defer prefs.mu.Unlock()
+
+	delete(prefs.Muted, roomId)
+	delete(prefs.Pinned, roomId)
+	delete(prefs.Archived, roomId)
+}
+
+func (prefs *RoomPreferences) toggle(values map[string]bool, roomId string) bool {
+	prefs.mu.Lock()
+	defer prefs.mu.Unlock()
+
+	if values[roomId] {
+		delete(values, roomId)
+		return false
+	}
+
+	values[roomId] = true
+	return true
+}
@@ -0,0 +1,322 @@
+package keymap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Action is a named action which can be bound to one or more keys
+type Action string
+
+// Actions shared by many pages
+const (
+	ACTION_BACK     Action = "back"
+	ACTION_NEXT     Action = "next"
+	ACTION_PREVIOUS Action = "previous"
+	ACTION_SEARCH   Action = "search"
+
+	ACTION_NEXT_PAGE     Action = "page.next"
+	ACTION_PREVIOUS_PAGE Action = "page.previous"
+
+	ACTION_COMMAND_PALETTE Action = "palette.open"
+
+	ACTION_HISTORY_BACK    Action = "history.back"
+	ACTION_HISTORY_FORWARD Action = "history.forward"
+)
+
+// Actions for the button menus on the welcome and home pages
+const (
+	ACTION_MENU_LEFT  Action = "menu.left"
+	ACTION_MENU_RIGHT Action = "menu.right"
+)
+
+// Actions for the login page
+const (
+	ACTION_FORGOT_PASSWORD Action = "login.forgot_password"
+)
+
+// Actions for the room list and room finder pages
+const (
+	ACTION_CREATE_ROOM     Action = "rooms.create"
+	ACTION_FIND_ROOM       Action = "rooms.find"
+	ACTION_ADMINISTER_ROOM Action = "rooms.administer"
+	ACTION_LEAVE_ROOM      Action = "rooms.leave"
+	ACTION_MUTE_ROOM       Action = "rooms.mute"
+	ACTION_PIN_ROOM        Action = "rooms.pin"
+	ACTION_ARCHIVE_ROOM    Action = "rooms.archive"
+	ACTION_VIEW_ARCHIVED   Action = "rooms.view_archived"
+	ACTION_SORT_ROOMS      Action = "rooms.sort"
+)
+
+// Actions for the room administration page
+const (
+	ACTION_REMOVE_MEMBER      Action = "admin.remove_member"
+	ACTION_TRANSFER_OWNERSHIP Action = "admin.transfer_ownership"
+)
+
+// Actions for the friends list, find a friend and friend request pages
+const (
+	ACTION_FIND_FRIEND     Action = "friends.find"
+	ACTION_VIEW_PENDING    Action = "friends.pending"
+	ACTION_VIEW_BLOCKED    Action = "friends.blocked"
+	ACTION_UNFRIEND        Action = "friends.unfriend"
+	ACTION_BLOCK_USER      Action = "friends.block"
+	ACTION_LOAD_MORE_USERS Action = "friends.load_more"
+	ACTION_DECLINE_REQUEST Action = "requests.decline"
+	ACTION_CANCEL_REQUEST  Action = "requests.cancel"
+)
+
+// Actions for the chat page and its member panel
+const (
+	ACTION_TOGGLE_MEMBERS      Action = "chat.members"
+	ACTION_SCROLL_UP           Action = "chat.scroll_up"
+	ACTION_SCROLL_DOWN         Action = "chat.scroll_down"
+	ACTION_COMPLETE_COMMAND    Action = "chat.complete"
+	ACTION_SEND_MESSAGE        Action = "chat.send"
+	ACTION_MESSAGE_MEMBER      Action = "members.message"
+	ACTION_FRIEND_REQUEST      Action = "members.friend_request"
+	ACTION_WHO_IS              Action = "members.who_is"
+	ACTION_CLOSE_MEMBERS_PANEL Action = "members.close"
+)
+
+// Actions for vim navigation mode. They are only used when vim navigation is enabled in the application settings.
+const (
+	ACTION_VIM_DOWN           Action = "vim.down"
+	ACTION_VIM_UP             Action = "vim.up"
+	ACTION_VIM_TOP            Action = "vim.top" // The key must be pressed twice, as with gg in vim
+	ACTION_VIM_BOTTOM         Action = "vim.bottom"
+	ACTION_VIM_HALF_PAGE_DOWN Action = "vim.half_page_down"
+	ACTION_VIM_HALF_PAGE_UP   Action = "vim.half_page_up"
+	ACTION_VIM_INSERT         Action = "vim.insert"
+)
+
+// defaultBindings are the keys each action is bound to unless overridden by the keybindings file
+var defaultBindings = map[Action][]string{
+	ACTION_BACK:     {"esc"},
+	ACTION_NEXT:     {"tab"},
+	ACTION_PREVIOUS: {"shift+tab"},
+	ACTION_SEARCH:   {"/"},
+
+	ACTION_NEXT_PAGE:     {"n"},
+	ACTION_PREVIOUS_PAGE: {"p"},
+
+	ACTION_COMMAND_PALETTE: {"ctrl+k"},
+
+	ACTION_HISTORY_BACK:    {"alt+left"},
+	ACTION_HISTORY_FORWARD: {"alt+right"},
+
+	ACTION_MENU_LEFT:  {"h", "left", "shift+tab"},
+	ACTION_MENU_RIGHT: {"l", "right", "tab"},
+
+	ACTION_FORGOT_PASSWORD: {"ctrl+f"},
+
+	ACTION_CREATE_ROOM:     {"n"},
+	ACTION_FIND_ROOM:       {"f"},
+	ACTION_ADMINISTER_ROOM: {"a"},
+	ACTION_LEAVE_ROOM:      {"l"},
+	ACTION_MUTE_ROOM:       {"m"},
+	ACTION_PIN_ROOM:        {"p"},
+	ACTION_ARCHIVE_ROOM:    {"x"},
+	ACTION_VIEW_ARCHIVED:   {"v"},
+	ACTION_SORT_ROOMS:      {"s"},
+
+	ACTION_REMOVE_MEMBER:      {"r"},
+	ACTION_TRANSFER_OWNERSHIP: {"o"},
+
+	ACTION_FIND_FRIEND:     {"f"},
+	ACTION_VIEW_PENDING:    {"p"},
+	ACTION_VIEW_BLOCKED:    {"b"},
+	ACTION_UNFRIEND:        {"u"},
+	ACTION_BLOCK_USER:      {"x"},
+	ACTION_LOAD_MORE_USERS: {"m"},
+	ACTION_DECLINE_REQUEST: {"d"},
+	ACTION_CANCEL_REQUEST:  {"c"},
+
+	ACTION_TOGGLE_MEMBERS:      {"ctrl+t"},
+	ACTION_SCROLL_UP:           {"pgup"},
+	ACTION_SCROLL_DOWN:         {"pgdn"},
+	ACTION_COMPLETE_COMMAND:    {"tab"},
+	ACTION_SEND_MESSAGE:        {"enter"},
+	ACTION_MESSAGE_MEMBER:      {"enter", "d"},
+	ACTION_FRIEND_REQUEST:      {"f"},
+	ACTION_WHO_IS:              {"w"},
+	ACTION_CLOSE_MEMBERS_PANEL: {"esc", "ctrl+t"},
+
+	ACTION_VIM_DOWN:           {"j"},
+	ACTION_VIM_UP:             {"k"},
+	ACTION_VIM_TOP:            {"g"},
+	ACTION_VIM_BOTTOM:         {"G"},
+	ACTION_VIM_HALF_PAGE_DOWN: {"ctrl+d"},
+	ACTION_VIM_HALF_PAGE_UP:   {"ctrl+u"},
+	ACTION_VIM_INSERT:         {"i"},
+}
+
+// Keymap resolves key events to actions
+type Keymap struct {
+	bindings map[Action][]Binding
+}
+
+// Default returns the keymap with every action bound to its default keys
+func Default() *Keymap {
+	km := &Keymap{
+		bindings: make(map[Action][]Binding, len(defaultBindings)),
+	}
+
+	for action, keys := range defaultBindings {
+		bindings := make([]Binding, 0, len(keys))
+
+		for _, key := range keys {
+			binding, err := ParseBinding(key)
+
+			if err != nil {
+				panic(fmt.Sprintf("invalid default binding for %s: %v", action, err))
+			}
+
+			bindings = append(bindings, binding)
+		}
+
+		km.bindings[action] = bindings
+	}
+
+	return km
+}
+
+// Load returns the default keymap with the overrides from the keybindings file applied.
+// The file is a JSON object of action names to a key or a list of keys, for example:
+//
+//	{ "login.forgot_password": "f2", "rooms.find": ["f", "/"] }
+//
+// An override replaces every default key for the action. Overrides which cannot be applied are skipped and reported in the returned errors.
+// A missing file is not an error.
+func Load(filePath string) (*Keymap, []error) {
+	km := Default()
+
+	fileBytes, err := os.ReadFile(filePath)
+
+	if os.IsNotExist(err) {
+		return km, nil
+	} else if err != nil {
+		return km, []error{err}
+	}
+
+	var overrides map[string]keyList
+
+	err = json.Unmarshal(fileBytes, &overrides)
+
+	if err != nil {
+		return km, []error{err}
+	}
+
+	errs := make([]error, 0)
+
+	// Apply the overrides in a stable order so that errors are reported consistently
+	names := make([]string, 0, len(overrides))
+
+	for name := range overrides {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		action := Action(name)
+
+		if _, ok := defaultBindings[action]; !ok {
+			errs = append(errs, fmt.Errorf("unknown action '%s'", name))
+			continue
+		}
+
+		keys := overrides[name]
+
+		if len(keys) == 0 {
+			errs = append(errs, fmt.Errorf("action '%s' must be bound to at least one key", name))
+			continue
+		}
+
+		bindings := make([]Binding, 0, len(keys))
+		valid := true
+
+		for _, key := range keys {
+			binding, err := ParseBinding(key)
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("action '%s': %w", name, err))
+				valid = false
+				break
+			}
+
+			bindings = append(bindings, binding)
+		}
+
+		if valid {
+			km.bindings[action] = bindings
+		}
+	}
+
+	return km, errs
+}
+
+// Is returns true if the key event is bound to the action
+func (km *Keymap) Is(event *tcell.EventKey, action Action) bool {
+	for _, binding := range km.bindings[action] {
+		if binding.Matches(event) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Match returns the first of the candidate actions the key event is bound to
+func (km *Keymap) Match(event *tcell.EventKey, candidates ...Action) (Action, bool) {
+	for _, action := range candidates {
+		if km.Is(event, action) {
+			return action, true
+		}
+	}
+
+	return "", false
+}
+
+// Label returns the keys bound to the action for display, for example "enter/d"
+func (km *Keymap) Label(action Action) string {
+	bindings := km.bindings[action]
+	names := make([]string, 0, len(bindings))
+
+	for _, binding := range bindings {
+		names = append(names, binding.String())
+	}
+
+	return strings.Join(names, "/")
+}
+
+// Hint returns the instruction text for the action in the form used by page instructions, for example "(f) Find a Room"
+func (km *Keymap) Hint(action Action, description string) string {
+	return fmt.Sprintf("(%s) %s", km.Label(action), description)
+}
+
+// keyList is a list of keys which may be written in the keybindings file as a single key or as a list of keys
+type keyList []string
+
+func (keys *keyList) UnmarshalJSON(data []byte) error {
+	var single string
+
+	if err := json.Unmarshal(data, &single); err == nil {
+		*keys = keyList{single}
+		return nil
+	}
+
+	var list []string
+
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("keys must be a string or a list of strings")
+	}
+
+	*keys = list
+	return nil
+}
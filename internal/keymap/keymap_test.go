@@ -0,0 +1,121 @@
+package keymap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeKeybindings(t *testing.T, content string) string {
+	t.Helper()
+
+	filePath := filepath.Join(t.TempDir(), "keybindings.json")
+
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return filePath
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantLabels map[Action]string
+		wantErrs   []string
+	}{
+		{
+			name:       "single key override",
+			content:    `{ "rooms.find": "ctrl+f" }`,
+			wantLabels: map[Action]string{ACTION_FIND_ROOM: "ctrl+f", ACTION_BACK: "esc"},
+		},
+		{
+			name:       "list of keys override",
+			content:    `{ "rooms.find": ["f", "/"], "history.back": "alt+h" }`,
+			wantLabels: map[Action]string{ACTION_FIND_ROOM: "f//", ACTION_HISTORY_BACK: "alt+h"},
+		},
+		{
+			name:       "unknown actions are reported and the rest applied",
+			content:    `{ "rooms.fnd": "x", "rooms.find": "y" }`,
+			wantLabels: map[Action]string{ACTION_FIND_ROOM: "y"},
+			wantErrs:   []string{"unknown action 'rooms.fnd'"},
+		},
+		{
+			name:       "invalid keys keep the defaults",
+			content:    `{ "rooms.find": ["g", "hyper+x"] }`,
+			wantLabels: map[Action]string{ACTION_FIND_ROOM: "f"},
+			wantErrs:   []string{"action 'rooms.find': 'hyper+x' is not a recognized key"},
+		},
+		{
+			name:       "empty key lists keep the defaults",
+			content:    `{ "rooms.find": [] }`,
+			wantLabels: map[Action]string{ACTION_FIND_ROOM: "f"},
+			wantErrs:   []string{"action 'rooms.find' must be bound to at least one key"},
+		},
+		{
+			name:       "keys which are not strings are reported",
+			content:    `{ "rooms.find": 5 }`,
+			wantLabels: map[Action]string{ACTION_FIND_ROOM: "f"},
+			wantErrs:   []string{"keys must be a string or a list of strings"},
+		},
+		{
+			name:       "malformed json is reported",
+			content:    `{ "rooms.find": `,
+			wantLabels: map[Action]string{ACTION_FIND_ROOM: "f"},
+			wantErrs:   []string{"unexpected end of JSON input"},
+		},
+		{
+			name:       "errors are reported in a stable order",
+			content:    `{ "zzz": "a", "aaa": "b" }`,
+			wantLabels: map[Action]string{ACTION_FIND_ROOM: "f"},
+			wantErrs:   []string{"unknown action 'aaa'", "unknown action 'zzz'"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			km, errs := Load(writeKeybindings(t, test.content))
+
+			if len(errs) != len(test.wantErrs) {
+				t.Fatalf("Load() returned the errors %v, want %d errors", errs, len(test.wantErrs))
+			}
+
+			for i, want := range test.wantErrs {
+				if !strings.Contains(errs[i].Error(), want) {
+					t.Errorf("error %d is %q, want it to contain %q", i, errs[i], want)
+				}
+			}
+
+			for action, want := range test.wantLabels {
+				if got := km.Label(action); got != want {
+					t.Errorf("Label(%s) = %q, want %q", action, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	km, errs := Load(filepath.Join(t.TempDir(), "keybindings.json"))
+
+	if len(errs) != 0 {
+		t.Fatalf("Load() returned the errors %v for a missing file, want none", errs)
+	}
+
+	if got := km.Label(ACTION_FIND_ROOM); got != "f" {
+		t.Fatalf("Label(%s) = %q, want the default %q", ACTION_FIND_ROOM, got, "f")
+	}
+}
+
+func TestDefaultBindingsAreValid(t *testing.T) {
+	// Default panics if a default binding cannot be parsed
+	km := Default()
+
+	for action := range defaultBindings {
+		if km.Label(action) == "" {
+			t.Errorf("%s has no default keys", action)
+		}
+	}
+}
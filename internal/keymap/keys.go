@@ -0,0 +1,123 @@
+package keymap
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// namedKeys maps the names used in keybinding files to tcell keys
+var namedKeys = map[string]tcell.Key{
+	"esc":       tcell.KeyEscape,
+	"escape":    tcell.KeyEscape,
+	"enter":     tcell.KeyEnter,
+	"tab":       tcell.KeyTab,
+	"backtab":   tcell.KeyBacktab,
+	"shift+tab": tcell.KeyBacktab,
+	"backspace": tcell.KeyBackspace2,
+	"delete":    tcell.KeyDelete,
+	"insert":    tcell.KeyInsert,
+	"home":      tcell.KeyHome,
+	"end":       tcell.KeyEnd,
+	"pgup":      tcell.KeyPgUp,
+	"pgdn":      tcell.KeyPgDn,
+	"up":        tcell.KeyUp,
+	"down":      tcell.KeyDown,
+	"left":      tcell.KeyLeft,
+	"right":     tcell.KeyRight,
+}
+
+// Binding is a single key combination
+type Binding struct {
+	name string
+	key  tcell.Key
+	ch   rune
+	mod  tcell.ModMask
+}
+
+// ParseBinding parses a key combination such as "f", "/", "esc", "pgup", "f5", "ctrl+f" or "alt+left".
+// Key names are case insensitive. Single characters are matched exactly so "N" and "n" are different keys.
+func ParseBinding(value string) (Binding, error) {
+	name := strings.TrimSpace(value)
+
+	if name == "" {
+		return Binding{}, fmt.Errorf("key must not be empty")
+	}
+
+	// A single character is a rune, including characters such as '+' which are otherwise used as separators
+	if utf8.RuneCountInString(name) == 1 {
+		ch, _ := utf8.DecodeRuneInString(name)
+		return Binding{name: name, key: tcell.KeyRune, ch: ch}, nil
+	}
+
+	binding := Binding{name: strings.ToLower(name)}
+	rest := binding.name
+
+	if strings.HasPrefix(rest, "alt+") && len(rest) > len("alt+") {
+		binding.mod = tcell.ModAlt
+		rest = strings.TrimPrefix(rest, "alt+")
+
+		// Keep the case of a character after the alt modifier
+		if utf8.RuneCountInString(rest) == 1 {
+			rest = name[len(name)-len(rest):]
+			binding.name = "alt+" + rest
+		}
+	}
+
+	if utf8.RuneCountInString(rest) == 1 {
+		binding.key = tcell.KeyRune
+		binding.ch, _ = utf8.DecodeRuneInString(rest)
+		return binding, nil
+	}
+
+	if rest == "space" {
+		binding.key = tcell.KeyRune
+		binding.ch = ' '
+		return binding, nil
+	}
+
+	if key, ok := namedKeys[rest]; ok {
+		binding.key = key
+		return binding, nil
+	}
+
+	// ctrl+a through ctrl+z
+	if strings.HasPrefix(rest, "ctrl+") && len(rest) == len("ctrl+")+1 {
+		letter := rest[len(rest)-1]
+
+		if letter >= 'a' && letter <= 'z' {
+			binding.key = tcell.KeyCtrlA + tcell.Key(letter-'a')
+			return binding, nil
+		}
+	}
+
+	// f1 through f12
+	var fn int
+
+	if _, err := fmt.Sscanf(rest, "f%d", &fn); err == nil && fmt.Sprintf("f%d", fn) == rest && fn >= 1 && fn <= 12 {
+		binding.key = tcell.KeyF1 + tcell.Key(fn-1)
+		return binding, nil
+	}
+
+	return Binding{}, fmt.Errorf("'%s' is not a recognized key", value)
+}
+
+// Matches returns true if the key event is this key combination
+func (binding Binding) Matches(event *tcell.EventKey) bool {
+	if event.Modifiers()&tcell.ModAlt != binding.mod {
+		return false
+	}
+
+	if binding.key == tcell.KeyRune {
+		return event.Key() == tcell.KeyRune && event.Rune() == binding.ch
+	}
+
+	return event.Key() == binding.key
+}
+
+// String returns the key combination as it is written in keybinding files
+func (binding Binding) String() string {
+	return binding.name
+}
@@ -0,0 +1,97 @@
+package keymap
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestParseBinding(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantName string
+		wantKey  tcell.Key
+		wantCh   rune
+		wantMod  tcell.ModMask
+	}{
+		// Rune keys keep their case
+		{input: "f", wantName: "f", wantKey: tcell.KeyRune, wantCh: 'f'},
+		{input: "G", wantName: "G", wantKey: tcell.KeyRune, wantCh: 'G'},
+		{input: "/", wantName: "/", wantKey: tcell.KeyRune, wantCh: '/'},
+		{input: "+", wantName: "+", wantKey: tcell.KeyRune, wantCh: '+'},
+		{input: "é", wantName: "é", wantKey: tcell.KeyRune, wantCh: 'é'},
+		{input: " q ", wantName: "q", wantKey: tcell.KeyRune, wantCh: 'q'},
+		{input: "space", wantName: "space", wantKey: tcell.KeyRune, wantCh: ' '},
+		// Named keys are case insensitive
+		{input: "esc", wantName: "esc", wantKey: tcell.KeyEscape},
+		{input: "Escape", wantName: "escape", wantKey: tcell.KeyEscape},
+		{input: "ENTER", wantName: "enter", wantKey: tcell.KeyEnter},
+		{input: "shift+tab", wantName: "shift+tab", wantKey: tcell.KeyBacktab},
+		{input: "pgup", wantName: "pgup", wantKey: tcell.KeyPgUp},
+		{input: "f1", wantName: "f1", wantKey: tcell.KeyF1},
+		{input: "F12", wantName: "f12", wantKey: tcell.KeyF12},
+		// Modifiers
+		{input: "ctrl+a", wantName: "ctrl+a", wantKey: tcell.KeyCtrlA},
+		{input: "Ctrl+Z", wantName: "ctrl+z", wantKey: tcell.KeyCtrlZ},
+		{input: "alt+left", wantName: "alt+left", wantKey: tcell.KeyLeft, wantMod: tcell.ModAlt},
+		{input: "alt+N", wantName: "alt+N", wantKey: tcell.KeyRune, wantCh: 'N', wantMod: tcell.ModAlt},
+		{input: "alt+f5", wantName: "alt+f5", wantKey: tcell.KeyF5, wantMod: tcell.ModAlt},
+		{input: "alt+ctrl+d", wantName: "alt+ctrl+d", wantKey: tcell.KeyCtrlD, wantMod: tcell.ModAlt},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			binding, err := ParseBinding(test.input)
+
+			if err != nil {
+				t.Fatalf("ParseBinding(%q) returned an error: %v", test.input, err)
+			}
+
+			if binding.String() != test.wantName || binding.key != test.wantKey || binding.ch != test.wantCh || binding.mod != test.wantMod {
+				t.Fatalf("ParseBinding(%q) = %q key %v rune %q mod %v, want %q key %v rune %q mod %v",
+					test.input, binding.String(), binding.key, binding.ch, binding.mod,
+					test.wantName, test.wantKey, test.wantCh, test.wantMod)
+			}
+		})
+	}
+}
+
+func TestParseBindingInvalid(t *testing.T) {
+	for _, input := range []string{"", "   ", "ab", "f0", "f13", "f01", "ctrl+", "ctrl+1", "ctrl+ab", "alt+", "alt+nope", "shift+a", "hyper+x"} {
+		t.Run(input, func(t *testing.T) {
+			if binding, err := ParseBinding(input); err == nil {
+				t.Fatalf("ParseBinding(%q) = %q, want an error", input, binding.String())
+			}
+		})
+	}
+}
+
+func TestBindingMatches(t *testing.T) {
+	tests := []struct {
+		binding string
+		event   *tcell.EventKey
+		want    bool
+	}{
+		{binding: "n", event: tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone), want: true},
+		{binding: "n", event: tcell.NewEventKey(tcell.KeyRune, 'N', tcell.ModNone), want: false},
+		{binding: "n", event: tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModAlt), want: false},
+		{binding: "alt+n", event: tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModAlt), want: true},
+		{binding: "alt+left", event: tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModAlt), want: true},
+		{binding: "alt+left", event: tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModNone), want: false},
+		{binding: "left", event: tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModAlt), want: false},
+		{binding: "ctrl+f", event: tcell.NewEventKey(tcell.KeyCtrlF, 0, tcell.ModCtrl), want: true},
+		{binding: "esc", event: tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone), want: true},
+	}
+
+	for _, test := range tests {
+		binding, err := ParseBinding(test.binding)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := binding.Matches(test.event); got != test.want {
+			t.Errorf("%q.Matches(%s) = %v, want %v", test.binding, test.event.Name(), got, test.want)
+		}
+	}
+}
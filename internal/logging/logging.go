@@ -0,0 +1,85 @@
+// Package logging configures the application's structured, leveled logger. Logs are written with log/slog to a rotating file
+// in the logs directory and access tokens, passwords and message content are redacted before they are written.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	LEVEL_DEBUG = "debug"
+	LEVEL_INFO  = "info"
+	LEVEL_WARN  = "warn"
+	LEVEL_ERROR = "error"
+)
+
+// DEFAULT_LEVEL is the level used when none is configured
+const DEFAULT_LEVEL = LEVEL_INFO
+
+// level is the minimum level written by the logger. It can be changed while the application is running.
+var level = new(slog.LevelVar)
+
+// Levels returns the names of the levels which can be configured, from the most to the least verbose
+func Levels() []string {
+	return []string{LEVEL_DEBUG, LEVEL_INFO, LEVEL_WARN, LEVEL_ERROR}
+}
+
+// ParseLevel returns the level with the name. Names are not case sensitive.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case LEVEL_DEBUG:
+		return slog.LevelDebug, nil
+	case LEVEL_INFO, "":
+		return slog.LevelInfo, nil
+	case LEVEL_WARN, "warning":
+		return slog.LevelWarn, nil
+	case LEVEL_ERROR:
+		return slog.LevelError, nil
+	}
+
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q, expected one of %s", name, strings.Join(Levels(), ", "))
+}
+
+// SetLevel changes the minimum level which is written. Unknown level names are ignored and returned as an error.
+func SetLevel(name string) error {
+	parsed, err := ParseLevel(name)
+
+	if err != nil {
+		return err
+	}
+
+	level.Set(parsed)
+
+	return nil
+}
+
+// Setup makes a logger writing to a rotating file in the logs directory the default logger, including for the standard log package.
+// If enabled is false nothing is written. The returned closer closes the log file.
+func Setup(logsDir string, enabled bool, levelName string) (io.Closer, error) {
+	levelErr := SetLevel(levelName)
+
+	if !enabled {
+		slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+		return io.NopCloser(nil), nil
+	}
+
+	file, err := NewRotatingFile(logsDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(file, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	})))
+
+	if levelErr != nil {
+		slog.Warn("The configured log level is invalid, using the default level", "err", levelErr, "level", DEFAULT_LEVEL)
+	}
+
+	return file, nil
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// REDACTED replaces the values which must not be written to the logs
+const REDACTED = "[REDACTED]"
+
+// sensitiveKeys are the attribute keys whose values are always redacted
+var sensitiveKeys = map[string]struct{}{
+	"token":         {},
+	"access_token":  {},
+	"accesstoken":   {},
+	"authorization": {},
+	"password":      {},
+	"content":       {},
+	"message":       {},
+	"body":          {},
+}
+
+// sensitivePatterns match secrets which appear within a log message, such as a token in an error returned by a client
+var sensitivePatterns = []*regexp.Regexp{
+	// Authorization headers
+	regexp.MustCompile(`(?i)\b(bearer)\s+[A-Za-z0-9\-._~+/]+=*`),
+	// JSON web tokens
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*`),
+	// Tokens and passwords in query strings and key value pairs
+	regexp.MustCompile(`(?i)\b(access_token|token|password)=[^\s&"]+`),
+}
+
+// redactAttr replaces the value of attributes with sensitive keys and removes secrets from all other string values, including the log message
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	if _, ok := sensitiveKeys[strings.ToLower(attr.Key)]; ok {
+		return slog.String(attr.Key, REDACTED)
+	}
+
+	if attr.Value.Kind() == slog.KindString {
+		return slog.String(attr.Key, Redact(attr.Value.String()))
+	}
+
+	if attr.Value.Kind() == slog.KindAny {
+		if err, ok := attr.Value.Any().(error); ok {
+			return slog.String(attr.Key, Redact(err.Error()))
+		}
+	}
+
+	return attr
+}
+
+// Redact removes tokens and passwords from the text
+func Redact(text string) string {
+	for _, pattern := range sensitivePatterns {
+		text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+			// Keep the name of the secret so that the log still says what was there
+			if i := strings.IndexAny(match, "= "); i >= 0 {
+				return match[:i+1] + REDACTED
+			}
+
+			return REDACTED
+		})
+	}
+
+	return text
+}
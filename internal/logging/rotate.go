@@ -0,0 +1,219 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// LOG_FILE_NAME is the name of the file logs are currently written to
+	LOG_FILE_NAME = "broterm.log"
+	// MAX_FILE_SIZE is the size in bytes at which the log file is rotated
+	MAX_FILE_SIZE = 5 * 1024 * 1024
+	// MAX_BACKUP_AGE is how long rotated log files are kept
+	MAX_BACKUP_AGE = 14 * 24 * time.Hour
+	// MAX_BACKUPS is the most rotated log files which are kept
+	MAX_BACKUPS = 10
+)
+
+// backupPrefix and backupSuffix surround the time a rotated log file was rotated at. Only files with these are ever deleted.
+const (
+	backupPrefix     = "broterm-"
+	backupSuffix     = ".log"
+	backupTimeLayout = "2006-01-02T15-04-05.000"
+)
+
+// RotatingFile is a log file which is rotated once it reaches MAX_FILE_SIZE or on the first write of a new day.
+// Rotated files are renamed with the time they were rotated at and are deleted once they are older than MAX_BACKUP_AGE or there are more than MAX_BACKUPS of them.
+// Only log files in the logs directory are ever rotated or deleted.
+type RotatingFile struct {
+	mu      sync.Mutex
+	dir     string
+	file    *os.File
+	size    int64
+	started time.Time
+}
+
+// NewRotatingFile creates the logs directory if it does not exist and opens the log file in it
+func NewRotatingFile(dir string) (*RotatingFile, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	rotating := &RotatingFile{dir: dir}
+
+	if err := rotating.open(filepath.Join(dir, LOG_FILE_NAME)); err != nil {
+		return nil, err
+	}
+
+	rotating.removeOldBackups()
+
+	return rotating, nil
+}
+
+// Write writes to the log file, rotating it first if it is full or was started on an earlier day
+func (rotating *RotatingFile) Write(p []byte) (int, error) {
+	rotating.mu.Lock()
+	defer rotating.mu.Unlock()
+
+	if rotating.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	now := time.Now()
+
+	if rotating.size+int64(len(p)) > MAX_FILE_SIZE || !sameDay(rotating.started, now) {
+		if err := rotating.rotate(now); err != nil {
+			if rotating.file == nil {
+				return 0, err
+			}
+
+			// The rotation failed but a log file is still open, so the failure is recorded in it
+			fmt.Fprintf(rotating.file, "time=%s level=ERROR msg=\"The log file could not be rotated\" err=%q\n", now.Format(time.RFC3339Nano), err.Error())
+		}
+	}
+
+	n, err := rotating.file.Write(p)
+	rotating.size += int64(n)
+
+	return n, err
+}
+
+// Close closes the log file
+func (rotating *RotatingFile) Close() error {
+	rotating.mu.Lock()
+	defer rotating.mu.Unlock()
+
+	if rotating.file == nil {
+		return nil
+	}
+
+	err := rotating.file.Close()
+	rotating.file = nil
+
+	return err
+}
+
+// open opens the file at the path for appending. The file is considered to have been started when it was last written to.
+func (rotating *RotatingFile) open(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	rotating.file = file
+	rotating.size = info.Size()
+	rotating.started = time.Now()
+
+	if info.Size() > 0 {
+		rotating.started = info.ModTime()
+	}
+
+	return nil
+}
+
+// rotate renames the log file with the rotation time, opens a new log file and removes the backups which are no longer kept.
+// If the file cannot be renamed or the new file cannot be opened, the file which is still there is reopened so that logging continues
+// and the error is returned. Must be called with the lock held.
+func (rotating *RotatingFile) rotate(now time.Time) error {
+	// The file is closed before it is renamed as open files cannot be renamed on all platforms
+	rotating.file.Close()
+	rotating.file = nil
+
+	logPath := filepath.Join(rotating.dir, LOG_FILE_NAME)
+	backupPath := filepath.Join(rotating.dir, fmt.Sprintf("%s%s%s", backupPrefix, now.Format(backupTimeLayout), backupSuffix))
+
+	if err := os.Rename(logPath, backupPath); err != nil {
+		if openErr := rotating.open(logPath); openErr != nil {
+			return errors.Join(err, openErr)
+		}
+
+		// Start counting again so that the rotation is retried later rather than on every write
+		rotating.size = 0
+		rotating.started = now
+
+		return err
+	}
+
+	if err := rotating.open(logPath); err != nil {
+		if openErr := rotating.open(backupPath); openErr != nil {
+			return errors.Join(err, openErr)
+		}
+
+		rotating.size = 0
+		rotating.started = now
+
+		return err
+	}
+
+	rotating.removeOldBackups()
+
+	return nil
+}
+
+// removeOldBackups deletes the rotated log files which are older than MAX_BACKUP_AGE or beyond the MAX_BACKUPS newest.
+// Failures are ignored as they will be tried again on the next rotation.
+func (rotating *RotatingFile) removeOldBackups() {
+	entries, err := os.ReadDir(rotating.dir)
+
+	if err != nil {
+		return
+	}
+
+	type backup struct {
+		name      string
+		rotatedAt time.Time
+	}
+
+	backups := make([]backup, 0)
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		if !entry.Type().IsRegular() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+			continue
+		}
+
+		rotatedAt, err := time.ParseInLocation(backupTimeLayout, strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix), time.Local)
+
+		// Files which were not named by a rotation are left alone
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, backup{name: name, rotatedAt: rotatedAt})
+	}
+
+	// Newest first
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].rotatedAt.After(backups[j].rotatedAt)
+	})
+
+	for i, b := range backups {
+		if i >= MAX_BACKUPS || time.Since(b.rotatedAt) > MAX_BACKUP_AGE {
+			os.Remove(filepath.Join(rotating.dir, b.name))
+		}
+	}
+}
+
+// sameDay returns true if both times are on the same local calendar day
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+
+	return ay == by && am == bm && ad == bd
+}
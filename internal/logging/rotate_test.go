@@ -0,0 +1,172 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func backupFileName(rotatedAt time.Time) string {
+	return fmt.Sprintf("%s%s%s", backupPrefix, rotatedAt.Format(backupTimeLayout), backupSuffix)
+}
+
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func fileNames(t *testing.T, dir string) map[string]bool {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(map[string]bool, len(entries))
+
+	for _, entry := range entries {
+		names[entry.Name()] = true
+	}
+
+	return names
+}
+
+func TestRemoveOldBackups(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	// More recent backups than are kept, newest first
+	recent := make([]string, 0, MAX_BACKUPS+2)
+
+	for i := 0; i < MAX_BACKUPS+2; i++ {
+		name := backupFileName(now.Add(-time.Duration(i+1) * time.Hour))
+		recent = append(recent, name)
+		writeFile(t, filepath.Join(dir, name))
+	}
+
+	expired := backupFileName(now.Add(-MAX_BACKUP_AGE - time.Hour))
+	writeFile(t, filepath.Join(dir, expired))
+
+	// Files which were not named by a rotation must never be deleted
+	kept := []string{LOG_FILE_NAME, "config.json", "room_preferences.json", "broterm-notes.log", "broterm-2020-01-01.txt", "other.log"}
+
+	for _, name := range kept {
+		writeFile(t, filepath.Join(dir, name))
+	}
+
+	// Directories are left alone even if they are named like a backup
+	oldDir := backupFileName(now.Add(-MAX_BACKUP_AGE - 2*time.Hour))
+
+	if err := os.Mkdir(filepath.Join(dir, oldDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	(&RotatingFile{dir: dir}).removeOldBackups()
+
+	names := fileNames(t, dir)
+
+	for i, name := range recent {
+		if want := i < MAX_BACKUPS; names[name] != want {
+			t.Errorf("backup %s exists = %v, want %v", name, names[name], want)
+		}
+	}
+
+	if names[expired] {
+		t.Errorf("expired backup %s was not deleted", expired)
+	}
+
+	for _, name := range append(kept, oldDir) {
+		if !names[name] {
+			t.Errorf("%s was deleted", name)
+		}
+	}
+}
+
+func TestRotatingFileRotatesOnANewDay(t *testing.T) {
+	dir := t.TempDir()
+
+	rotating, err := NewRotatingFile(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rotating.Close()
+
+	rotating.started = time.Now().AddDate(0, 0, -1)
+
+	if _, err := rotating.Write([]byte("first\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	backups := 0
+
+	for name := range fileNames(t, dir) {
+		if strings.HasPrefix(name, backupPrefix) {
+			backups++
+		}
+	}
+
+	if backups != 1 {
+		t.Fatalf("found %d backups after rotating, want 1", backups)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, LOG_FILE_NAME))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "first\n" {
+		t.Fatalf("the log file contains %q, want the write made after rotating", content)
+	}
+}
+
+func TestRotatingFileKeepsLoggingWhenRotationFails(t *testing.T) {
+	dir := t.TempDir()
+
+	rotating, err := NewRotatingFile(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rotating.Close()
+
+	if _, err := rotating.Write([]byte("before\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+
+	// A directory in the way of the backup makes the rename fail
+	if err := os.Mkdir(filepath.Join(dir, backupFileName(now)), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rotating.rotate(now); err == nil {
+		t.Fatal("expected the rotation to fail")
+	}
+
+	if _, err := rotating.Write([]byte("after\n")); err != nil {
+		t.Fatalf("Write() after a failed rotation returned an error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, LOG_FILE_NAME))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "before\nafter\n" {
+		t.Fatalf("the log file contains %q, want both writes", content)
+	}
+}
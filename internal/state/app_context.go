@@ -2,11 +2,11 @@ package state
 
 import (
 	"context"
-	"errors"
 	"sync"
 	"time"
 
 	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/keymap"
 	"github.com/dmars8047/broterm/internal/theme"
 )
 
@@ -21,38 +21,189 @@ type ApplicationContext struct {
 	mut               sync.RWMutex
 	monitoringContext context.Context
 	cancelMonitoring  context.CancelFunc
-	theme             *theme.Theme
+	themes            *theme.Manager
+	keymap            *keymap.Keymap
+	vimMode           bool
+	blockedUsers      map[string]chat.UserInfo
+	chatColor         string
 }
 
-func NewApplicationContext(context context.Context, themeCode string) *ApplicationContext {
+// NewApplicationContext creates the application context.
+// colorDepth is the number of colors supported by the terminal and is used to map every theme to colors the terminal can display.
+func NewApplicationContext(context context.Context, themeCode string, colorDepth int) *ApplicationContext {
 	return &ApplicationContext{
 		Context: context,
-		theme:   theme.NewTheme(themeCode),
+		themes:  theme.NewManager(themeCode, colorDepth),
+		keymap:  keymap.Default(),
 	}
 }
 
+// GetTheme returns the active theme
 func (appContext *ApplicationContext) GetTheme() theme.Theme {
+	return appContext.themes.Current()
+}
+
+// SetTheme makes the theme with the given code the active theme and re-themes every primitive registered with the theme manager.
+// Must be called from the UI goroutine.
+func (appContext *ApplicationContext) SetTheme(themeName string) {
+	appContext.themes.SetTheme(themeName)
+}
+
+// GetKeymap returns the keymap pages resolve key events through
+func (appContext *ApplicationContext) GetKeymap() *keymap.Keymap {
 	appContext.mut.RLock()
 	defer appContext.mut.RUnlock()
-	return *appContext.theme
+	return appContext.keymap
 }
 
-func (appContext *ApplicationContext) SetTheme(themeName string) {
+// SetKeymap replaces the keymap. Must be called before the pages are set up.
+func (appContext *ApplicationContext) SetKeymap(km *keymap.Keymap) {
 	appContext.mut.Lock()
 	defer appContext.mut.Unlock()
-	appContext.theme = theme.NewTheme(themeName)
+	appContext.keymap = km
+}
+
+// IsVimModeEnabled returns true if the vim style navigation keys are enabled
+func (appContext *ApplicationContext) IsVimModeEnabled() bool {
+	appContext.mut.RLock()
+	defer appContext.mut.RUnlock()
+	return appContext.vimMode
 }
 
+// SetVimModeEnabled enables or disables the vim style navigation keys
+func (appContext *ApplicationContext) SetVimModeEnabled(enabled bool) {
+	appContext.mut.Lock()
+	defer appContext.mut.Unlock()
+	appContext.vimMode = enabled
+}
+
+// GetThemeManager returns the theme manager which pages register their primitives with
+func (appContext *ApplicationContext) GetThemeManager() *theme.Manager {
+	return appContext.themes
+}
+
+// GetBrochatUser returns a copy of the logged in user. The user is updated from the feed goroutine so it is read under the lock.
+// Returns an empty user if no user has logged in.
 func (appContext *ApplicationContext) GetBrochatUser() chat.User {
+	appContext.mut.RLock()
+	defer appContext.mut.RUnlock()
+
+	if appContext.brochatUser == nil {
+		return chat.User{}
+	}
+
 	return *appContext.brochatUser
 }
 
+// SetBrochatUser replaces the logged in user. The slices of users previously returned by GetBrochatUser are never modified.
 func (appContext *ApplicationContext) SetBrochatUser(user chat.User) {
 	appContext.mut.Lock()
 	defer appContext.mut.Unlock()
 	appContext.brochatUser = &user
 }
 
+// UpdateRelationshipPresence updates the presence of the user's relationship with the given user.
+// Returns false if the logged in user has no relationship with the user.
+func (appContext *ApplicationContext) UpdateRelationshipPresence(userId string, isOnline bool, lastOnlineUtc time.Time) bool {
+	appContext.mut.Lock()
+	defer appContext.mut.Unlock()
+
+	if appContext.brochatUser == nil {
+		return false
+	}
+
+	// Copy the relationships so that users previously returned by GetBrochatUser are not modified
+	relationships := make([]chat.UserRelationship, len(appContext.brochatUser.Relationships))
+	copy(relationships, appContext.brochatUser.Relationships)
+
+	for i := range relationships {
+		if relationships[i].UserId == userId {
+			relationships[i].IsOnline = isOnline
+
+			if lastOnlineUtc.After(relationships[i].LastOnlineUtc) {
+				relationships[i].LastOnlineUtc = lastOnlineUtc
+			}
+
+			user := *appContext.brochatUser
+			user.Relationships = relationships
+			appContext.brochatUser = &user
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetChatColor returns the logged in user's preferred chat label color. An empty string means the color is assigned automatically.
+func (appContext *ApplicationContext) GetChatColor() string {
+	appContext.mut.RLock()
+	defer appContext.mut.RUnlock()
+	return appContext.chatColor
+}
+
+// SetChatColor sets the logged in user's preferred chat label color
+func (appContext *ApplicationContext) SetChatColor(color string) {
+	appContext.mut.Lock()
+	defer appContext.mut.Unlock()
+	appContext.chatColor = color
+}
+
+// GetBlockedUsers returns the users the logged in user has blocked
+func (appContext *ApplicationContext) GetBlockedUsers() []chat.UserInfo {
+	appContext.mut.RLock()
+	defer appContext.mut.RUnlock()
+
+	users := make([]chat.UserInfo, 0, len(appContext.blockedUsers))
+
+	for _, u := range appContext.blockedUsers {
+		users = append(users, u)
+	}
+
+	return users
+}
+
+// SetBlockedUsers replaces the users the logged in user has blocked
+func (appContext *ApplicationContext) SetBlockedUsers(users []chat.UserInfo) {
+	appContext.mut.Lock()
+	defer appContext.mut.Unlock()
+
+	appContext.blockedUsers = make(map[string]chat.UserInfo, len(users))
+
+	for _, u := range users {
+		appContext.blockedUsers[u.Id] = u
+	}
+}
+
+// AddBlockedUser records that the logged in user has blocked the user
+func (appContext *ApplicationContext) AddBlockedUser(user chat.UserInfo) {
+	appContext.mut.Lock()
+	defer appContext.mut.Unlock()
+
+	if appContext.blockedUsers == nil {
+		appContext.blockedUsers = make(map[string]chat.UserInfo)
+	}
+
+	appContext.blockedUsers[user.Id] = user
+}
+
+// RemoveBlockedUser records that the logged in user has unblocked the user
+func (appContext *ApplicationContext) RemoveBlockedUser(userId string) {
+	appContext.mut.Lock()
+	defer appContext.mut.Unlock()
+
+	delete(appContext.blockedUsers, userId)
+}
+
+// IsBlocked returns true if the logged in user has blocked the user
+func (appContext *ApplicationContext) IsBlocked(userId string) bool {
+	appContext.mut.RLock()
+	defer appContext.mut.RUnlock()
+
+	_, ok := appContext.blockedUsers[userId]
+	return ok
+}
+
 func (appContext *ApplicationContext) GetUserAuth() UserAuth {
 	appContext.mut.RLock()
 	defer appContext.mut.RUnlock()
@@ -117,13 +268,15 @@ func (appContext *ApplicationContext) SetUserSession(auth UserAuth, redirect fun
 }
 
 // GenerateUserSessionBoundContextWithCancel generates a new context with cancel function that is bound to the lifetime of the user session.
-// If the user session is not set, this will panic.
+// If the user session is not set, for example because it expired as a page was opened, the context is already cancelled.
 func (appContext *ApplicationContext) GenerateUserSessionBoundContextWithCancel() (context.Context, context.CancelFunc) {
 	appContext.mut.RLock()
 	defer appContext.mut.RUnlock()
 
 	if appContext.userSession == nil {
-		panic(errors.New("user session is not set"))
+		ctx, cancel := context.WithCancel(appContext.Context)
+		cancel()
+		return ctx, cancel
 	}
 
 	ctx, cancel := context.WithCancel(appContext.userSession.context)
@@ -150,6 +303,8 @@ func (appContext *ApplicationContext) CancelUserSession() {
 type UserAuth struct {
 	AccessToken     string
 	TokenExpiration time.Time
+	// The email address the user logged in with
+	Email string
 }
 
 type UserSession struct {
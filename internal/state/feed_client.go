@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"sync"
@@ -29,8 +29,15 @@ type FeedClient struct {
 	chatMessageChannels       map[string]chan chat.ChatMessage
 	userProfileUpdateChannels map[string]chan chat.UserProfileUpdateCode
 	channelUpdateChannels     map[string]chan string
+	typingEventChannels       map[string]chan TypingEvent
+	channelReadChannels       map[string]chan ChannelReadEvent
+	presenceChannels          map[string]chan UserPresenceEvent
+	chatNotificationChannels  map[string]chan chat.ChatNotification
 	Closed                    bool
 	mu                        sync.RWMutex
+	// The unread channels have their own lock as they are used from the UI goroutine while subscribers are being sent to under mu
+	unreadChannels map[string]struct{}
+	unreadMu       sync.Mutex
 }
 
 // NewFeedClient creates a new instance of the feed client.
@@ -42,6 +49,11 @@ func NewFeedClient(dialer *websocket.Dialer, baseUrl string, broChatClient *chat
 		chatMessageChannels:       make(map[string]chan chat.ChatMessage, 0),
 		userProfileUpdateChannels: make(map[string]chan chat.UserProfileUpdateCode, 0),
 		channelUpdateChannels:     make(map[string]chan string, 0),
+		typingEventChannels:       make(map[string]chan TypingEvent, 0),
+		channelReadChannels:       make(map[string]chan ChannelReadEvent, 0),
+		presenceChannels:          make(map[string]chan UserPresenceEvent, 0),
+		chatNotificationChannels:  make(map[string]chan chat.ChatNotification, 0),
+		unreadChannels:            make(map[string]struct{}, 0),
 		Closed:                    true,
 		mu:                        sync.RWMutex{},
 		appContext:                appContext,
@@ -141,7 +153,148 @@ func (c *FeedClient) UnsubscribeFromChannelUpdates(id string) {
 	delete(c.channelUpdateChannels, id)
 }
 
-func (c *FeedClient) Connect() error {
+// SubscribeToTypingEvents subscribes to typing events and returns a channel to receive events on.
+// The returned string is the subscription ID and is used to unsubscribe from typing events.
+// The returned channel will be closed when the subscription is removed. Suggested usage is to defer the call to UnsubscribeFromTypingEvents.
+func (c *FeedClient) SubscribeToTypingEvents() (string, <-chan TypingEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := uuid.NewString()
+	ch := make(chan TypingEvent)
+
+	c.typingEventChannels[id] = ch
+
+	return id, ch
+}
+
+// UnsubscribeFromTypingEvents unsubscribes from typing events.
+func (c *FeedClient) UnsubscribeFromTypingEvents(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch, ok := c.typingEventChannels[id]
+
+	if !ok {
+		return
+	}
+
+	close(ch)
+	delete(c.typingEventChannels, id)
+}
+
+// SubscribeToChannelReadEvents subscribes to channel read events and returns a channel to receive events on.
+// The returned string is the subscription ID and is used to unsubscribe from channel read events.
+// The returned channel will be closed when the subscription is removed. Suggested usage is to defer the call to UnsubscribeFromChannelReadEvents.
+func (c *FeedClient) SubscribeToChannelReadEvents() (string, <-chan ChannelReadEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := uuid.NewString()
+	ch := make(chan ChannelReadEvent)
+
+	c.channelReadChannels[id] = ch
+
+	return id, ch
+}
+
+// UnsubscribeFromChannelReadEvents unsubscribes from channel read events.
+func (c *FeedClient) UnsubscribeFromChannelReadEvents(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch, ok := c.channelReadChannels[id]
+
+	if !ok {
+		return
+	}
+
+	close(ch)
+	delete(c.channelReadChannels, id)
+}
+
+// SubscribeToPresenceEvents subscribes to friend presence events and returns a channel to receive events on.
+// The user's relationships are updated with the new presence before the event is sent.
+// The returned string is the subscription ID and is used to unsubscribe from presence events.
+// The returned channel will be closed when the subscription is removed. Suggested usage is to defer the call to UnsubscribeFromPresenceEvents.
+func (c *FeedClient) SubscribeToPresenceEvents() (string, <-chan UserPresenceEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := uuid.NewString()
+	ch := make(chan UserPresenceEvent)
+
+	c.presenceChannels[id] = ch
+
+	return id, ch
+}
+
+// UnsubscribeFromPresenceEvents unsubscribes from presence events.
+func (c *FeedClient) UnsubscribeFromPresenceEvents(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch, ok := c.presenceChannels[id]
+
+	if !ok {
+		return
+	}
+
+	close(ch)
+	delete(c.presenceChannels, id)
+}
+
+// SubscribeToChatNotifications subscribes to chat notifications and returns a channel to receive notifications on.
+// The server sends a notification instead of the message when a message is sent in a channel the user is not listening to.
+// The channel is marked as unread before the notification is sent.
+// The returned string is the subscription ID and is used to unsubscribe from chat notifications.
+// The returned channel will be closed when the subscription is removed. Suggested usage is to defer the call to UnsubscribeFromChatNotifications.
+func (c *FeedClient) SubscribeToChatNotifications() (string, <-chan chat.ChatNotification) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := uuid.NewString()
+	ch := make(chan chat.ChatNotification)
+
+	c.chatNotificationChannels[id] = ch
+
+	return id, ch
+}
+
+// UnsubscribeFromChatNotifications unsubscribes from chat notifications.
+func (c *FeedClient) UnsubscribeFromChatNotifications(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch, ok := c.chatNotificationChannels[id]
+
+	if !ok {
+		return
+	}
+
+	close(ch)
+	delete(c.chatNotificationChannels, id)
+}
+
+// IsChannelUnread returns true if a chat notification has been received for the channel since it was last marked as read
+func (c *FeedClient) IsChannelUnread(channelId string) bool {
+	c.unreadMu.Lock()
+	defer c.unreadMu.Unlock()
+
+	_, ok := c.unreadChannels[channelId]
+
+	return ok
+}
+
+// MarkChannelRead marks the channel as read. Called when the user opens the channel.
+func (c *FeedClient) MarkChannelRead(channelId string) {
+	c.unreadMu.Lock()
+	defer c.unreadMu.Unlock()
+
+	delete(c.unreadChannels, channelId)
+}
+
+func (c *FeedClient) Connect(ctx context.Context) error {
 
 	accessToken, ok := c.appContext.GetAccessToken()
 
@@ -152,7 +305,7 @@ func (c *FeedClient) Connect() error {
 	headers := http.Header{}
 	headers.Set("Authorization", "Bearer "+accessToken)
 
-	conn, _, err := c.dialer.Dial(c.url.String(), headers)
+	conn, _, err := c.dialer.DialContext(ctx, c.url.String(), headers)
 
 	if err != nil {
 		return err
@@ -175,9 +328,9 @@ func (c *FeedClient) Connect() error {
 
 			if err != nil {
 				if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) || err == websocket.ErrCloseSent {
-					log.Printf("Websocket connection to %s closed", c.url.String())
+					slog.Info("Websocket connection closed", "url", c.url.String())
 				} else {
-					log.Printf("Error reading message from websocket: %s", err.Error())
+					slog.Error("Error reading message from websocket", "err", err)
 				}
 				return
 			}
@@ -188,7 +341,7 @@ func (c *FeedClient) Connect() error {
 				msgErr := json.Unmarshal(message, &feedMessage)
 
 				if msgErr != nil {
-					log.Printf("Error unmarshaling feed message: %s", msgErr.Error())
+					slog.Error("Error unmarshaling feed message", "err", msgErr)
 					continue
 				}
 
@@ -199,7 +352,7 @@ func (c *FeedClient) Connect() error {
 					chtMsgErr := json.Unmarshal(feedMessage.Content, &channelUpdatedEvent)
 
 					if chtMsgErr != nil {
-						log.Printf("Error unmarshaling channel updated event during channel updated event processing: %s", chtMsgErr.Error())
+						slog.Error("Error unmarshaling channel updated event during channel updated event processing", "err", chtMsgErr)
 						continue
 					}
 
@@ -216,7 +369,7 @@ func (c *FeedClient) Connect() error {
 					chtMsgErr := json.Unmarshal(feedMessage.Content, &chatMessage)
 
 					if chtMsgErr != nil {
-						log.Printf("Error unmarshaling chat message during chat message event processing: %s", chtMsgErr.Error())
+						slog.Error("Error unmarshaling chat message during chat message event processing", "err", chtMsgErr)
 						continue
 					}
 
@@ -226,6 +379,89 @@ func (c *FeedClient) Connect() error {
 						c.chatMessageChannels[ch] <- chatMessage
 					}
 
+					c.mu.RUnlock()
+				case FEED_MESSAGE_TYPE_TYPING_EVENT:
+					var typingEvent TypingEvent
+
+					typingErr := json.Unmarshal(feedMessage.Content, &typingEvent)
+
+					if typingErr != nil {
+						slog.Error("Error unmarshaling typing event during typing event processing", "err", typingErr)
+						continue
+					}
+
+					c.mu.RLock()
+
+					for ch := range c.typingEventChannels {
+						c.typingEventChannels[ch] <- typingEvent
+					}
+
+					c.mu.RUnlock()
+				case FEED_MESSAGE_TYPE_CHANNEL_READ_EVENT:
+					var channelReadEvent ChannelReadEvent
+
+					readErr := json.Unmarshal(feedMessage.Content, &channelReadEvent)
+
+					if readErr != nil {
+						slog.Error("Error unmarshaling channel read event during channel read event processing", "err", readErr)
+						continue
+					}
+
+					c.mu.RLock()
+
+					for ch := range c.channelReadChannels {
+						c.channelReadChannels[ch] <- channelReadEvent
+					}
+
+					c.mu.RUnlock()
+				case chat.FEED_MESSAGE_TYPE_USER_ONLINE_EVENT, chat.FEED_MESSAGE_TYPE_USER_OFFLINE_EVENT:
+					var presenceEvent UserPresenceEvent
+
+					presenceErr := json.Unmarshal(feedMessage.Content, &presenceEvent)
+
+					if presenceErr != nil {
+						slog.Error("Error unmarshaling presence event during presence event processing", "err", presenceErr)
+						continue
+					}
+
+					presenceEvent.IsOnline = feedMessage.Type == chat.FEED_MESSAGE_TYPE_USER_ONLINE_EVENT
+
+					if presenceEvent.LastOnlineUtc.IsZero() {
+						presenceEvent.LastOnlineUtc = time.Now().UTC()
+					}
+
+					// Only relationships are tracked so presence changes for other users are ignored
+					if !c.appContext.UpdateRelationshipPresence(presenceEvent.UserId, presenceEvent.IsOnline, presenceEvent.LastOnlineUtc) {
+						continue
+					}
+
+					c.mu.RLock()
+
+					for ch := range c.presenceChannels {
+						c.presenceChannels[ch] <- presenceEvent
+					}
+
+					c.mu.RUnlock()
+				case chat.FEED_MESSAGE_TYPE_CHAT_NOTIFICATION:
+					var chatNotification chat.ChatNotification
+
+					chtMsgErr := json.Unmarshal(feedMessage.Content, &chatNotification)
+
+					if chtMsgErr != nil {
+						slog.Error("Error unmarshaling chat notification during chat notification processing", "err", chtMsgErr)
+						continue
+					}
+
+					c.unreadMu.Lock()
+					c.unreadChannels[chatNotification.ChannelId] = struct{}{}
+					c.unreadMu.Unlock()
+
+					c.mu.RLock()
+
+					for ch := range c.chatNotificationChannels {
+						c.chatNotificationChannels[ch] <- chatNotification
+					}
+
 					c.mu.RUnlock()
 				case chat.FEED_MESSAGE_TYPE_USER_PROFILE_UPDATED:
 					brochatUser := c.appContext.GetBrochatUser()
@@ -233,7 +469,7 @@ func (c *FeedClient) Connect() error {
 					accessToken, ok := c.appContext.GetAccessToken()
 
 					if !ok {
-						log.Println("No valid authentication information available for user profile updated event processing")
+						slog.Warn("No valid authentication information available for user profile updated event processing")
 						c.appContext.CancelUserSession()
 						return
 					}
@@ -243,8 +479,8 @@ func (c *FeedClient) Connect() error {
 					err = result.Err()
 
 					if err != nil {
-						log.Printf("An error occurred during the processing of a user profile updated event. "+
-							"The call to retrieve user data resulted in the following error: %s", err.Error())
+						slog.Error("An error occurred during the processing of a user profile updated event. "+
+							"The call to retrieve user data failed", "err", err)
 
 						continue
 					}
@@ -256,7 +492,7 @@ func (c *FeedClient) Connect() error {
 					chtMsgErr := json.Unmarshal(feedMessage.Content, &userProfileUpdatedEvent)
 
 					if chtMsgErr != nil {
-						log.Printf("Error unmarshaling user profile updated event during user profile updated event processing: %s", chtMsgErr.Error())
+						slog.Error("Error unmarshaling user profile updated event during user profile updated event processing", "err", chtMsgErr)
 						continue
 					}
 
@@ -293,11 +529,44 @@ func (c *FeedClient) Connect() error {
 
 				clear(c.userProfileUpdateChannels)
 
+				// Close all typing event channels
+				for ch := range c.typingEventChannels {
+					close(c.typingEventChannels[ch])
+				}
+
+				clear(c.typingEventChannels)
+
+				// Close all channel read event channels
+				for ch := range c.channelReadChannels {
+					close(c.channelReadChannels[ch])
+				}
+
+				clear(c.channelReadChannels)
+
+				// Close all presence event channels
+				for ch := range c.presenceChannels {
+					close(c.presenceChannels[ch])
+				}
+
+				clear(c.presenceChannels)
+
+				// Close all chat notification channels
+				for ch := range c.chatNotificationChannels {
+					close(c.chatNotificationChannels[ch])
+				}
+
+				clear(c.chatNotificationChannels)
+
+				// Forget the unread channels so they are not shown to the next user
+				c.unreadMu.Lock()
+				clear(c.unreadChannels)
+				c.unreadMu.Unlock()
+
 				// Close the connection
 				defer func() {
-					log.Printf("Closing websocket connection to %s", c.url.String())
+					slog.Info("Closing websocket connection", "url", c.url.String())
 					if err := c.conn.Close(); err != nil {
-						log.Println("websocket close error:", err)
+						slog.Error("Websocket close error", "err", err)
 					}
 				}()
 
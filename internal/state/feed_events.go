@@ -0,0 +1,58 @@
+package state
+
+import (
+	"time"
+
+	"github.com/dmars8047/brolib/chat"
+)
+
+// Feed message types which are not yet defined by brolib.
+//
+// These are an extension to the BroChat feed protocol of brolib v0.1.8 and are only exchanged with servers which implement it.
+// A server without the extension drops the typing requests and never sends the typing or channel read events,
+// so the chat page shows no typing indicators or seen markers and is otherwise unaffected.
+const (
+	// Typing request message type. Sent to the server when the user is composing a message in a channel.
+	FEED_MESSAGE_TYPE_TYPING_REQUEST chat.FeedMessageType = "brochat:feed_message_type:typing_request"
+	// Typing event message type. Recieved when another member of a channel is composing a message.
+	FEED_MESSAGE_TYPE_TYPING_EVENT chat.FeedMessageType = "brochat:feed_message_type:typing_event"
+	// Channel read event message type. Recieved when another member of a channel has read the messages in it.
+	// The server emits this when a member makes the channel their active channel (see FEED_MESSAGE_TYPE_SET_ACTIVE_CHANNEL_REQUEST)
+	// and for each message delivered to a member while the channel is active.
+	FEED_MESSAGE_TYPE_CHANNEL_READ_EVENT chat.FeedMessageType = "brochat:feed_message_type:channel_read_event"
+)
+
+// A request to notify the other members of a channel that the user is composing a message.
+type TypingRequest struct {
+	// The ID of the channel the user is composing a message in.
+	ChannelId string `json:"channel_id"`
+}
+
+// Represents an event where another user is composing a message in a channel.
+type TypingEvent struct {
+	// The ID of the channel the message is being composed in.
+	ChannelId string `json:"channel_id"`
+	// The ID of the user that is composing the message.
+	UserId string `json:"user_id"`
+}
+
+// Represents an event where another user has read the messages in a channel.
+type ChannelReadEvent struct {
+	// The ID of the channel that was read.
+	ChannelId string `json:"channel_id"`
+	// The ID of the user that read the channel.
+	UserId string `json:"user_id"`
+	// Every message recieved at or before this time has been read by the user.
+	ReadAtUtc time.Time `json:"read_at_utc"`
+}
+
+// Represents an event where a friend of the user has come online or gone offline.
+// Recieved with the FEED_MESSAGE_TYPE_USER_ONLINE_EVENT and FEED_MESSAGE_TYPE_USER_OFFLINE_EVENT message types.
+type UserPresenceEvent struct {
+	// The ID of the user whose presence changed.
+	UserId string `json:"user_id"`
+	// True if the user came online, false if the user went offline. Set from the message type.
+	IsOnline bool `json:"-"`
+	// When the user was last online. If not provided the time the event was recieved is used.
+	LastOnlineUtc time.Time `json:"last_online_utc"`
+}
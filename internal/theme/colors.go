@@ -0,0 +1,119 @@
+package theme
+
+import "github.com/gdamore/tcell/v2"
+
+// TRUE_COLOR_DEPTH is the number of colors tcell reports for terminals which support true color
+const TRUE_COLOR_DEPTH = 1 << 24
+
+// ForColorDepth returns a copy of the theme with every color mapped to the nearest color the terminal can display.
+// colors is the number of colors supported by the terminal as reported by tcell.Screen.Colors.
+// Terminals which support more than 256 colors are assumed to support true color and the theme is returned unchanged.
+func (theme Theme) ForColorDepth(colors int) *Theme {
+	palette := paletteFor(colors)
+
+	if palette == nil {
+		themeCopy := theme
+		themeCopy.ChatLabelColors = append([]string(nil), theme.ChatLabelColors...)
+		return &themeCopy
+	}
+
+	nearest := func(c tcell.Color) tcell.Color {
+		if !c.Valid() {
+			return c
+		}
+
+		return tcell.FindColor(c, palette)
+	}
+
+	// Styles keep their attributes and have their foreground moved off of the background if both map to the same color
+	style := func(s tcell.Style) tcell.Style {
+		fg, bg, attrs := s.Decompose()
+		fg, bg = nearest(fg), nearest(bg)
+
+		return tcell.StyleDefault.Foreground(readableOn(fg, bg)).Background(bg).Attributes(attrs)
+	}
+
+	background := nearest(theme.BackgroundColor)
+
+	mapped := &Theme{
+		Code:                        theme.Code,
+		BackgroundColor:             background,
+		ForgroundColor:              readableOn(nearest(theme.ForgroundColor), background),
+		HighlightColor:              readableOn(nearest(theme.HighlightColor), background),
+		AccentColor:                 nearest(theme.AccentColor),
+		AccentColorTwo:              nearest(theme.AccentColorTwo),
+		ButtonStyle:                 style(theme.ButtonStyle),
+		ActivatedButtonStyle:        style(theme.ActivatedButtonStyle),
+		DropdownListUnselectedStyle: style(theme.DropdownListUnselectedStyle),
+		DropdownListSelectedStyle:   style(theme.DropdownListSelectedStyle),
+		TextAreaTextStyle:           style(theme.TextAreaTextStyle),
+		BorderColor:                 readableOn(nearest(theme.BorderColor), background),
+		TitleColor:                  readableOn(nearest(theme.TitleColor), background),
+		InfoColor:                   readableOn(nearest(theme.InfoColor), background),
+		InfoColorTwo:                readableOn(nearest(theme.InfoColorTwo), background),
+		ChatTextColor:               readableOn(nearest(theme.ChatTextColor), background),
+		ChatLabelColors:             make([]string, 0, len(theme.ChatLabelColors)),
+	}
+
+	// Several label colors can map to the same palette color so duplicates are dropped.
+	// Labels which would disappear into the background are dropped as well.
+	seen := make(map[tcell.Color]struct{})
+
+	for _, label := range theme.ChatLabelColors {
+		c := nearest(tcell.GetColor(label))
+
+		if c == background || c == tcell.ColorDefault {
+			continue
+		}
+
+		if _, ok := seen[c]; ok {
+			continue
+		}
+
+		seen[c] = struct{}{}
+		mapped.ChatLabelColors = append(mapped.ChatLabelColors, c.CSS())
+	}
+
+	if len(mapped.ChatLabelColors) == 0 {
+		mapped.ChatLabelColors = append(mapped.ChatLabelColors, readableOn(background, background).CSS())
+	}
+
+	return mapped
+}
+
+// paletteFor returns the palette available to a terminal which supports the given number of colors.
+// Returns nil if the terminal supports true color.
+func paletteFor(colors int) []tcell.Color {
+	if colors > 256 {
+		return nil
+	}
+
+	// Terminals with fewer than 8 colors are treated as monochrome
+	if colors < 8 {
+		return []tcell.Color{tcell.ColorBlack, tcell.ColorWhite}
+	}
+
+	palette := make([]tcell.Color, colors)
+
+	for i := range palette {
+		palette[i] = tcell.PaletteColor(i)
+	}
+
+	return palette
+}
+
+// readableOn returns fg unless it is the same color as bg in which case black or white is returned, whichever contrasts with bg
+func readableOn(fg, bg tcell.Color) tcell.Color {
+	if fg != bg || !bg.Valid() {
+		return fg
+	}
+
+	r, g, b := bg.RGB()
+
+	// Perceived brightness (ITU-R BT.601)
+	if (299*r+587*g+114*b)/1000 > 128 {
+		return tcell.ColorBlack
+	}
+
+	return tcell.ColorWhite
+}
@@ -0,0 +1,259 @@
+package theme
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// customThemeCodePattern restricts custom theme codes to values that can be typed in a slash command
+var customThemeCodePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+var (
+	customThemes     = make(map[string]*Theme)
+	customCodes      []string
+	customThemeMutex sync.RWMutex
+)
+
+// customThemeFile is the on disk representation of a custom theme.
+// Colors are either hex values (#RRGGBB) or W3C color names.
+type customThemeFile struct {
+	Code                        string            `json:"code"`
+	BackgroundColor             string            `json:"background_color"`
+	ForgroundColor              string            `json:"foreground_color"`
+	HighlightColor              string            `json:"highlight_color"`
+	AccentColor                 string            `json:"accent_color"`
+	AccentColorTwo              string            `json:"accent_color_two"`
+	ButtonStyle                 *customThemeStyle `json:"button_style"`
+	ActivatedButtonStyle        *customThemeStyle `json:"activated_button_style"`
+	DropdownListUnselectedStyle *customThemeStyle `json:"dropdown_list_unselected_style"`
+	DropdownListSelectedStyle   *customThemeStyle `json:"dropdown_list_selected_style"`
+	TextAreaTextStyle           *customThemeStyle `json:"text_area_text_style"`
+	BorderColor                 string            `json:"border_color"`
+	TitleColor                  string            `json:"title_color"`
+	InfoColor                   string            `json:"info_color"`
+	InfoColorTwo                string            `json:"info_color_two"`
+	ChatTextColor               string            `json:"chat_text_color"`
+	ChatLabelColors             []string          `json:"chat_label_colors"`
+}
+
+// customThemeStyle is the on disk representation of a tcell style
+type customThemeStyle struct {
+	Foreground string `json:"foreground"`
+	Background string `json:"background"`
+}
+
+// LoadCustomThemes reads every .json and .toml theme file in the given directory and makes them available through NewTheme and Codes.
+// Previously loaded custom themes are replaced. Files which fail validation are skipped and reported in the returned errors.
+// A missing directory is not an error.
+func LoadCustomThemes(dir string) []error {
+	dirEntries, err := os.ReadDir(dir)
+
+	if os.IsNotExist(err) {
+		dirEntries = nil
+	} else if err != nil {
+		return []error{err}
+	}
+
+	loaded := make(map[string]*Theme)
+	codes := make([]string, 0)
+	errs := make([]error, 0)
+
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() {
+			continue
+		}
+
+		ext := strings.ToLower(filepath.Ext(dirEntry.Name()))
+
+		if ext != ".json" && ext != ".toml" {
+			continue
+		}
+
+		thm, err := loadCustomThemeFile(filepath.Join(dir, dirEntry.Name()))
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("theme file %s: %w", dirEntry.Name(), err))
+			continue
+		}
+
+		if _, ok := loaded[thm.Code]; ok {
+			errs = append(errs, fmt.Errorf("theme file %s: theme code '%s' is already used by another theme file", dirEntry.Name(), thm.Code))
+			continue
+		}
+
+		loaded[thm.Code] = thm
+		codes = append(codes, thm.Code)
+	}
+
+	customThemeMutex.Lock()
+	defer customThemeMutex.Unlock()
+
+	customThemes = loaded
+	customCodes = codes
+
+	return errs
+}
+
+// customThemeCodes returns the codes of the loaded custom themes in the order they were loaded
+func customThemeCodes() []string {
+	customThemeMutex.RLock()
+	defer customThemeMutex.RUnlock()
+
+	codes := make([]string, len(customCodes))
+	copy(codes, customCodes)
+
+	return codes
+}
+
+// getCustomTheme returns a copy of the loaded custom theme with the given code
+func getCustomTheme(code string) (*Theme, bool) {
+	customThemeMutex.RLock()
+	defer customThemeMutex.RUnlock()
+
+	thm, ok := customThemes[code]
+
+	if !ok {
+		return nil, false
+	}
+
+	themeCopy := *thm
+	themeCopy.ChatLabelColors = make([]string, len(thm.ChatLabelColors))
+	copy(themeCopy.ChatLabelColors, thm.ChatLabelColors)
+
+	return &themeCopy, true
+}
+
+// loadCustomThemeFile reads, decodes and validates a single theme file
+func loadCustomThemeFile(filePath string) (*Theme, error) {
+	fileBytes, err := os.ReadFile(filePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// TOML files are converted to JSON so that both formats share the same decoding and validation
+	if strings.ToLower(filepath.Ext(filePath)) == ".toml" {
+		values, err := parseTOML(string(fileBytes))
+
+		if err != nil {
+			return nil, err
+		}
+
+		fileBytes, err = json.Marshal(values)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(fileBytes))
+	decoder.DisallowUnknownFields()
+
+	var themeFile customThemeFile
+
+	err = decoder.Decode(&themeFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return themeFile.toTheme()
+}
+
+// toTheme validates the theme file and converts it into a theme.
+// Every problem with the file is reported rather than only the first.
+func (themeFile *customThemeFile) toTheme() (*Theme, error) {
+	problems := make([]error, 0)
+
+	color := func(field, value string) tcell.Color {
+		c, err := parseThemeColor(field, value)
+
+		if err != nil {
+			problems = append(problems, err)
+		}
+
+		return c
+	}
+
+	style := func(field string, value *customThemeStyle) tcell.Style {
+		if value == nil {
+			problems = append(problems, fmt.Errorf("%s is required", field))
+			return tcell.StyleDefault
+		}
+
+		return tcell.StyleDefault.
+			Foreground(color(field+".foreground", value.Foreground)).
+			Background(color(field+".background", value.Background))
+	}
+
+	code := strings.TrimSpace(themeFile.Code)
+
+	if code == "" {
+		problems = append(problems, errors.New("code is required"))
+	} else if !customThemeCodePattern.MatchString(code) {
+		problems = append(problems, fmt.Errorf("code '%s' may only contain lowercase letters, numbers, dashes and underscores", code))
+	} else if _, ok := builtinThemes[code]; ok {
+		problems = append(problems, fmt.Errorf("code '%s' is already used by a built-in theme", code))
+	}
+
+	thm := &Theme{
+		Code:                        code,
+		BackgroundColor:             color("background_color", themeFile.BackgroundColor),
+		ForgroundColor:              color("foreground_color", themeFile.ForgroundColor),
+		HighlightColor:              color("highlight_color", themeFile.HighlightColor),
+		AccentColor:                 color("accent_color", themeFile.AccentColor),
+		AccentColorTwo:              color("accent_color_two", themeFile.AccentColorTwo),
+		ButtonStyle:                 style("button_style", themeFile.ButtonStyle),
+		ActivatedButtonStyle:        style("activated_button_style", themeFile.ActivatedButtonStyle),
+		DropdownListUnselectedStyle: style("dropdown_list_unselected_style", themeFile.DropdownListUnselectedStyle),
+		DropdownListSelectedStyle:   style("dropdown_list_selected_style", themeFile.DropdownListSelectedStyle),
+		TextAreaTextStyle:           style("text_area_text_style", themeFile.TextAreaTextStyle),
+		BorderColor:                 color("border_color", themeFile.BorderColor),
+		TitleColor:                  color("title_color", themeFile.TitleColor),
+		InfoColor:                   color("info_color", themeFile.InfoColor),
+		InfoColorTwo:                color("info_color_two", themeFile.InfoColorTwo),
+		ChatTextColor:               color("chat_text_color", themeFile.ChatTextColor),
+		ChatLabelColors:             make([]string, 0, len(themeFile.ChatLabelColors)),
+	}
+
+	if len(themeFile.ChatLabelColors) == 0 {
+		problems = append(problems, errors.New("chat_label_colors must contain at least one color"))
+	}
+
+	for i, value := range themeFile.ChatLabelColors {
+		// Chat label colors are used in tview color tags so they are stored as hex values
+		thm.ChatLabelColors = append(thm.ChatLabelColors, color(fmt.Sprintf("chat_label_colors[%d]", i), value).CSS())
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+
+	return thm, nil
+}
+
+// parseThemeColor parses a hex (#RRGGBB) or W3C named color
+func parseThemeColor(field, value string) (tcell.Color, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if value == "" {
+		return tcell.ColorDefault, fmt.Errorf("%s is required", field)
+	}
+
+	c := tcell.GetColor(value)
+
+	if c == tcell.ColorDefault {
+		return tcell.ColorDefault, fmt.Errorf("%s '%s' is not a valid hex (#RRGGBB) or named color", field, value)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,96 @@
+package theme
+
+import "sync"
+
+// Manager owns the active theme and applies it to every registered styler.
+// Stylers are applied on registration and again whenever the theme changes so that
+// every registered primitive is re-themed immediately, whether or not it is currently visible.
+// Stylers update tview primitives so theme changes must be made from the UI goroutine.
+type Manager struct {
+	mu         sync.RWMutex
+	current    *Theme
+	colorDepth int
+	stylers    []func(Theme)
+}
+
+// NewManager creates a theme manager with the given theme as the active theme.
+// colorDepth is the number of colors supported by the terminal and is used to map every theme to colors the terminal can display.
+func NewManager(themeCode string, colorDepth int) *Manager {
+	return &Manager{
+		current:    NewTheme(themeCode).ForColorDepth(colorDepth),
+		colorDepth: colorDepth,
+		stylers:    make([]func(Theme), 0),
+	}
+}
+
+// Current returns the active theme
+func (manager *Manager) Current() Theme {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+	return *manager.current
+}
+
+// ColorDepth returns the number of colors supported by the terminal
+func (manager *Manager) ColorDepth() int {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+	return manager.colorDepth
+}
+
+// SetColorDepth changes the number of colors supported by the terminal, maps the active theme to it and applies it to every registered styler
+func (manager *Manager) SetColorDepth(colorDepth int) {
+	manager.mu.Lock()
+
+	if colorDepth == manager.colorDepth {
+		manager.mu.Unlock()
+		return
+	}
+
+	manager.colorDepth = colorDepth
+	manager.current = NewTheme(manager.current.Code).ForColorDepth(colorDepth)
+	manager.mu.Unlock()
+
+	manager.Apply()
+}
+
+// Register adds a styler and immediately applies the active theme to it
+func (manager *Manager) Register(styler func(Theme)) {
+	manager.mu.Lock()
+	manager.stylers = append(manager.stylers, styler)
+	current := *manager.current
+	manager.mu.Unlock()
+
+	styler(current)
+}
+
+// SetTheme makes the theme with the given code the active theme and applies it to every registered styler
+func (manager *Manager) SetTheme(themeCode string) {
+	manager.mu.Lock()
+	manager.current = NewTheme(themeCode).ForColorDepth(manager.colorDepth)
+	manager.mu.Unlock()
+
+	manager.Apply()
+}
+
+// Apply applies the active theme to every registered styler.
+// Used to restore the active theme after a preview.
+func (manager *Manager) Apply() {
+	manager.apply(manager.Current())
+}
+
+// Preview applies the given theme to every registered styler without making it the active theme.
+// The theme is mapped to the terminal's color depth before it is applied.
+func (manager *Manager) Preview(themeCode string) {
+	manager.apply(*NewTheme(themeCode).ForColorDepth(manager.ColorDepth()))
+}
+
+func (manager *Manager) apply(thm Theme) {
+	manager.mu.RLock()
+	stylers := make([]func(Theme), len(manager.stylers))
+	copy(stylers, manager.stylers)
+	manager.mu.RUnlock()
+
+	for _, styler := range stylers {
+		styler(thm)
+	}
+}
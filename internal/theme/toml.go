@@ -0,0 +1,413 @@
+package theme
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tomlParser parses the subset of TOML needed for theme files.
+// Supported are bare and quoted keys, single level tables, inline tables, arrays, strings, booleans and integers.
+type tomlParser struct {
+	input string
+	pos   int
+}
+
+// parseTOML parses a TOML document into a map of keys to values
+func parseTOML(input string) (map[string]interface{}, error) {
+	p := &tomlParser{input: input}
+
+	root := make(map[string]interface{})
+	current := root
+
+	for {
+		p.skipBlankLines()
+
+		if p.eof() {
+			return root, nil
+		}
+
+		if p.peek() == '[' {
+			p.pos++
+			p.skipSpaces()
+
+			name, err := p.parseKey()
+
+			if err != nil {
+				return nil, err
+			}
+
+			p.skipSpaces()
+
+			if !p.consume(']') {
+				return nil, p.errorf("expected ']' after table name '%s'", name)
+			}
+
+			if _, ok := root[name]; ok {
+				return nil, p.errorf("'%s' is defined more than once", name)
+			}
+
+			table := make(map[string]interface{})
+			root[name] = table
+			current = table
+		} else {
+			key, value, err := p.parseKeyValue()
+
+			if err != nil {
+				return nil, err
+			}
+
+			if _, ok := current[key]; ok {
+				return nil, p.errorf("'%s' is defined more than once", key)
+			}
+
+			current[key] = value
+		}
+
+		p.skipSpaces()
+		p.skipComment()
+
+		if !p.eof() && !p.consume('\n') {
+			return nil, p.errorf("expected the end of the line")
+		}
+	}
+}
+
+func (p *tomlParser) parseKeyValue() (string, interface{}, error) {
+	key, err := p.parseKey()
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	p.skipSpaces()
+
+	if !p.consume('=') {
+		return "", nil, p.errorf("expected '=' after key '%s'", key)
+	}
+
+	p.skipSpaces()
+
+	value, err := p.parseValue()
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return key, value, nil
+}
+
+func (p *tomlParser) parseKey() (string, error) {
+	if p.eof() {
+		return "", p.errorf("expected a key")
+	}
+
+	switch p.peek() {
+	case '"':
+		return p.parseBasicString()
+	case '\'':
+		return p.parseLiteralString()
+	}
+
+	start := p.pos
+
+	for !p.eof() && isBareKeyChar(p.peek()) {
+		p.pos++
+	}
+
+	if start == p.pos {
+		return "", p.errorf("expected a key")
+	}
+
+	if !p.eof() && p.peek() == '.' {
+		return "", p.errorf("dotted keys are not supported")
+	}
+
+	return p.input[start:p.pos], nil
+}
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+	if p.eof() {
+		return nil, p.errorf("expected a value")
+	}
+
+	switch c := p.peek(); {
+	case c == '"':
+		return p.parseBasicString()
+	case c == '\'':
+		return p.parseLiteralString()
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseInlineTable()
+	case strings.HasPrefix(p.input[p.pos:], "true"):
+		p.pos += len("true")
+		return true, nil
+	case strings.HasPrefix(p.input[p.pos:], "false"):
+		p.pos += len("false")
+		return false, nil
+	case c == '+' || c == '-' || (c >= '0' && c <= '9'):
+		return p.parseInteger()
+	}
+
+	return nil, p.errorf("unsupported value")
+}
+
+// parseBasicString parses a double quoted string. Only the escapes defined by TOML are accepted, which differ from Go's.
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++
+
+	var value strings.Builder
+
+	for !p.eof() {
+		c := p.peek()
+
+		switch {
+		case c == '"':
+			p.pos++
+			return value.String(), nil
+		case c == '\\':
+			p.pos++
+
+			if err := p.parseEscape(&value); err != nil {
+				return "", err
+			}
+
+			continue
+		case c == '\n':
+			return "", p.errorf("unterminated string")
+		case c < 0x20 && c != '\t' || c == 0x7f:
+			return "", p.errorf("control characters must be escaped in strings")
+		}
+
+		value.WriteByte(c)
+		p.pos++
+	}
+
+	return "", p.errorf("unterminated string")
+}
+
+// parseEscape parses the escape sequence which follows a backslash in a basic string and writes the character it stands for
+func (p *tomlParser) parseEscape(value *strings.Builder) error {
+	if p.eof() {
+		return p.errorf("unterminated string")
+	}
+
+	c := p.peek()
+	p.pos++
+
+	switch c {
+	case 'b':
+		value.WriteByte('\b')
+	case 't':
+		value.WriteByte('\t')
+	case 'n':
+		value.WriteByte('\n')
+	case 'f':
+		value.WriteByte('\f')
+	case 'r':
+		value.WriteByte('\r')
+	case '"':
+		value.WriteByte('"')
+	case '\\':
+		value.WriteByte('\\')
+	case 'u', 'U':
+		digits := 4
+
+		if c == 'U' {
+			digits = 8
+		}
+
+		if p.pos+digits > len(p.input) {
+			return p.errorf("invalid escape \\%c", c)
+		}
+
+		hex := p.input[p.pos : p.pos+digits]
+		code, err := strconv.ParseUint(hex, 16, 32)
+
+		// Surrogate halves and code points beyond the unicode range are not characters
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("invalid escape \\%c%s", c, hex)
+		}
+
+		value.WriteRune(rune(code))
+		p.pos += digits
+	default:
+		return p.errorf("invalid escape \\%c", c)
+	}
+
+	return nil
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++
+	start := p.pos
+
+	for !p.eof() {
+		switch p.peek() {
+		case '\n':
+			return "", p.errorf("unterminated string")
+		case '\'':
+			value := p.input[start:p.pos]
+			p.pos++
+			return value, nil
+		}
+
+		p.pos++
+	}
+
+	return "", p.errorf("unterminated string")
+}
+
+func (p *tomlParser) parseInteger() (int64, error) {
+	start := p.pos
+
+	for !p.eof() && strings.ContainsRune("+-_0123456789", rune(p.peek())) {
+		p.pos++
+	}
+
+	value, err := strconv.ParseInt(strings.ReplaceAll(p.input[start:p.pos], "_", ""), 10, 64)
+
+	if err != nil {
+		return 0, p.errorf("invalid integer %s", p.input[start:p.pos])
+	}
+
+	return value, nil
+}
+
+// parseArray parses an array which may span multiple lines and have a trailing comma
+func (p *tomlParser) parseArray() ([]interface{}, error) {
+	p.pos++
+
+	values := make([]interface{}, 0)
+
+	for {
+		p.skipBlankLines()
+
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+
+		if p.consume(']') {
+			return values, nil
+		}
+
+		value, err := p.parseValue()
+
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+
+		p.skipBlankLines()
+
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+
+		if p.consume(',') {
+			continue
+		}
+
+		if p.consume(']') {
+			return values, nil
+		}
+
+		return nil, p.errorf("expected ',' or ']' in array")
+	}
+}
+
+// parseInlineTable parses a single line table such as { foreground = "#FFFFFF", background = "#000000" }
+func (p *tomlParser) parseInlineTable() (map[string]interface{}, error) {
+	p.pos++
+
+	table := make(map[string]interface{})
+
+	p.skipSpaces()
+
+	if p.consume('}') {
+		return table, nil
+	}
+
+	for {
+		p.skipSpaces()
+
+		key, value, err := p.parseKeyValue()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := table[key]; ok {
+			return nil, p.errorf("'%s' is defined more than once", key)
+		}
+
+		table[key] = value
+
+		p.skipSpaces()
+
+		if p.consume(',') {
+			continue
+		}
+
+		if p.consume('}') {
+			return table, nil
+		}
+
+		return nil, p.errorf("expected ',' or '}' in inline table")
+	}
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *tomlParser) peek() byte {
+	return p.input[p.pos]
+}
+
+func (p *tomlParser) consume(c byte) bool {
+	if !p.eof() && p.peek() == c {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *tomlParser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\r') {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	if !p.eof() && p.peek() == '#' {
+		for !p.eof() && p.peek() != '\n' {
+			p.pos++
+		}
+	}
+}
+
+// skipBlankLines skips whitespace, comments and line breaks
+func (p *tomlParser) skipBlankLines() {
+	for {
+		p.skipSpaces()
+		p.skipComment()
+
+		if !p.consume('\n') {
+			return
+		}
+	}
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.input[:min(p.pos, len(p.input))], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func isBareKeyChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == '-'
+}
@@ -0,0 +1,142 @@
+package theme
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]interface{}
+	}{
+		{
+			name:  "empty document",
+			input: "",
+			want:  map[string]interface{}{},
+		},
+		{
+			name:  "comments and blank lines",
+			input: "# a theme\n\n  # indented comment\nname = \"dark\" # trailing comment\n\n",
+			want:  map[string]interface{}{"name": "dark"},
+		},
+		{
+			name:  "hash inside a string is not a comment",
+			input: `color = "#FFFFFF"`,
+			want:  map[string]interface{}{"color": "#FFFFFF"},
+		},
+		{
+			name:  "bare and quoted keys",
+			input: "bare-key_1 = 1\n\"quoted key\" = 2\n'literal key' = 3\n",
+			want:  map[string]interface{}{"bare-key_1": int64(1), "quoted key": int64(2), "literal key": int64(3)},
+		},
+		{
+			name:  "booleans and integers",
+			input: "on = true\noff = false\npositive = +1_000\nnegative = -42\n",
+			want:  map[string]interface{}{"on": true, "off": false, "positive": int64(1000), "negative": int64(-42)},
+		},
+		{
+			name:  "basic string escapes",
+			input: `value = "tab\tnewline\nquote\"backslash\\bell\b\f\r"`,
+			want:  map[string]interface{}{"value": "tab\tnewline\nquote\"backslash\\bell\b\f\r"},
+		},
+		{
+			name:  "unicode escapes",
+			input: `value = "\u00E9\U0001F600"`,
+			want:  map[string]interface{}{"value": "é😀"},
+		},
+		{
+			name:  "literal strings are not escaped",
+			input: `value = 'C:\path\x41'`,
+			want:  map[string]interface{}{"value": `C:\path\x41`},
+		},
+		{
+			name:  "tables",
+			input: "name = \"custom\"\n\n[colors]\nbackground = \"#000000\"\n\n[\"chat colors\"]\nself = \"#FFFFFF\"\n",
+			want: map[string]interface{}{
+				"name":        "custom",
+				"colors":      map[string]interface{}{"background": "#000000"},
+				"chat colors": map[string]interface{}{"self": "#FFFFFF"},
+			},
+		},
+		{
+			name:  "inline tables",
+			input: `border = { foreground = "#FFFFFF", bold = true }`,
+			want:  map[string]interface{}{"border": map[string]interface{}{"foreground": "#FFFFFF", "bold": true}},
+		},
+		{
+			name:  "multi line arrays with a trailing comma",
+			input: "colors = [\n  \"#FF0000\", # red\n  \"#00FF00\",\n]\nempty = []\n",
+			want:  map[string]interface{}{"colors": []interface{}{"#FF0000", "#00FF00"}, "empty": []interface{}{}},
+		},
+		{
+			name:  "windows line endings",
+			input: "a = 1\r\nb = 2\r\n",
+			want:  map[string]interface{}{"a": int64(1), "b": int64(2)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseTOML(test.input)
+
+			if err != nil {
+				t.Fatalf("parseTOML() returned an error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("parseTOML() = %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "missing value", input: "key =", wantErr: "line 1: expected a value"},
+		{name: "missing equals", input: "key \"value\"", wantErr: "expected '='"},
+		{name: "missing key", input: "= 1", wantErr: "expected a key"},
+		{name: "dotted key", input: "a.b = 1", wantErr: "dotted keys are not supported"},
+		{name: "duplicate key", input: "a = 1\na = 2", wantErr: "line 2: 'a' is defined more than once"},
+		{name: "duplicate table", input: "[a]\n[a]", wantErr: "'a' is defined more than once"},
+		{name: "unclosed table", input: "[colors\n", wantErr: "expected ']'"},
+		{name: "unterminated string", input: "a = \"abc\nb = 1", wantErr: "unterminated string"},
+		{name: "unterminated string at end", input: `a = "abc\`, wantErr: "unterminated string"},
+		{name: "unterminated literal string", input: "a = 'abc", wantErr: "unterminated string"},
+		{name: "unescaped control character", input: "a = \"\x01\"", wantErr: "control characters must be escaped"},
+		{name: "hex escape", input: `a = "\x41"`, wantErr: `invalid escape \x`},
+		{name: "octal escape", input: `a = "\101"`, wantErr: `invalid escape \1`},
+		{name: "single quote escape", input: `a = "\'"`, wantErr: `invalid escape \'`},
+		{name: "short unicode escape", input: `a = "\u12"`, wantErr: `invalid escape \u`},
+		{name: "non hex unicode escape", input: `a = "\uZZZZ"`, wantErr: `invalid escape \uZZZZ`},
+		{name: "surrogate unicode escape", input: `a = "\uD800"`, wantErr: `invalid escape \uD800`},
+		{name: "out of range unicode escape", input: `a = "\U00110000"`, wantErr: `invalid escape \U00110000`},
+		{name: "invalid integer", input: "a = 1-2", wantErr: "invalid integer"},
+		{name: "unsupported value", input: "a = 1.5", wantErr: "expected the end of the line"},
+		{name: "unknown value", input: "a = yes", wantErr: "unsupported value"},
+		{name: "unterminated array", input: "a = [1, 2", wantErr: "unterminated array"},
+		{name: "array missing comma", input: "a = [1 2]", wantErr: "expected ',' or ']'"},
+		{name: "inline table missing comma", input: "a = { b = 1 c = 2 }", wantErr: "expected ',' or '}'"},
+		{name: "trailing content", input: "a = 1 b = 2", wantErr: "expected the end of the line"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseTOML(test.input)
+
+			if err == nil {
+				t.Fatalf("parseTOML() = %#v, want an error containing %q", got, test.wantErr)
+			}
+
+			if !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("parseTOML() error = %q, want it to contain %q", err, test.wantErr)
+			}
+		})
+	}
+}
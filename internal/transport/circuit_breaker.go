@@ -0,0 +1,142 @@
+package transport
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of sending a request while the circuit is open
+var ErrCircuitOpen = errors.New("the server is unavailable")
+
+// CircuitState is the state of a circuit breaker
+type CircuitState int
+
+const (
+	// CIRCUIT_CLOSED is the normal state where every request is sent
+	CIRCUIT_CLOSED CircuitState = iota
+	// CIRCUIT_OPEN is the state after repeated failures where requests fail immediately until the cooldown has passed
+	CIRCUIT_OPEN
+	// CIRCUIT_HALF_OPEN is the state after the cooldown where a single request is sent to check if the server has recovered
+	CIRCUIT_HALF_OPEN
+)
+
+// String returns the name of the circuit state
+func (state CircuitState) String() string {
+	switch state {
+	case CIRCUIT_OPEN:
+		return "open"
+	case CIRCUIT_HALF_OPEN:
+		return "half open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker stops requests from being sent to a server which keeps failing, so that callers fail fast instead of each waiting for a timeout.
+// The circuit opens after a number of consecutive failures. Once the cooldown has passed a single request is let through,
+// closing the circuit if it succeeds and opening it again if it fails.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int
+	threshold int
+	cooldown  time.Duration
+	openedAt  time.Time
+	probing   bool
+}
+
+// NewCircuitBreaker creates a closed circuit breaker which opens after threshold consecutive failures and stays open for the cooldown
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		state:     CIRCUIT_CLOSED,
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+	}
+}
+
+// Allow returns ErrCircuitOpen if a request should not be sent.
+// Every request which is allowed must be followed by a call to Success, Failure or Cancel.
+func (breaker *CircuitBreaker) Allow() error {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	switch breaker.state {
+	case CIRCUIT_OPEN:
+		if time.Since(breaker.openedAt) < breaker.cooldown {
+			return ErrCircuitOpen
+		}
+
+		breaker.setState(CIRCUIT_HALF_OPEN)
+		breaker.probing = true
+		return nil
+	case CIRCUIT_HALF_OPEN:
+		// Only the probe request is sent until it has completed
+		if breaker.probing {
+			return ErrCircuitOpen
+		}
+
+		breaker.probing = true
+		return nil
+	}
+
+	return nil
+}
+
+// Success records a request which reached the server and closes the circuit
+func (breaker *CircuitBreaker) Success() {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	breaker.failures = 0
+	breaker.probing = false
+	breaker.setState(CIRCUIT_CLOSED)
+}
+
+// Failure records a request which could not reach the server or which the server failed to handle.
+// The circuit opens once the threshold is reached, or straight away if the probe request of a half open circuit failed.
+func (breaker *CircuitBreaker) Failure() {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	breaker.failures++
+	breaker.probing = false
+
+	if breaker.state == CIRCUIT_HALF_OPEN || breaker.failures >= breaker.threshold {
+		breaker.openedAt = time.Now()
+		breaker.setState(CIRCUIT_OPEN)
+	}
+}
+
+// Cancel records a request which was allowed but abandoned by the caller before it completed, for example because its context was cancelled.
+// The request says nothing about the server so the failure count is unchanged, but a half open circuit lets another request through.
+func (breaker *CircuitBreaker) Cancel() {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	breaker.probing = false
+}
+
+// State returns the current state of the circuit
+func (breaker *CircuitBreaker) State() CircuitState {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	return breaker.state
+}
+
+// IsOpen returns true if requests are currently failing without being sent, including while a half open circuit waits on its probe request
+func (breaker *CircuitBreaker) IsOpen() bool {
+	return breaker.State() != CIRCUIT_CLOSED
+}
+
+// setState changes the state of the circuit and logs the change. Must be called with the lock held.
+func (breaker *CircuitBreaker) setState(state CircuitState) {
+	if breaker.state == state {
+		return
+	}
+
+	slog.Warn("Circuit breaker state changed", "from", breaker.state.String(), "to", state.String(), "failures", breaker.failures)
+	breaker.state = state
+}
@@ -0,0 +1,97 @@
+package transport
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	breaker := NewCircuitBreaker(3, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("Allow() = %v before the threshold was reached", err)
+		}
+
+		breaker.Failure()
+	}
+
+	if breaker.State() != CIRCUIT_CLOSED {
+		t.Fatalf("state is %s before the threshold was reached, want closed", breaker.State())
+	}
+
+	breaker.Allow()
+	breaker.Failure()
+
+	if breaker.State() != CIRCUIT_OPEN {
+		t.Fatalf("state is %s after the threshold was reached, want open", breaker.State())
+	}
+
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow() = %v while open, want ErrCircuitOpen", err)
+	}
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	breaker := NewCircuitBreaker(2, time.Hour)
+
+	breaker.Failure()
+	breaker.Success()
+	breaker.Failure()
+
+	if breaker.State() != CIRCUIT_CLOSED {
+		t.Fatalf("state is %s, want closed as the failures were not consecutive", breaker.State())
+	}
+}
+
+func TestCircuitBreakerRecovery(t *testing.T) {
+	const cooldown = 20 * time.Millisecond
+
+	tests := []struct {
+		name      string
+		probe     func(breaker *CircuitBreaker)
+		wantState CircuitState
+	}{
+		{name: "successful probe closes the circuit", probe: (*CircuitBreaker).Success, wantState: CIRCUIT_CLOSED},
+		{name: "failed probe opens the circuit again", probe: (*CircuitBreaker).Failure, wantState: CIRCUIT_OPEN},
+		{name: "cancelled probe leaves the circuit half open", probe: (*CircuitBreaker).Cancel, wantState: CIRCUIT_HALF_OPEN},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			breaker := NewCircuitBreaker(1, cooldown)
+			breaker.Failure()
+
+			if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("Allow() = %v during the cooldown, want ErrCircuitOpen", err)
+			}
+
+			time.Sleep(cooldown + 10*time.Millisecond)
+
+			if err := breaker.Allow(); err != nil {
+				t.Fatalf("Allow() = %v after the cooldown, want the probe request let through", err)
+			}
+
+			if breaker.State() != CIRCUIT_HALF_OPEN {
+				t.Fatalf("state is %s after the cooldown, want half open", breaker.State())
+			}
+
+			if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("Allow() = %v while the probe is in flight, want ErrCircuitOpen", err)
+			}
+
+			test.probe(breaker)
+
+			if breaker.State() != test.wantState {
+				t.Fatalf("state is %s after the probe, want %s", breaker.State(), test.wantState)
+			}
+
+			wantAllowed := test.wantState != CIRCUIT_OPEN
+
+			if err := breaker.Allow(); (err == nil) != wantAllowed {
+				t.Fatalf("Allow() = %v after the probe, want allowed %v", err, wantAllowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/dmars8047/broterm/internal/config"
+	"github.com/gorilla/websocket"
+)
+
+// Network holds the proxy and TLS settings shared by the http transport and the websocket dialer,
+// so that the API clients and the chat feed connect to the server the same way
+type Network struct {
+	proxy     func(*http.Request) (*url.URL, error)
+	tlsConfig *tls.Config
+}
+
+// NewNetwork validates the network settings and loads the certificates they refer to.
+// Settings which are empty use the defaults: the proxy from the standard environment variables and the system's certificate authorities.
+func NewNetwork(settings config.NetworkSettings) (*Network, error) {
+	proxy, err := proxyFunc(settings.ProxyURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := loadTLSConfig(settings)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Network{
+		proxy:     proxy,
+		tlsConfig: tlsConfig,
+	}, nil
+}
+
+// HTTPTransport creates the base transport the http client's requests are sent with
+func (network *Network) HTTPTransport() *http.Transport {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.Proxy = network.proxy
+
+	if network.tlsConfig != nil {
+		base.TLSClientConfig = network.tlsConfig.Clone()
+	}
+
+	return base
+}
+
+// WebsocketDialer creates the dialer the chat feed connects with
+func (network *Network) WebsocketDialer(handshakeTimeout time.Duration) *websocket.Dialer {
+	dialer := &websocket.Dialer{
+		Proxy:            network.proxy,
+		HandshakeTimeout: handshakeTimeout,
+	}
+
+	if network.tlsConfig != nil {
+		dialer.TLSClientConfig = network.tlsConfig.Clone()
+	}
+
+	return dialer
+}
+
+// proxyFunc returns the proxy function for the proxy url. The standard proxy environment variables are used if the url is empty.
+func proxyFunc(proxyURL string) (func(*http.Request) (*url.URL, error), error) {
+	if proxyURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	parsed, err := url.Parse(proxyURL)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url: %w", err)
+	}
+
+	// These are the schemes supported by both the http transport and the websocket dialer
+	switch parsed.Scheme {
+	case "http", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q, expected http, socks5 or socks5h", parsed.Scheme)
+	}
+
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("invalid proxy url %q: the host is missing", parsed.Redacted())
+	}
+
+	return http.ProxyURL(parsed), nil
+}
+
+// loadTLSConfig returns the TLS config for the certificate authorities and client certificate in the settings.
+// Returns nil if neither are set so that the defaults are used.
+func loadTLSConfig(settings config.NetworkSettings) (*tls.Config, error) {
+	hasClientCert := settings.ClientCertPath != "" || settings.ClientKeyPath != ""
+
+	if len(settings.CABundlePaths) == 0 && !hasClientCert {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if len(settings.CABundlePaths) > 0 {
+		roots, err := loadCertPool(settings.CABundlePaths)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.RootCAs = roots
+	}
+
+	if hasClientCert {
+		if settings.ClientCertPath == "" || settings.ClientKeyPath == "" {
+			return nil, errors.New("a client certificate requires both a certificate file and a key file")
+		}
+
+		cert, err := tls.LoadX509KeyPair(settings.ClientCertPath, settings.ClientKeyPath)
+
+		if err != nil {
+			return nil, fmt.Errorf("the client certificate could not be loaded: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// loadCertPool returns the system's certificate authorities along with those in the bundle files
+func loadCertPool(bundlePaths []string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+
+	// The bundles are added to an empty pool on systems where the system pool is unavailable
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	for _, path := range bundlePaths {
+		bundle, err := os.ReadFile(path)
+
+		if err != nil {
+			return nil, fmt.Errorf("the CA bundle could not be read: %w", err)
+		}
+
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("the CA bundle %s does not contain any PEM certificates", path)
+		}
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,356 @@
+// Package transport implements the http.RoundTripper shared by the BroChat and IDAM clients.
+// It bounds each request by an overall deadline and the application's lifetime, retries idempotent requests which fail for transient reasons
+// and stops sending requests to a server which keeps failing so that callers do not stack up timeouts.
+package transport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DEFAULT_REQUEST_TIMEOUT is how long a request may take in total, including all of its retries, the waits between them and reading the response body
+	DEFAULT_REQUEST_TIMEOUT = 20 * time.Second
+	// DEFAULT_ATTEMPT_TIMEOUT is how long a single attempt at a request may take, including reading the response body
+	DEFAULT_ATTEMPT_TIMEOUT = 10 * time.Second
+	// DEFAULT_MAX_RETRIES is how many times a request is retried after its first attempt
+	DEFAULT_MAX_RETRIES = 2
+	// DEFAULT_BASE_BACKOFF is the wait before the first retry. The wait doubles with each retry.
+	DEFAULT_BASE_BACKOFF = 250 * time.Millisecond
+	// DEFAULT_MAX_BACKOFF is the longest wait between retries, including waits asked for by the server with Retry-After
+	DEFAULT_MAX_BACKOFF = 5 * time.Second
+	// DEFAULT_FAILURE_THRESHOLD is the number of consecutive failed requests which opens the circuit
+	DEFAULT_FAILURE_THRESHOLD = 5
+	// DEFAULT_COOLDOWN is how long the circuit stays open before a request is let through to check if the server has recovered
+	DEFAULT_COOLDOWN = 30 * time.Second
+)
+
+// Transport is an http.RoundTripper which adds timeouts, retries and circuit breaking to the requests sent by a base round tripper.
+//
+// Each request is cancelled if its own context is done, the transport's context is done or the request timeout passes, so requests made by
+// clients which do not take a context still stop when the application exits and no request outlives the request timeout. GET and HEAD requests are retried with exponential backoff after network errors
+// and 5xx responses. Requests answered with 429 Too Many Requests are retried after the wait given by the Retry-After header, if the body
+// can be sent again and the wait is not too long.
+type Transport struct {
+	// Base sends the requests. http.DefaultTransport is used if nil.
+	Base http.RoundTripper
+	// Breaker stops requests from being sent while the server is failing
+	Breaker *CircuitBreaker
+	// RequestTimeout is how long a request may take in total, including its retries. Zero means no timeout.
+	RequestTimeout time.Duration
+	// AttemptTimeout is how long a single attempt may take. Zero means no timeout.
+	AttemptTimeout time.Duration
+	// MaxRetries is how many times a request is retried after its first attempt
+	MaxRetries int
+	// BaseBackoff is the wait before the first retry
+	BaseBackoff time.Duration
+	// MaxBackoff is the longest wait between retries
+	MaxBackoff time.Duration
+
+	ctx context.Context
+}
+
+// New creates a transport with the default settings which sends its requests with base.
+// Requests are cancelled once ctx is done.
+func New(ctx context.Context, base http.RoundTripper) *Transport {
+	return &Transport{
+		Base:           base,
+		Breaker:        NewCircuitBreaker(DEFAULT_FAILURE_THRESHOLD, DEFAULT_COOLDOWN),
+		RequestTimeout: DEFAULT_REQUEST_TIMEOUT,
+		AttemptTimeout: DEFAULT_ATTEMPT_TIMEOUT,
+		MaxRetries:     DEFAULT_MAX_RETRIES,
+		BaseBackoff:    DEFAULT_BASE_BACKOFF,
+		MaxBackoff:     DEFAULT_MAX_BACKOFF,
+		ctx:            ctx,
+	}
+}
+
+// RoundTrip sends the request, retrying it if it failed for a transient reason.
+// Returns ErrCircuitOpen without sending the request if the circuit is open.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Breaker != nil {
+		if err := t.Breaker.Allow(); err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+	}
+
+	ctx, cancel := t.requestContext(req.Context())
+
+	started := time.Now()
+
+	res, err := t.send(ctx, req)
+
+	if err != nil {
+		slog.Debug("Request failed", "method", req.Method, "url", req.URL.Redacted(), "duration", time.Since(started), "err", err)
+	} else {
+		slog.Debug("Request completed", "method", req.Method, "url", req.URL.Redacted(), "duration", time.Since(started), "status", res.StatusCode)
+	}
+
+	if t.Breaker != nil {
+		switch {
+		case req.Context().Err() != nil || t.ctx != nil && t.ctx.Err() != nil:
+			t.Breaker.Cancel()
+		case err != nil || res.StatusCode >= http.StatusInternalServerError:
+			t.Breaker.Failure()
+		default:
+			t.Breaker.Success()
+		}
+	}
+
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The context must outlive the round trip so that the caller can read the body
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+
+	return res, nil
+}
+
+// send makes attempts at the request until it succeeds, fails in a way which should not be retried or runs out of retries
+func (t *Transport) send(ctx context.Context, req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		attemptReq, err := t.attemptRequest(ctx, req, attempt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := t.attempt(attemptReq)
+
+		wait, retry := t.shouldRetry(req, res, err, attempt)
+
+		// There is no point waiting for a retry which the request timeout would cancel
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			retry = false
+		}
+
+		if !retry {
+			return res, err
+		}
+
+		if err != nil {
+			slog.Warn("Retrying a failed request", "method", req.Method, "url", req.URL.Redacted(), "wait", wait, "attempt", attempt+1, "err", err)
+		} else {
+			slog.Warn("Retrying a failed request", "method", req.Method, "url", req.URL.Redacted(), "wait", wait, "attempt", attempt+1, "status", res.Status)
+			discard(res)
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends a single attempt at the request, bounded by the attempt timeout
+func (t *Transport) attempt(req *http.Request) (*http.Response, error) {
+	if t.AttemptTimeout <= 0 {
+		return t.base().RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.AttemptTimeout)
+
+	res, err := t.base().RoundTrip(req.WithContext(ctx))
+
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+
+	return res, nil
+}
+
+// attemptRequest returns the request to send for the attempt. Retries get a copy of the request with a fresh body.
+func (t *Transport) attemptRequest(ctx context.Context, req *http.Request, attempt int) (*http.Request, error) {
+	attemptReq := req.Clone(ctx)
+
+	if attempt == 0 || req.Body == nil || req.Body == http.NoBody {
+		return attemptReq, nil
+	}
+
+	body, err := req.GetBody()
+
+	if err != nil {
+		return nil, err
+	}
+
+	attemptReq.Body = body
+
+	return attemptReq, nil
+}
+
+// shouldRetry returns how long to wait before retrying the request and whether it should be retried at all
+func (t *Transport) shouldRetry(req *http.Request, res *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= t.MaxRetries {
+		return 0, false
+	}
+
+	// The caller gave up on the request or the application is exiting
+	if req.Context().Err() != nil || t.ctx != nil && t.ctx.Err() != nil {
+		return 0, false
+	}
+
+	rewindable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	if err == nil && res.StatusCode == http.StatusTooManyRequests {
+		wait, ok := retryAfter(res, time.Now())
+
+		if !ok {
+			wait = t.backoff(attempt)
+		}
+
+		// The server asked for a longer wait than is worth holding the caller for
+		if wait > t.MaxBackoff || !rewindable {
+			return 0, false
+		}
+
+		return wait, true
+	}
+
+	if !isIdempotent(req.Method) {
+		return 0, false
+	}
+
+	if err != nil {
+		if errors.Is(err, ErrCircuitOpen) {
+			return 0, false
+		}
+
+		return t.backoff(attempt), true
+	}
+
+	switch res.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if wait, ok := retryAfter(res, time.Now()); ok {
+			if wait > t.MaxBackoff {
+				return 0, false
+			}
+
+			return wait, true
+		}
+
+		return t.backoff(attempt), true
+	}
+
+	return 0, false
+}
+
+// backoff returns the wait before the retry which follows the attempt. The wait doubles with each attempt and is jittered
+// so that many clients retrying at once do not hit the server together.
+func (t *Transport) backoff(attempt int) time.Duration {
+	wait := t.BaseBackoff << attempt
+
+	if wait <= 0 || wait > t.MaxBackoff {
+		wait = t.MaxBackoff
+	}
+
+	// Wait somewhere between half and all of the backoff
+	half := wait / 2
+
+	if half <= 0 {
+		return wait
+	}
+
+	return half + rand.N(half)
+}
+
+// requestContext returns a context which is done when the request's context or the transport's context is done or the request timeout passes
+func (t *Transport) requestContext(reqCtx context.Context) (context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+
+	if t.RequestTimeout > 0 {
+		ctx, cancel = context.WithTimeout(reqCtx, t.RequestTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(reqCtx)
+	}
+
+	if t.ctx == nil {
+		return ctx, cancel
+	}
+
+	stop := context.AfterFunc(t.ctx, cancel)
+
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// base returns the round tripper which sends the requests
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+
+	return http.DefaultTransport
+}
+
+// isIdempotent returns true if sending a request with the method more than once has the same effect as sending it once
+func isIdempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// retryAfter returns the wait given by the response's Retry-After header, which is either a number of seconds or a date
+func retryAfter(res *http.Response, now time.Time) (time.Duration, bool) {
+	value := res.Header.Get("Retry-After")
+
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+
+	return 0, false
+}
+
+// discard reads and closes the body of a response which will not be returned so that its connection can be reused
+func discard(res *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+	res.Body.Close()
+}
+
+// closeRequestBody closes the body of a request which will not be sent, as a round tripper must always close the request body
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+// cancelOnClose cancels a request's context once its response body has been closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and cancels the context
+func (body *cancelOnClose) Close() error {
+	err := body.ReadCloser.Close()
+	body.cancel()
+	return err
+}
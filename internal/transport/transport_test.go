@@ -0,0 +1,309 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubRoundTripper answers every attempt with the next response in its list, repeating the last one
+type stubRoundTripper struct {
+	mu        sync.Mutex
+	responses []stubResponse
+	attempts  int
+	bodies    []string
+}
+
+type stubResponse struct {
+	status     int
+	retryAfter string
+	err        error
+}
+
+func (stub *stubRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+
+	if req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		req.Body.Close()
+		stub.bodies = append(stub.bodies, string(body))
+	}
+
+	next := stub.responses[min(stub.attempts, len(stub.responses)-1)]
+	stub.attempts++
+
+	if next.err != nil {
+		return nil, next.err
+	}
+
+	res := &http.Response{
+		StatusCode: next.status,
+		Status:     http.StatusText(next.status),
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}
+
+	if next.retryAfter != "" {
+		res.Header.Set("Retry-After", next.retryAfter)
+	}
+
+	return res, nil
+}
+
+func newTestTransport(stub *stubRoundTripper) *Transport {
+	return &Transport{
+		Base:           stub,
+		AttemptTimeout: time.Second,
+		RequestTimeout: 5 * time.Second,
+		MaxRetries:     2,
+		BaseBackoff:    time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+		ctx:            context.Background(),
+	}
+}
+
+func TestTransportRetries(t *testing.T) {
+	errNetwork := errors.New("connection reset")
+
+	tests := []struct {
+		name         string
+		method       string
+		body         string
+		responses    []stubResponse
+		wantAttempts int
+		wantStatus   int
+		wantErr      bool
+	}{
+		{
+			name:         "success is not retried",
+			method:       http.MethodGet,
+			responses:    []stubResponse{{status: http.StatusOK}},
+			wantAttempts: 1,
+			wantStatus:   http.StatusOK,
+		},
+		{
+			name:         "get is retried after a server error",
+			method:       http.MethodGet,
+			responses:    []stubResponse{{status: http.StatusServiceUnavailable}, {status: http.StatusOK}},
+			wantAttempts: 2,
+			wantStatus:   http.StatusOK,
+		},
+		{
+			name:         "get is retried after a network error",
+			method:       http.MethodGet,
+			responses:    []stubResponse{{err: errNetwork}, {status: http.StatusOK}},
+			wantAttempts: 2,
+			wantStatus:   http.StatusOK,
+		},
+		{
+			name:         "retries stop at the limit",
+			method:       http.MethodGet,
+			responses:    []stubResponse{{status: http.StatusBadGateway}},
+			wantAttempts: 3,
+			wantStatus:   http.StatusBadGateway,
+		},
+		{
+			name:         "client errors are not retried",
+			method:       http.MethodGet,
+			responses:    []stubResponse{{status: http.StatusNotFound}},
+			wantAttempts: 1,
+			wantStatus:   http.StatusNotFound,
+		},
+		{
+			name:         "post is not retried after a server error",
+			method:       http.MethodPost,
+			body:         "message",
+			responses:    []stubResponse{{status: http.StatusServiceUnavailable}, {status: http.StatusOK}},
+			wantAttempts: 1,
+			wantStatus:   http.StatusServiceUnavailable,
+		},
+		{
+			name:         "post is not retried after a network error",
+			method:       http.MethodPost,
+			body:         "message",
+			responses:    []stubResponse{{err: errNetwork}, {status: http.StatusOK}},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "post is retried with its body after too many requests",
+			method:       http.MethodPost,
+			body:         "message",
+			responses:    []stubResponse{{status: http.StatusTooManyRequests, retryAfter: "0"}, {status: http.StatusOK}},
+			wantAttempts: 2,
+			wantStatus:   http.StatusOK,
+		},
+		{
+			name:         "retry after in seconds longer than the backoff cap is not waited for",
+			method:       http.MethodGet,
+			responses:    []stubResponse{{status: http.StatusTooManyRequests, retryAfter: "120"}, {status: http.StatusOK}},
+			wantAttempts: 1,
+			wantStatus:   http.StatusTooManyRequests,
+		},
+		{
+			name:         "retry after as a past date is retried straight away",
+			method:       http.MethodGet,
+			responses:    []stubResponse{{status: http.StatusTooManyRequests, retryAfter: "Mon, 02 Jan 2006 15:04:05 GMT"}, {status: http.StatusOK}},
+			wantAttempts: 2,
+			wantStatus:   http.StatusOK,
+		},
+		{
+			name:         "retry after on a server error longer than the backoff cap is not waited for",
+			method:       http.MethodGet,
+			responses:    []stubResponse{{status: http.StatusServiceUnavailable, retryAfter: "120"}, {status: http.StatusOK}},
+			wantAttempts: 1,
+			wantStatus:   http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stub := &stubRoundTripper{responses: test.responses}
+
+			var body io.Reader
+
+			if test.body != "" {
+				body = bytes.NewReader([]byte(test.body))
+			}
+
+			req, err := http.NewRequest(test.method, "https://example.com/api", body)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := newTestTransport(stub).RoundTrip(req)
+
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("RoundTrip() returned status %d, want an error", res.StatusCode)
+				}
+			} else if err != nil {
+				t.Fatalf("RoundTrip() returned an error: %v", err)
+			} else {
+				res.Body.Close()
+
+				if res.StatusCode != test.wantStatus {
+					t.Fatalf("RoundTrip() returned status %d, want %d", res.StatusCode, test.wantStatus)
+				}
+			}
+
+			if stub.attempts != test.wantAttempts {
+				t.Fatalf("made %d attempts, want %d", stub.attempts, test.wantAttempts)
+			}
+
+			for i, sent := range stub.bodies {
+				if sent != test.body {
+					t.Fatalf("attempt %d sent the body %q, want %q", i+1, sent, test.body)
+				}
+			}
+		})
+	}
+}
+
+func TestTransportDeadlineBoundsRetries(t *testing.T) {
+	stub := &stubRoundTripper{responses: []stubResponse{{status: http.StatusServiceUnavailable}}}
+
+	transport := newTestTransport(stub)
+	transport.MaxRetries = 100
+	transport.BaseBackoff = 20 * time.Millisecond
+	transport.MaxBackoff = 20 * time.Millisecond
+	transport.RequestTimeout = 100 * time.Millisecond
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/api", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := time.Now()
+
+	res, err := transport.RoundTrip(req)
+
+	if err == nil {
+		res.Body.Close()
+	}
+
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("the request took %s, want it bounded by the request timeout", elapsed)
+	}
+
+	if stub.attempts >= transport.MaxRetries {
+		t.Fatalf("made %d attempts, want the deadline to stop the retries", stub.attempts)
+	}
+}
+
+func TestTransportDoesNotSendWhileCircuitIsOpen(t *testing.T) {
+	stub := &stubRoundTripper{responses: []stubResponse{{status: http.StatusOK}}}
+
+	transport := newTestTransport(stub)
+	transport.Breaker = NewCircuitBreaker(1, time.Hour)
+	transport.Breaker.Failure()
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/api", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := transport.RoundTrip(req); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("RoundTrip() returned %v, want ErrCircuitOpen", err)
+	}
+
+	if stub.attempts != 0 {
+		t.Fatalf("made %d attempts while the circuit was open, want 0", stub.attempts)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOk bool
+	}{
+		{name: "missing", value: "", wantOk: false},
+		{name: "seconds", value: "3", want: 3 * time.Second, wantOk: true},
+		{name: "zero seconds", value: "0", want: 0, wantOk: true},
+		{name: "negative seconds", value: "-1", wantOk: false},
+		{name: "future date", value: "Tue, 02 Jan 2024 15:04:15 GMT", want: 10 * time.Second, wantOk: true},
+		{name: "past date", value: "Tue, 02 Jan 2024 15:00:00 GMT", want: 0, wantOk: true},
+		{name: "invalid", value: "soon", wantOk: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := &http.Response{Header: make(http.Header)}
+
+			if test.value != "" {
+				res.Header.Set("Retry-After", test.value)
+			}
+
+			got, ok := retryAfter(res, now)
+
+			if ok != test.wantOk || got != test.want {
+				t.Fatalf("retryAfter(%q) = %s, %v, want %s, %v", test.value, got, ok, test.want, test.wantOk)
+			}
+		})
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	transport := &Transport{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for attempt := 0; attempt < 70; attempt++ {
+		if wait := transport.backoff(attempt); wait <= 0 || wait > transport.MaxBackoff {
+			t.Fatalf("backoff(%d) = %s, want a wait between 0 and %s", attempt, wait, transport.MaxBackoff)
+		}
+	}
+}
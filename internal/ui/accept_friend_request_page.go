@@ -3,9 +3,13 @@ package ui
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
 
 	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/apperr"
+	"github.com/dmars8047/broterm/internal/chatext"
+	"github.com/dmars8047/broterm/internal/keymap"
 	"github.com/dmars8047/broterm/internal/state"
 	"github.com/dmars8047/broterm/internal/theme"
 	"github.com/gdamore/tcell/v2"
@@ -14,30 +18,40 @@ import (
 
 const ACCEPT_FRIEND_REQUEST_PAGE PageSlug = "accept_friend_request"
 
-// AcceptFriendRequestPage is the page for accepting friend requests
+// acceptFriendRequestInstructions returns the instructions for the accept friend request page using the keys bound in the keymap
+func acceptFriendRequestInstructions(keys *keymap.Keymap) string {
+	return strings.Join([]string{
+		"(enter) Accept",
+		keys.Hint(keymap.ACTION_DECLINE_REQUEST, "Decline"),
+		keys.Hint(keymap.ACTION_CANCEL_REQUEST, "Cancel Sent Request"),
+		keys.Hint(keymap.ACTION_BLOCK_USER, "Block"),
+		keys.Hint(keymap.ACTION_BACK, "Quit"),
+	}, " - ")
+}
+
+// AcceptFriendRequestPage is the page for managing received and sent friend requests
 type AcceptFriendRequestPage struct {
 	brochatClient       *chat.BroChatClient
+	chatextClient       *chatext.Client
 	userPendingRequests map[uint8]chat.UserRelationship
 	table               *tview.Table
 	feedClient          *state.FeedClient
-	currentThemeCode    string
 }
 
 // NewAcceptFriendRequestPage creates a new accept friend request page
-func NewAcceptFriendRequestPage(brochatClient *chat.BroChatClient, feedClient *state.FeedClient) *AcceptFriendRequestPage {
+func NewAcceptFriendRequestPage(brochatClient *chat.BroChatClient, chatextClient *chatext.Client, feedClient *state.FeedClient) *AcceptFriendRequestPage {
 	return &AcceptFriendRequestPage{
 		brochatClient:       brochatClient,
+		chatextClient:       chatextClient,
 		feedClient:          feedClient,
 		userPendingRequests: make(map[uint8]chat.UserRelationship, 0),
 		table:               tview.NewTable(),
-		currentThemeCode:    "NOT_SET",
 	}
 }
 
 // Setup sets up the accept friend request page and registers it with the page navigator
 func (page *AcceptFriendRequestPage) Setup(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
-
-	theme := appContext.GetTheme()
+	keys := appContext.GetKeymap()
 
 	tvHeader := tview.NewTextView().SetTextAlign(tview.AlignCenter)
 	tvHeader.SetText("Pending Friend Requests")
@@ -53,47 +67,102 @@ func (page *AcceptFriendRequestPage) Setup(app *tview.Application, appContext *s
 			return
 		}
 
+		if selectedUser.Type&chat.RELATIONSHIP_TYPE_FRIEND_REQUEST_RECIEVED == 0 {
+			nav.Alert(FIND_A_FRIEND_PAGE_ALERT_INFO, fmt.Sprintf("Waiting for %s to accept your friend request.", selectedUser.Username))
+			return
+		}
+
 		accessToken, ok := appContext.GetAccessToken()
 
 		if !ok {
-			log.Printf("Valid user authentication information not found. Redirecting to login page.")
-			nav.NavigateTo(LOGIN_PAGE, nil)
+			slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+			nav.RedirectToLogin()
 			return
 		}
 
-		nav.Confirm(FIND_A_FRIEND_PAGE_CONFIRM, fmt.Sprintf("Accept Friend Request from %s?", selectedUser.Username), func() {
-			result := page.brochatClient.AcceptFriendRequest(accessToken, chat.AcceptFriendRequestRequest{
-				InitiatingUserId: selectedUser.UserId,
-			})
-
-			err := result.Err()
+		var acceptFriendRequest func()
 
-			if err != nil {
-				if len(result.ErrorDetails) > 0 {
-					nav.Alert(FIND_A_FRIEND_PAGE_ALERT_INFO, result.ErrorDetails[0])
+		acceptFriendRequest = func() {
+			runSessionAsync(app, appContext, nav, "Accepting friend request...", func(ctx context.Context) chat.BroChatClientResult {
+				return page.brochatClient.AcceptFriendRequest(accessToken, chat.AcceptFriendRequestRequest{
+					InitiatingUserId: selectedUser.UserId,
+				})
+			}, func(result chat.BroChatClientResult) {
+				if err := apperr.FromChatResult(result); err != nil {
+					nav.AlertError(FIND_A_FRIEND_PAGE_ALERT_ERR, "The friend request could not be accepted.", err, acceptFriendRequest)
 					return
 				}
 
-				if result.ResponseCode == chat.BROCHAT_RESPONSE_CODE_FORBIDDEN_ERROR {
-					nav.Alert(FIND_A_FRIEND_PAGE_ALERT_INFO, FORBIDDEN_OPERATION_ERROR_MESSAGE)
-					return
-				}
-
-				nav.AlertFatal(app, FIND_A_FRIEND_PAGE_ALERT_ERR, err.Error())
-				return
-			}
+				page.table.RemoveRow(row)
+				nav.Toast(fmt.Sprintf("Accepted Friend Request from %s", selectedUser.Username))
+			})
+		}
 
-			page.table.RemoveRow(row)
-			nav.Alert(FIND_A_FRIEND_PAGE_ALERT_INFO, fmt.Sprintf("Accepted Friend Request from %s", selectedUser.Username))
-		})
+		nav.Confirm(FIND_A_FRIEND_PAGE_CONFIRM, fmt.Sprintf("Accept Friend Request from %s?", selectedUser.Username), acceptFriendRequest)
 	})
 
+	vimNavigation := vimTableNavigation(app, appContext, nav, page.table, 2, nil)
+
 	page.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyEscape {
-			nav.NavigateTo(FRIENDS_LIST_PAGE, nil)
+		if vimNavigation(event) {
+			return nil
+		}
+
+		if action, ok := keys.Match(event, keymap.ACTION_DECLINE_REQUEST, keymap.ACTION_CANCEL_REQUEST, keymap.ACTION_BLOCK_USER); ok {
+			row, _ := page.table.GetSelection()
+			selectedUser, ok := page.userPendingRequests[uint8(row)]
+
+			if !ok {
+				return event
+			}
+
+			received := selectedUser.Type&chat.RELATIONSHIP_TYPE_FRIEND_REQUEST_RECIEVED != 0
+
+			switch action {
+			case keymap.ACTION_DECLINE_REQUEST:
+				if !received {
+					return nil
+				}
+
+				nav.Confirm(FIND_A_FRIEND_PAGE_CONFIRM, fmt.Sprintf("Decline Friend Request from %s?", selectedUser.Username), func() {
+					page.updateRequest(app, appContext, nav, fmt.Sprintf("Declined Friend Request from %s", selectedUser.Username), func(ctx context.Context, accessToken string) chat.BroChatClientResult {
+						return page.chatextClient.DeclineFriendRequest(ctx, accessToken, selectedUser.UserId)
+					}, nil)
+				})
+
+				return nil
+			case keymap.ACTION_CANCEL_REQUEST:
+				if received {
+					return nil
+				}
+
+				nav.Confirm(FIND_A_FRIEND_PAGE_CONFIRM, fmt.Sprintf("Cancel Friend Request to %s?", selectedUser.Username), func() {
+					page.updateRequest(app, appContext, nav, fmt.Sprintf("Cancelled Friend Request to %s", selectedUser.Username), func(ctx context.Context, accessToken string) chat.BroChatClientResult {
+						return page.chatextClient.CancelFriendRequest(ctx, accessToken, selectedUser.UserId)
+					}, nil)
+				})
+
+				return nil
+			case keymap.ACTION_BLOCK_USER:
+				nav.Confirm(FIND_A_FRIEND_PAGE_CONFIRM, fmt.Sprintf("Block %s? Their messages will be hidden.", selectedUser.Username), func() {
+					page.updateRequest(app, appContext, nav, fmt.Sprintf("%s has been blocked.", selectedUser.Username), func(ctx context.Context, accessToken string) chat.BroChatClientResult {
+						return page.chatextClient.BlockUser(ctx, accessToken, selectedUser.UserId)
+					}, func() {
+						appContext.AddBlockedUser(chat.UserInfo{
+							Id:            selectedUser.UserId,
+							Username:      selectedUser.Username,
+							LastOnlineUtc: selectedUser.LastOnlineUtc,
+						})
+					})
+				})
+
+				return nil
+			}
+		} else if keys.Is(event, keymap.ACTION_BACK) {
+			nav.Back()
 			page.userPendingRequests = make(map[uint8]chat.UserRelationship, 0)
 			page.table.Clear()
-		} else if event.Key() == tcell.KeyTab {
+		} else if keys.Is(event, keymap.ACTION_NEXT) {
 			// Change the selected row to the next row
 			row, _ := page.table.GetSelection()
 			if row+1 >= page.table.GetRowCount() {
@@ -103,7 +172,7 @@ func (page *AcceptFriendRequestPage) Setup(app *tview.Application, appContext *s
 			}
 
 			page.table.Select(row, 0)
-		} else if event.Key() == tcell.KeyBacktab {
+		} else if keys.Is(event, keymap.ACTION_PREVIOUS) {
 			// Change the selected row to the previous row
 			row, _ := page.table.GetSelection()
 
@@ -120,12 +189,10 @@ func (page *AcceptFriendRequestPage) Setup(app *tview.Application, appContext *s
 	})
 
 	tvInstructions := tview.NewTextView().SetTextAlign(tview.AlignCenter)
-	tvInstructions.SetText("(esc) Quit")
+	tvInstructions.SetText(acceptFriendRequestInstructions(keys))
 
 	grid := tview.NewGrid()
-	grid.SetBackgroundColor(theme.BackgroundColor)
-
-	grid.SetRows(2, 1, 1, 0, 1, 1, 2)
+	grid.SetRows(2, 1, 1, 0, 1, 2, 2)
 	grid.SetColumns(0, 76, 0)
 
 	grid.AddItem(tvHeader, 1, 1, 1, 1, 0, 0, false)
@@ -135,30 +202,16 @@ func (page *AcceptFriendRequestPage) Setup(app *tview.Application, appContext *s
 	var pageContext context.Context
 	var cancel context.CancelFunc
 
-	applyTheme := func() {
-		theme := appContext.GetTheme()
-
-		if page.currentThemeCode != theme.Code {
-			page.currentThemeCode = theme.Code
-			grid.SetBackgroundColor(theme.BackgroundColor)
-			page.table.SetBordersColor(theme.BorderColor)
-			page.table.SetBorderColor(theme.BorderColor)
-			page.table.SetTitleColor(theme.TitleColor)
-			page.table.SetBackgroundColor(theme.BackgroundColor)
-			page.table.SetSelectedStyle(theme.DropdownListSelectedStyle)
-			tvHeader.SetBackgroundColor(theme.BackgroundColor)
-			tvHeader.SetTextColor(theme.TitleColor)
-			tvInstructions.SetBackgroundColor(theme.BackgroundColor)
-			tvInstructions.SetTextColor(theme.InfoColor)
-		}
-	}
+	themes := appContext.GetThemeManager()
 
-	applyTheme()
+	themeBackground(themes, grid)
+	themeTextView(themes, tvHeader, headerTextColor)
+	themeTable(themes, page.table)
+	themeTextView(themes, tvInstructions, instructionsTextColor)
 
 	nav.Register(ACCEPT_FRIEND_REQUEST_PAGE, grid, true, false,
-		func(param interface{}) {
+		func(param PageParameters) {
 			pageContext, cancel = appContext.GenerateUserSessionBoundContextWithCancel()
-			applyTheme()
 			page.onPageLoad(app, appContext, pageContext, page.feedClient)
 		},
 		func() {
@@ -209,7 +262,13 @@ func (page *AcceptFriendRequestPage) populateTable(brochatUser chat.User, thm th
 		SetSelectable(false).
 		SetAttributes(tcell.AttrBold|tcell.AttrUnderline))
 
-	page.table.SetCell(0, 1, tview.NewTableCell("Last Active").
+	page.table.SetCell(0, 1, tview.NewTableCell("Request").
+		SetTextColor(thm.ForgroundColor).
+		SetAlign(tview.AlignCenter).
+		SetSelectable(false).
+		SetAttributes(tcell.AttrBold|tcell.AttrUnderline))
+
+	page.table.SetCell(0, 2, tview.NewTableCell("Last Active").
 		SetTextColor(thm.ForgroundColor).
 		SetAlign(tview.AlignRight).
 		SetSelectable(false).
@@ -217,14 +276,53 @@ func (page *AcceptFriendRequestPage) populateTable(brochatUser chat.User, thm th
 
 	row := 1
 
-	for _, rel := range brochatUser.Relationships {
-		if rel.Type&chat.RELATIONSHIP_TYPE_FRIEND_REQUEST_RECIEVED != 0 {
-			page.table.SetCell(row, 0, tview.NewTableCell(rel.Username).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignCenter))
-			var dateString string = rel.LastOnlineUtc.Local().Format("Jan 2, 2006")
-			page.table.SetCell(row, 1, tview.NewTableCell(dateString).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignRight))
+	// Received requests are listed before sent requests
+	for _, requestType := range []chat.RelationshipType{chat.RELATIONSHIP_TYPE_FRIEND_REQUEST_RECIEVED, chat.RELATIONSHIP_TYPE_FRIENDSHIP_REQUESTED} {
+		direction := "Received"
+
+		if requestType == chat.RELATIONSHIP_TYPE_FRIENDSHIP_REQUESTED {
+			direction = "Sent"
+		}
+
+		for _, rel := range brochatUser.Relationships {
+			if rel.Type&requestType != 0 {
+				page.table.SetCell(row, 0, tview.NewTableCell(rel.Username).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignCenter))
+				page.table.SetCell(row, 1, tview.NewTableCell(direction).SetTextColor(thm.InfoColorTwo).SetAlign(tview.AlignCenter))
+				var dateString string = rel.LastOnlineUtc.Local().Format("Jan 2, 2006")
+				page.table.SetCell(row, 2, tview.NewTableCell(dateString).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignRight))
 
-			page.userPendingRequests[uint8(row)] = rel
-			row++
+				page.userPendingRequests[uint8(row)] = rel
+				row++
+			}
 		}
 	}
 }
+
+// updateRequest performs a change to a friend request and alerts the user to the outcome. onSuccess, which may be nil, is called if the change succeeded.
+// The table is redrawn when the change is reflected back through a user profile update event.
+func (page *AcceptFriendRequestPage) updateRequest(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator, successMessage string, change func(ctx context.Context, accessToken string) chat.BroChatClientResult, onSuccess func()) {
+	accessToken, ok := appContext.GetAccessToken()
+
+	if !ok {
+		slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+		nav.RedirectToLogin()
+		return
+	}
+
+	runSessionAsync(app, appContext, nav, "Updating friend request...", func(ctx context.Context) chat.BroChatClientResult {
+		return change(ctx, accessToken)
+	}, func(result chat.BroChatClientResult) {
+		if err := apperr.FromChatResult(result); err != nil {
+			nav.AlertError(FIND_A_FRIEND_PAGE_ALERT_ERR, "The friend request could not be updated.", err, func() {
+				page.updateRequest(app, appContext, nav, successMessage, change, onSuccess)
+			})
+			return
+		}
+
+		nav.Toast(successMessage)
+
+		if onSuccess != nil {
+			onSuccess()
+		}
+	})
+}
@@ -1,12 +1,14 @@
 package ui
 
 import (
-	"encoding/json"
-	"log"
-	"os"
-	"path/filepath"
+	"log/slog"
+	"slices"
+	"strings"
 
+	"github.com/dmars8047/broterm/internal/apperr"
 	"github.com/dmars8047/broterm/internal/config"
+	"github.com/dmars8047/broterm/internal/keymap"
+	"github.com/dmars8047/broterm/internal/logging"
 	"github.com/dmars8047/broterm/internal/state"
 	"github.com/dmars8047/broterm/internal/theme"
 	"github.com/gdamore/tcell/v2"
@@ -15,31 +17,34 @@ import (
 
 const APP_SETTINGS_PAGE PageSlug = "app_settings"
 
+const APP_SETTINGS_PAGE_ALERT_ERR = "app_settings:alert:err"
+
 // AppSettingsPage is the location where users can configure application level settings.
 type AppSettingsPage struct {
-	settingsForm  *tview.Form
-	currentTheme  string
-	logginEnabled bool
+	settingsForm *tview.Form
+	settings     config.ConfigSettings
 }
 
-// NewAppSettingsPage creates a new instance of the application settings page
-func NewAppSettingsPage(logginEnabled bool) *AppSettingsPage {
+// NewAppSettingsPage creates a new instance of the application settings page.
+// settings are the settings loaded from the config file. Settings which are not shown on the page, such as the network settings, are saved unchanged.
+func NewAppSettingsPage(settings config.ConfigSettings) *AppSettingsPage {
 	return &AppSettingsPage{
-		settingsForm:  tview.NewForm(),
-		currentTheme:  "NOT_SET",
-		logginEnabled: logginEnabled,
+		settingsForm: tview.NewForm(),
+		settings:     settings,
 	}
 }
 
 // Setup configures the application settings page and registers it with the page navigator
 // The page includes a form which allows the user to set the following settings:
 // The host address of the server
-// The theme (default, america, matrix, halloween, and morning)
+// The theme (the built-in themes and any custom themes in the themes directory)
 // The log and setting config file storage location
+// The minimum level of the messages written to the log files
+// Whether the vim style navigation keys are enabled
 func (page *AppSettingsPage) Setup(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
-	const title = " BroChat - Application Settings "
+	keys := appContext.GetKeymap()
 
-	page.currentTheme = appContext.GetTheme().Code
+	const title = " BroChat - Application Settings "
 
 	grid := tview.NewGrid()
 	grid.SetRows(4, 0, 1, 3, 4)
@@ -48,175 +53,197 @@ func (page *AppSettingsPage) Setup(app *tview.Application, appContext *state.App
 	page.settingsForm.SetBorder(true).SetTitle(title).SetTitleAlign(tview.AlignCenter)
 
 	// Dropdown for theme selection
-	page.settingsForm.AddDropDown("Theme: ", []string{"default", "america", "matrix", "halloween", "christmas", "satanic"}, 0, nil)
+	page.settingsForm.AddDropDown("Theme: ", theme.Codes(), 0, nil)
 
 	page.settingsForm.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyEscape {
-			nav.NavigateTo(WELCOME_PAGE, nil)
+		if keys.Is(event, keymap.ACTION_BACK) {
+			nav.Back()
 			return nil
 		}
 
 		return event
 	})
 
-	applyTheme := func(previewTheme *theme.Theme) {
-		var theme theme.Theme
+	themes := appContext.GetThemeManager()
 
-		isPreview := previewTheme != nil
-
-		if isPreview {
-			theme = *previewTheme
-		} else {
-			theme = appContext.GetTheme()
-		}
-
-		nav.Pages.SetBackgroundColor(theme.BackgroundColor)
-		theme.ApplyGlobals()
-
-		grid.SetBackgroundColor(theme.BackgroundColor)
-		page.settingsForm.SetBackgroundColor(theme.AccentColor)
-		page.settingsForm.SetFieldBackgroundColor(theme.AccentColorTwo)
-		page.settingsForm.SetFieldTextColor(theme.ForgroundColor)
-		page.settingsForm.SetLabelColor(theme.HighlightColor)
-		page.settingsForm.SetButtonStyle(theme.ButtonStyle)
-		page.settingsForm.SetButtonActivatedStyle(theme.ActivatedButtonStyle)
-		page.settingsForm.SetBorderColor(theme.BorderColor)
-		page.settingsForm.SetTitleColor(theme.TitleColor)
+	// Get the dropdown
+	themeDropdown, ok := page.settingsForm.GetFormItemByLabel("Theme: ").(*tview.DropDown)
 
-		// Get the dropdown
-		themeDropdown, ok := page.settingsForm.GetFormItemByLabel("Theme: ").(*tview.DropDown)
+	if !ok {
+		slog.Error("Theme dropdown form access failure on setup for settings page")
+	}
 
-		if ok {
-			themeDropdown.SetListStyles(theme.DropdownListUnselectedStyle, theme.DropdownListSelectedStyle)
-		} else {
-			log.Printf("Theme dropdown form access failure on theme change for settings page")
+	// populateThemes lists the built-in and custom themes and selects the current theme
+	populateThemes := func() {
+		if themeDropdown == nil {
+			return
 		}
 
-		page.currentTheme = theme.Code
-	}
+		codes := theme.Codes()
+		currentCode := appContext.GetTheme().Code
+		selected := 0
 
-	// Get the dropdown
-	themeDropdown, ok := page.settingsForm.GetFormItemByLabel("Theme: ").(*tview.DropDown)
+		for i, code := range codes {
+			if code == currentCode {
+				selected = i
+				break
+			}
+		}
 
-	if !ok {
-		log.Printf("Theme dropdown form access failure on setup for settings page")
-	} else {
-		themeDropdown.SetSelectedFunc(func(text string, index int) {
-			preview := theme.NewTheme(text)
-			applyTheme(preview)
+		// Selecting a theme previews it on every page until the settings are saved or the page is closed
+		themeDropdown.SetOptions(codes, func(text string, index int) {
+			themes.Preview(text)
 		})
+
+		themeDropdown.SetCurrentOption(selected)
 	}
 
 	page.settingsForm.AddCheckbox("Keep Error Log Files: ", true, nil)
+	page.settingsForm.AddDropDown("Log Level: ", logging.Levels(), 0, nil)
+	page.settingsForm.AddCheckbox("Vim Navigation: ", false, nil)
+
+	themeBackground(themes, grid)
+	themeForm(themes, page.settingsForm)
 
 	// Add the save and back buttons
-	page.settingsForm.AddButton("Save & Apply", func() {
+	var save func()
 
+	save = func() {
 		// Get the logs flag from the form
 		logsCheckbox, ok := page.settingsForm.GetFormItemByLabel("Keep Error Log Files: ").(*tview.Checkbox)
 
 		if !ok {
-			log.Printf("Logs checkbox form access failure on save for settings page")
-			panic("logs checkbox form access failure")
+			nav.Alert(APP_SETTINGS_PAGE_ALERT_ERR, "Settings Not Saved - Keep Error Log Files Field Unavailable")
+			return
 		}
 
-		// Get the theme from the dropdown
-		themeDropdown, ok := page.settingsForm.GetFormItemByLabel("Theme: ").(*tview.DropDown)
+		// Get the log level from the dropdown
+		logLevelDropdown, ok := page.settingsForm.GetFormItemByLabel("Log Level: ").(*tview.DropDown)
 
 		if !ok {
-			log.Printf("Theme dropdown form access failure on save for settings page")
-			panic("theme dropdown form access failure")
+			nav.Alert(APP_SETTINGS_PAGE_ALERT_ERR, "Settings Not Saved - Log Level Field Unavailable")
+			return
 		}
 
-		_, themeText := themeDropdown.GetCurrentOption()
-
-		appSettings := config.NewConfigSettings()
-		appSettings.Theme = themeText
-		appSettings.LoggingEnabled = logsCheckbox.IsChecked()
-
-		bytesToSave, err := json.Marshal(appSettings)
-
-		if err != nil {
-			log.Printf("Error marshalling app settings: %v", err)
-			panic("error marshalling app settings")
-		}
-
-		homeDir, err := os.UserHomeDir()
+		// Get the theme from the dropdown
+		themeDropdown, ok := page.settingsForm.GetFormItemByLabel("Theme: ").(*tview.DropDown)
 
-		if err != nil {
-			log.Printf("Error getting user home directory: %v", err)
-			panic("error getting user home directory")
+		if !ok {
+			nav.Alert(APP_SETTINGS_PAGE_ALERT_ERR, "Settings Not Saved - Theme Field Unavailable")
+			return
 		}
 
-		configDir := filepath.Join(homeDir, config.DEFAULT_CONFIG_DIRECTORY_NAME)
+		// Get the vim navigation flag from the form
+		vimCheckbox, ok := page.settingsForm.GetFormItemByLabel("Vim Navigation: ").(*tview.Checkbox)
 
-		//If the config directory does not exist, create it
-		if _, err := os.Stat(configDir); os.IsNotExist(err) {
-			err = os.Mkdir(configDir, os.ModePerm)
-
-			if err != nil {
-				log.Printf("Error creating config directory: %v", err)
-				panic("error creating config directory")
-			}
+		if !ok {
+			nav.Alert(APP_SETTINGS_PAGE_ALERT_ERR, "Settings Not Saved - Vim Navigation Field Unavailable")
+			return
 		}
 
-		configFilePath := filepath.Join(configDir, config.CONFIG_FILE_NAME)
+		_, themeText := themeDropdown.GetCurrentOption()
+		_, logLevelText := logLevelDropdown.GetCurrentOption()
 
-		err = os.WriteFile(configFilePath, bytesToSave, 0644)
+		appSettings := page.settings
+		appSettings.Theme = themeText
+		appSettings.LoggingEnabled = logsCheckbox.IsChecked()
+		appSettings.LogLevel = logLevelText
+		appSettings.VimMode = vimCheckbox.IsChecked()
 
-		if err != nil {
-			log.Printf("Error writing app settings to file: %v", err)
-			panic("error writing app settings to file")
+		if err := appSettings.Save(); err != nil {
+			nav.AlertError(APP_SETTINGS_PAGE_ALERT_ERR, "Settings Not Saved", apperr.Local(err), save)
+			return
 		}
 
 		// Save the theme to the config
 		appContext.SetTheme(themeText)
-		page.logginEnabled = logsCheckbox.IsChecked()
+		page.settings = appSettings
+
+		if err := logging.SetLevel(logLevelText); err != nil {
+			slog.Error("The log level could not be applied", "err", err)
+		}
+		appContext.SetVimModeEnabled(vimCheckbox.IsChecked())
 
 		nav.AlertWithDoneFunc("Settings Saved", "Settings have been saved and applied. Some settings may require an application restart.", func(_ int, _ string) {
-			nav.NavigateTo(WELCOME_PAGE, nil)
+			nav.Back()
 		})
-	})
+	}
+
+	page.settingsForm.AddButton("Save & Apply", save)
 
 	page.settingsForm.AddButton("Back", func() {
-		nav.NavigateTo(WELCOME_PAGE, nil)
+		nav.Back()
 	})
 
 	grid.AddItem(page.settingsForm, 1, 1, 1, 1, 0, 0, true)
 
-	nav.Register(APP_SETTINGS_PAGE, grid, true, false, func(param interface{}) {
-		applyTheme(nil)
+	nav.Register(APP_SETTINGS_PAGE, grid, true, false, func(param PageParameters) {
 		page.settingsForm.SetFocus(0)
 
-		// Set the dropdown to the current theme
-		switch page.currentTheme {
-		case "default":
-			themeDropdown.SetCurrentOption(0)
-		case "america":
-			themeDropdown.SetCurrentOption(1)
-		case "matrix":
-			themeDropdown.SetCurrentOption(2)
-		case "halloween":
-			themeDropdown.SetCurrentOption(3)
-		case "christmas":
-			themeDropdown.SetCurrentOption(4)
-		case "satanic":
-			themeDropdown.SetCurrentOption(5)
-		default:
-			themeDropdown.SetCurrentOption(0)
+		// Pick up any theme files which have been added or changed since the last time the page was opened
+		loadErrs := page.reloadCustomThemes()
+
+		populateThemes()
+
+		if len(loadErrs) > 0 {
+			nav.AlertErrors(APP_SETTINGS_PAGE_ALERT_ERR, "Some custom themes could not be loaded", loadErrs)
 		}
 
 		// Set the logs checkbox to the current value
-		logsCheckbox, ok := page.settingsForm.GetFormItemByLabel("Keep Error Log Files: ").(*tview.Checkbox)
-
-		if !ok {
-			log.Printf("Logs checkbox form access failure on open for settings page")
-			panic("logs checkbox form access failure")
+		if logsCheckbox, ok := page.settingsForm.GetFormItemByLabel("Keep Error Log Files: ").(*tview.Checkbox); ok {
+			logsCheckbox.SetChecked(page.settings.LoggingEnabled)
+		} else {
+			slog.Error("Logs checkbox form access failure on open for settings page")
 		}
 
-		logsCheckbox.SetChecked(page.logginEnabled)
+		// Select the current log level
+		if logLevelDropdown, ok := page.settingsForm.GetFormItemByLabel("Log Level: ").(*tview.DropDown); ok {
+			logLevelDropdown.SetCurrentOption(logLevelOption(page.settings.LogLevel))
+		} else {
+			slog.Error("Log level dropdown form access failure on open for settings page")
+		}
 
+		// Set the vim navigation checkbox to the current value
+		if vimCheckbox, ok := page.settingsForm.GetFormItemByLabel("Vim Navigation: ").(*tview.Checkbox); ok {
+			vimCheckbox.SetChecked(appContext.IsVimModeEnabled())
+		} else {
+			slog.Error("Vim navigation checkbox form access failure on open for settings page")
+		}
 	}, func() {
-		applyTheme(nil)
+		// Discard any theme which was previewed but not saved
+		themes.Apply()
 	})
 }
+
+// logLevelOption returns the index of the log level in the log level dropdown. Unknown levels select the default level.
+func logLevelOption(level string) int {
+	levels := logging.Levels()
+
+	for i, name := range levels {
+		if strings.EqualFold(name, level) {
+			return i
+		}
+	}
+
+	return slices.Index(levels, logging.DEFAULT_LEVEL)
+}
+
+// reloadCustomThemes loads the custom themes from the themes directory.
+// Returns a message for each theme file which could not be loaded.
+func (page *AppSettingsPage) reloadCustomThemes() []string {
+	themesDir, err := config.ThemesDirectoryPath()
+
+	if err != nil {
+		slog.Warn("The themes directory could not be located", "err", err)
+		return []string{"the themes directory could not be located"}
+	}
+
+	messages := make([]string, 0)
+
+	for _, err := range theme.LoadCustomThemes(themesDir) {
+		slog.Warn("A custom theme could not be loaded", "err", err)
+		messages = append(messages, err.Error())
+	}
+
+	return messages
+}
@@ -0,0 +1,245 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dmars8047/broterm/internal/keymap"
+	"github.com/dmars8047/broterm/internal/state"
+	"github.com/dmars8047/broterm/internal/theme"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// LOADING_INDICATOR is the page the loading indicator is shown on
+const LOADING_INDICATOR = "loading_indicator"
+
+// spinnerInterval is how often the spinner moves to its next frame
+const spinnerInterval = 100 * time.Millisecond
+
+// spinnerFrames are the frames of the loading spinner
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// asyncResult is the response of a request which returns a value and an error
+type asyncResult[T any] struct {
+	value T
+	err   error
+}
+
+// runAsync runs the request on a background goroutine while the loading indicator is shown above the current page, so the UI keeps drawing while waiting on the server.
+// apply is called on the UI goroutine with the response unless the request was cancelled, either with the back key, by navigating to another page or by ctx being cancelled.
+// Only one request runs at a time, starting a request cancels the request which is already running.
+// Must be called on the UI goroutine.
+func runAsync[T any](app *tview.Application, nav *PageNavigator, ctx context.Context, message string, request func(ctx context.Context) T, apply func(response T)) {
+	requestCtx, id := nav.loading.start(ctx, message)
+
+	go func() {
+		response := request(requestCtx)
+
+		app.QueueUpdateDraw(func() {
+			if nav.loading.finish(id) {
+				apply(response)
+			}
+		})
+	}()
+}
+
+// runSessionAsync runs the request like runAsync with a context bound to the user session, so that ending the session cancels the request.
+// Used by pages which do not have a page context of their own.
+func runSessionAsync[T any](app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator, message string, request func(ctx context.Context) T, apply func(response T)) {
+	ctx, cancel := appContext.GenerateUserSessionBoundContextWithCancel()
+
+	runAsync(app, nav, ctx, message, func(ctx context.Context) T {
+		defer cancel()
+		return request(ctx)
+	}, apply)
+}
+
+// loadingIndicator shows a spinner above the current page while a request runs in the background.
+// The indicator takes focus so the page cannot be used until the request completes, and the back key cancels the request.
+// The indicator is only used from the UI goroutine.
+type loadingIndicator struct {
+	app           *tview.Application
+	pages         *tview.Pages
+	appContext    *state.ApplicationContext
+	grid          *tview.Grid
+	textView      *tview.TextView
+	message       string
+	frame         int
+	active        int
+	sequence      int
+	cancel        context.CancelFunc
+	previousFocus tview.Primitive
+}
+
+// newLoadingIndicator creates a loading indicator which is shown above the pages
+func newLoadingIndicator(app *tview.Application, pages *tview.Pages, appContext *state.ApplicationContext) *loadingIndicator {
+	indicator := &loadingIndicator{
+		app:        app,
+		pages:      pages,
+		appContext: appContext,
+		grid:       tview.NewGrid(),
+		textView:   tview.NewTextView(),
+	}
+
+	indicator.textView.SetBorder(true)
+	indicator.textView.SetTextAlign(tview.AlignCenter)
+	indicator.textView.SetDynamicColors(false)
+
+	indicator.textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if appContext.GetKeymap().Is(event, keymap.ACTION_BACK) {
+			indicator.cancelActive()
+		}
+
+		// The page cannot be used while the request is running
+		return nil
+	})
+
+	indicator.grid.SetRows(0, 4, 0)
+	indicator.grid.SetColumns(0, 50, 0)
+	indicator.grid.AddItem(indicator.textView, 1, 1, 1, 1, 0, 0, true)
+
+	appContext.GetThemeManager().Register(indicator.style)
+
+	return indicator
+}
+
+// start shows the indicator for a new request and returns the context of the request along with its id.
+// A request which is already running is cancelled. The context is cancelled when the request finishes or is cancelled.
+func (indicator *loadingIndicator) start(parent context.Context, message string) (context.Context, int) {
+	indicator.cancelActive()
+
+	ctx, cancel := context.WithCancel(parent)
+
+	indicator.sequence++
+	indicator.active = indicator.sequence
+	indicator.cancel = cancel
+	indicator.message = message
+	indicator.frame = 0
+
+	indicator.show()
+
+	id := indicator.active
+
+	// Animate the spinner until the request finishes. Hide the indicator if the parent context is cancelled, for example when the page is closed.
+	go func() {
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				indicator.app.QueueUpdateDraw(func() {
+					indicator.finish(id)
+				})
+				return
+			case <-ticker.C:
+				indicator.app.QueueUpdateDraw(func() {
+					indicator.tick(id)
+				})
+			}
+		}
+	}()
+
+	return ctx, id
+}
+
+// finish hides the indicator if the request is still the active request.
+// Returns false if the request was cancelled or replaced by another request, in which case its response should be discarded.
+func (indicator *loadingIndicator) finish(id int) bool {
+	if indicator.active != id || indicator.cancel == nil {
+		return false
+	}
+
+	indicator.cancel()
+	indicator.cancel = nil
+	indicator.active = 0
+	indicator.hide()
+
+	return true
+}
+
+// cancelActive cancels the running request, if there is one, and hides the indicator
+func (indicator *loadingIndicator) cancelActive() {
+	if indicator.cancel == nil {
+		return
+	}
+
+	indicator.cancel()
+	indicator.cancel = nil
+	indicator.active = 0
+	indicator.hide()
+}
+
+// isLoading returns true if a request is running
+func (indicator *loadingIndicator) isLoading() bool {
+	return indicator.cancel != nil
+}
+
+// tick moves the spinner of the request to its next frame
+func (indicator *loadingIndicator) tick(id int) {
+	if indicator.active != id {
+		return
+	}
+
+	indicator.frame = (indicator.frame + 1) % len(spinnerFrames)
+	indicator.render()
+}
+
+// keepOnTop shows the indicator above a page which has just been switched to, for example when the page started loading as it was opened.
+// The page's focused primitive is remembered so that focus is returned to the new page once the request finishes.
+func (indicator *loadingIndicator) keepOnTop() {
+	if !indicator.isLoading() {
+		return
+	}
+
+	indicator.previousFocus = indicator.app.GetFocus()
+	indicator.pages.ShowPage(LOADING_INDICATOR).SendToFront(LOADING_INDICATOR)
+	indicator.app.SetFocus(indicator.textView)
+}
+
+// show adds the indicator above the current page and focuses it
+func (indicator *loadingIndicator) show() {
+	indicator.style(indicator.appContext.GetTheme())
+	indicator.render()
+
+	if !indicator.pages.HasPage(LOADING_INDICATOR) {
+		indicator.previousFocus = indicator.app.GetFocus()
+		indicator.pages.AddPage(LOADING_INDICATOR, indicator.grid, true, true)
+	}
+
+	indicator.app.SetFocus(indicator.textView)
+}
+
+// hide removes the indicator and returns focus to the primitive which had focus before it was shown
+func (indicator *loadingIndicator) hide() {
+	if !indicator.pages.HasPage(LOADING_INDICATOR) {
+		return
+	}
+
+	// Leave focus alone if something else, such as a modal, has taken it since the indicator was shown
+	hadFocus := indicator.textView.HasFocus()
+
+	indicator.pages.HidePage(LOADING_INDICATOR).RemovePage(LOADING_INDICATOR)
+
+	if hadFocus && indicator.previousFocus != nil {
+		indicator.app.SetFocus(indicator.previousFocus)
+	}
+
+	indicator.previousFocus = nil
+}
+
+// render writes the current spinner frame and message
+func (indicator *loadingIndicator) render() {
+	keys := indicator.appContext.GetKeymap()
+
+	indicator.textView.SetText(fmt.Sprintf("%s %s\n%s", spinnerFrames[indicator.frame], indicator.message, keys.Hint(keymap.ACTION_BACK, "Cancel")))
+}
+
+// style themes the indicator
+func (indicator *loadingIndicator) style(thm theme.Theme) {
+	indicator.textView.SetBackgroundColor(thm.AccentColor)
+	indicator.textView.SetTextColor(thm.ForgroundColor)
+	indicator.textView.SetBorderColor(thm.BorderColor)
+}
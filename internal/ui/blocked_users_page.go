@@ -0,0 +1,218 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/apperr"
+	"github.com/dmars8047/broterm/internal/chatext"
+	"github.com/dmars8047/broterm/internal/keymap"
+	"github.com/dmars8047/broterm/internal/state"
+	"github.com/dmars8047/broterm/internal/theme"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const BLOCKED_USERS_PAGE PageSlug = "blocked_users"
+
+const (
+	BLOCKED_USERS_PAGE_ALERT_ERR = "home:blockedusers:alert:err"
+	BLOCKED_USERS_PAGE_CONFIRM   = "home:blockedusers:confirm"
+)
+
+// BlockedUsersPage is the page listing the users the user has blocked
+type BlockedUsersPage struct {
+	chatextClient *chatext.Client
+	table         *tview.Table
+	blockedUsers  map[int]chat.UserInfo
+}
+
+// NewBlockedUsersPage creates a new blocked users page
+func NewBlockedUsersPage(chatextClient *chatext.Client) *BlockedUsersPage {
+	return &BlockedUsersPage{
+		chatextClient: chatextClient,
+		table:         tview.NewTable(),
+		blockedUsers:  make(map[int]chat.UserInfo, 0),
+	}
+}
+
+// Setup sets up the blocked users page and registers it with the page navigator
+func (page *BlockedUsersPage) Setup(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	keys := appContext.GetKeymap()
+
+	tvHeader := tview.NewTextView().SetTextAlign(tview.AlignCenter)
+	tvHeader.SetText("Blocked Users")
+
+	page.table.SetBorders(true)
+	page.table.SetFixed(1, 1)
+	page.table.SetSelectable(true, false)
+
+	page.table.SetSelectedFunc(func(row int, _ int) {
+		blockedUser, ok := page.blockedUsers[row]
+
+		if !ok {
+			return
+		}
+
+		var unblockUser func()
+
+		unblockUser = func() {
+			accessToken, ok := appContext.GetAccessToken()
+
+			if !ok {
+				slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+				nav.RedirectToLogin()
+				return
+			}
+
+			runSessionAsync(app, appContext, nav, fmt.Sprintf("Unblocking %s...", blockedUser.Username), func(ctx context.Context) chat.BroChatClientResult {
+				return page.chatextClient.UnblockUser(ctx, accessToken, blockedUser.Id)
+			}, func(result chat.BroChatClientResult) {
+				if err := apperr.FromChatResult(result); err != nil {
+					nav.AlertError(BLOCKED_USERS_PAGE_ALERT_ERR, fmt.Sprintf("%s could not be unblocked.", blockedUser.Username), err, unblockUser)
+					return
+				}
+
+				appContext.RemoveBlockedUser(blockedUser.Id)
+				page.populateTable(appContext.GetBlockedUsers(), appContext.GetTheme())
+				nav.Toast(fmt.Sprintf("%s has been unblocked.", blockedUser.Username))
+			})
+		}
+
+		nav.Confirm(BLOCKED_USERS_PAGE_CONFIRM, fmt.Sprintf("Unblock %s?", blockedUser.Username), unblockUser)
+	})
+
+	vimNavigation := vimTableNavigation(app, appContext, nav, page.table, 2, nil)
+
+	page.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if vimNavigation(event) {
+			return nil
+		}
+
+		if keys.Is(event, keymap.ACTION_BACK) {
+			nav.Back()
+		} else if keys.Is(event, keymap.ACTION_NEXT) {
+			// Change the selected row to the next row
+			row, _ := page.table.GetSelection()
+			if row+1 >= page.table.GetRowCount() {
+				row = 1
+			} else {
+				row++
+			}
+
+			page.table.Select(row, 0)
+		} else if keys.Is(event, keymap.ACTION_PREVIOUS) {
+			// Change the selected row to the previous row
+			row, _ := page.table.GetSelection()
+
+			if row-1 < 1 {
+				row = page.table.GetRowCount() - 1
+			} else {
+				row--
+			}
+
+			page.table.Select(row, 0)
+		}
+
+		return event
+	})
+
+	tvInstructions := tview.NewTextView().SetTextAlign(tview.AlignCenter)
+	tvInstructions.SetText("(enter) Unblock - " + keys.Hint(keymap.ACTION_BACK, "Quit"))
+
+	grid := tview.NewGrid()
+
+	grid.SetRows(2, 1, 1, 0, 1, 1, 2)
+	grid.SetColumns(0, 76, 0)
+
+	grid.AddItem(tvHeader, 1, 1, 1, 1, 0, 0, false)
+	grid.AddItem(page.table, 3, 1, 1, 1, 0, 0, true)
+	grid.AddItem(tvInstructions, 5, 1, 1, 1, 0, 0, false)
+
+	themes := appContext.GetThemeManager()
+
+	themeBackground(themes, grid)
+	themeTextView(themes, tvHeader, headerTextColor)
+	themeTable(themes, page.table)
+	themeTextView(themes, tvInstructions, instructionsTextColor)
+
+	nav.Register(BLOCKED_USERS_PAGE, grid, true, false,
+		func(_ PageParameters) {
+			page.onPageLoad(app, appContext, nav)
+		},
+		func() {
+			page.onPageClose()
+		})
+}
+
+// onPageLoad is called when the blocked users page is navigated to.
+// The last known blocked users are shown while they are retrieved again so that blocks made from another client are shown.
+func (page *BlockedUsersPage) onPageLoad(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	accessToken, ok := appContext.GetAccessToken()
+
+	if !ok {
+		slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+		nav.RedirectToLogin()
+		return
+	}
+
+	page.populateTable(appContext.GetBlockedUsers(), appContext.GetTheme())
+
+	runSessionAsync(app, appContext, nav, "Loading blocked users...", func(ctx context.Context) chat.BroChatClientContentResult[[]chat.UserInfo] {
+		return page.chatextClient.GetBlockedUsers(ctx, accessToken)
+	}, func(result chat.BroChatClientContentResult[[]chat.UserInfo]) {
+		if err := result.Err(); err != nil {
+			slog.Error("An error occurred while retrieving blocked users", "err", err)
+			nav.Alert(BLOCKED_USERS_PAGE_ALERT_ERR, "The blocked users could not be retrieved. Showing the last known blocked users.")
+			return
+		}
+
+		appContext.SetBlockedUsers(result.Content)
+		page.populateTable(appContext.GetBlockedUsers(), appContext.GetTheme())
+	})
+}
+
+// onPageClose is called when the blocked users page is navigated away from
+func (page *BlockedUsersPage) onPageClose() {
+	page.blockedUsers = make(map[int]chat.UserInfo, 0)
+	page.table.Clear()
+}
+
+// populateTable lists the blocked users in the table
+func (page *BlockedUsersPage) populateTable(blockedUsers []chat.UserInfo, thm theme.Theme) {
+	page.table.Clear()
+	page.blockedUsers = make(map[int]chat.UserInfo, 0)
+
+	page.table.SetCell(0, 0, tview.NewTableCell("Username").
+		SetTextColor(thm.ForgroundColor).
+		SetAlign(tview.AlignCenter).
+		SetExpansion(1).
+		SetSelectable(false).
+		SetAttributes(tcell.AttrBold|tcell.AttrUnderline))
+
+	page.table.SetCell(0, 1, tview.NewTableCell("Last Active").
+		SetTextColor(thm.ForgroundColor).
+		SetAlign(tview.AlignRight).
+		SetSelectable(false).
+		SetAttributes(tcell.AttrBold|tcell.AttrUnderline))
+
+	sort.Slice(blockedUsers, func(i, j int) bool {
+		return strings.ToLower(blockedUsers[i].Username) < strings.ToLower(blockedUsers[j].Username)
+	})
+
+	for i, usr := range blockedUsers {
+		row := i + 1
+
+		page.table.SetCell(row, 0, tview.NewTableCell(usr.Username).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignCenter))
+		var dateString string = usr.LastOnlineUtc.Local().Format("Jan 2, 2006")
+		page.table.SetCell(row, 1, tview.NewTableCell(dateString).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignRight))
+
+		page.blockedUsers[row] = usr
+	}
+
+	page.table.Select(1, 0)
+}
@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/theme"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// memberPanelWidth is the width of the channel member panel on the chat page.
+const memberPanelWidth = 34
+
+// channelMember is a member of a channel along with their presence information.
+type channelMember struct {
+	user       chat.UserInfo
+	isSelf     bool
+	isOnline   bool
+	lastOnline time.Time
+	// relationship is the user's relationship with the member. It is nil if there is no relationship.
+	relationship *chat.UserRelationship
+}
+
+// isFriend returns true if the member is a friend of the user.
+func (member channelMember) isFriend() bool {
+	return member.relationship != nil && member.relationship.Type == chat.RELATIONSHIP_TYPE_FRIEND
+}
+
+// getChannelMembers resolves the presence of each channel user from the perspective of the brochat user.
+// Presence is only known for friends, other members only have a last online time.
+// Members are sorted online first then by username.
+func getChannelMembers(users []chat.UserInfo, brochatUser chat.User) []channelMember {
+	members := make([]channelMember, 0, len(users))
+
+	for _, u := range users {
+		member := channelMember{
+			user:       u,
+			lastOnline: u.LastOnlineUtc,
+		}
+
+		if u.Id == brochatUser.Id {
+			member.isSelf = true
+			member.isOnline = true
+		}
+
+		for i := range brochatUser.Relationships {
+			rel := brochatUser.Relationships[i]
+
+			if rel.UserId == u.Id {
+				member.relationship = &rel
+				member.lastOnline = rel.LastOnlineUtc
+
+				if rel.Type == chat.RELATIONSHIP_TYPE_FRIEND {
+					member.isOnline = rel.IsOnline
+				}
+
+				break
+			}
+		}
+
+		members = append(members, member)
+	}
+
+	sort.SliceStable(members, func(i, j int) bool {
+		if members[i].isOnline != members[j].isOnline {
+			return members[i].isOnline
+		}
+
+		return members[i].user.Username < members[j].user.Username
+	})
+
+	return members
+}
+
+// populateMemberTable fills the member panel with the channel members.
+func (page *ChatPage) populateMemberTable(users []chat.UserInfo, brochatUser chat.User, colorManifest map[string]string, thm theme.Theme) {
+	selectedRow, _ := page.memberTable.GetSelection()
+
+	page.memberTable.Clear()
+	page.members = make(map[int]channelMember, 0)
+
+	page.memberTable.SetTitle(fmt.Sprintf(" Members (%d) ", len(users)))
+
+	page.memberTable.SetCell(0, 0, tview.NewTableCell("Username").
+		SetTextColor(thm.ForgroundColor).
+		SetExpansion(1).
+		SetSelectable(false).
+		SetAttributes(tcell.AttrBold|tcell.AttrUnderline))
+
+	page.memberTable.SetCell(0, 1, tview.NewTableCell("Status").
+		SetTextColor(thm.ForgroundColor).
+		SetAlign(tview.AlignRight).
+		SetSelectable(false).
+		SetAttributes(tcell.AttrBold|tcell.AttrUnderline))
+
+	for i, member := range getChannelMembers(users, brochatUser) {
+		row := i + 1
+
+		color := tcell.GetColor(colorManifest[member.user.Id])
+
+		if color == tcell.ColorDefault {
+			color = thm.ForgroundColor
+		}
+
+		username := member.user.Username
+
+		if member.isSelf {
+			username += " (you)"
+		}
+
+		var status string
+
+		if member.isOnline {
+			status = "Online"
+		} else if member.isFriend() {
+			status = "Offline"
+		} else {
+			status = member.lastOnline.Local().Format("Jan 2")
+		}
+
+		page.memberTable.SetCell(row, 0, tview.NewTableCell(username).SetTextColor(color))
+		page.memberTable.SetCell(row, 1, tview.NewTableCell(status).SetTextColor(thm.InfoColorTwo).SetAlign(tview.AlignRight))
+
+		page.members[row] = member
+	}
+
+	if selectedRow < 1 || selectedRow >= page.memberTable.GetRowCount() {
+		selectedRow = 1
+	}
+
+	page.memberTable.Select(selectedRow, 0)
+}
+
+// toggleMemberPanel shows or hides the channel member panel. Focus moves to the panel when it is shown.
+func (page *ChatPage) toggleMemberPanel(app *tview.Application) {
+	if page.memberPanelVisible {
+		page.hideMemberPanel()
+		app.SetFocus(page.textArea)
+		return
+	}
+
+	page.memberPanelVisible = true
+	page.grid.SetColumns(0, memberPanelWidth)
+	page.grid.AddItem(page.memberTable, 0, 1, 1, 1, 0, 0, false)
+	app.SetFocus(page.memberTable)
+}
+
+// hideMemberPanel removes the channel member panel from the chat page.
+func (page *ChatPage) hideMemberPanel() {
+	if !page.memberPanelVisible {
+		return
+	}
+
+	page.memberPanelVisible = false
+	page.grid.RemoveItem(page.memberTable)
+	page.grid.SetColumns(0)
+}
@@ -3,11 +3,16 @@ package ui
 import (
 	"context"
 	"fmt"
-	"log"
+	"hash/fnv"
+	"log/slog"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/apperr"
+	"github.com/dmars8047/broterm/internal/keymap"
 	"github.com/dmars8047/broterm/internal/state"
 	"github.com/dmars8047/broterm/internal/theme"
 	"github.com/gdamore/tcell/v2"
@@ -16,30 +21,62 @@ import (
 
 const CHAT_PAGE PageSlug = "chat"
 
+const (
+	CHAT_PAGE_ALERT_INFO = "home:chat:alert:info"
+	CHAT_PAGE_ALERT_ERR  = "home:chat:alert:err"
+	CHAT_PAGE_CONFIRM    = "home:chat:confirm"
+
+	CHAT_PAGE_SEARCH_PROMPT = "home:chat:prompt:search"
+)
+
+// chatHistoryPageSize is the number of messages requested at a time when loading the chat history
+const chatHistoryPageSize = 100
+
+// chatConversation is the channel and newest messages retrieved when the chat page is opened
+type chatConversation struct {
+	getChannelResult         chat.BroChatClientContentResult[chat.Channel]
+	getChannelMessagesResult chat.BroChatClientContentResult[[]chat.ChatMessage]
+}
+
 // ChatPage is the chat page
 type ChatPage struct {
-	brochatClient    *chat.BroChatClient
-	feedClient       *state.FeedClient
-	textView         *tview.TextView
-	textArea         *tview.TextArea
-	mu               sync.Mutex
-	currentThemeCode string
+	brochatClient      *chat.BroChatClient
+	feedClient         *state.FeedClient
+	grid               *tview.Grid
+	textView           *tview.TextView
+	textArea           *tview.TextArea
+	tvTyping           *tview.TextView
+	memberTable        *tview.Table
+	members            map[int]channelMember
+	memberPanelVisible bool
+	typing             *typingTracker
+	lastTypingSent     time.Time
+	slashCommands      *SlashCommandRegistry
+	mu                 sync.Mutex
 }
 
 // NewChatPage creates a new chat page
 func NewChatPage(brochatClient *chat.BroChatClient, feedClient *state.FeedClient) *ChatPage {
 	return &ChatPage{
-		brochatClient:    brochatClient,
-		feedClient:       feedClient,
-		textView:         tview.NewTextView(),
-		textArea:         tview.NewTextArea(),
-		currentThemeCode: "NOT_SET",
+		brochatClient: brochatClient,
+		feedClient:    feedClient,
+		grid:          tview.NewGrid(),
+		textView:      tview.NewTextView(),
+		textArea:      tview.NewTextArea(),
+		tvTyping:      tview.NewTextView(),
+		memberTable:   tview.NewTable(),
+		members:       make(map[int]channelMember, 0),
+		typing:        newTypingTracker(),
+		slashCommands: newDefaultSlashCommandRegistry(brochatClient),
 	}
 }
 
 // Setup configures the chat page and registers it with the page navigator
 func (page *ChatPage) Setup(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	keys := appContext.GetKeymap()
+
 	page.textView.SetDynamicColors(true)
+	page.textView.SetRegions(true)
 	page.textView.SetBorder(true)
 	page.textView.SetScrollable(true)
 
@@ -51,45 +88,101 @@ func (page *ChatPage) Setup(app *tview.Application, appContext *state.Applicatio
 
 	tvInstructions := tview.NewTextView().SetTextAlign(tview.AlignCenter)
 
-	tvInstructions.SetText("(enter) Send - (pgup/pgdn) Scroll - (esc) Back")
+	// chatInstructions returns the instructions for the composer. In vim mode the back key moves to the chat history.
+	chatInstructions := func() string {
+		backHint := keys.Hint(keymap.ACTION_BACK, "Back")
+
+		if appContext.IsVimModeEnabled() {
+			backHint = keys.Hint(keymap.ACTION_BACK, "History")
+		}
+
+		return strings.Join([]string{
+			keys.Hint(keymap.ACTION_SEND_MESSAGE, "Send"),
+			"(/help) Commands",
+			keys.Hint(keymap.ACTION_COMPLETE_COMMAND, "Complete"),
+			keys.Hint(keymap.ACTION_TOGGLE_MEMBERS, "Members"),
+			fmt.Sprintf("(%s/%s) Scroll", keys.Label(keymap.ACTION_SCROLL_UP), keys.Label(keymap.ACTION_SCROLL_DOWN)),
+			backHint,
+		}, " - ")
+	}
+
+	historyInstructions := strings.Join([]string{
+		fmt.Sprintf("(%s/%s) Scroll", keys.Label(keymap.ACTION_VIM_DOWN), keys.Label(keymap.ACTION_VIM_UP)),
+		fmt.Sprintf("(%s%s/%s) Top/Bottom", keys.Label(keymap.ACTION_VIM_TOP), keys.Label(keymap.ACTION_VIM_TOP), keys.Label(keymap.ACTION_VIM_BOTTOM)),
+		fmt.Sprintf("(%s/%s) Half Page", keys.Label(keymap.ACTION_VIM_HALF_PAGE_DOWN), keys.Label(keymap.ACTION_VIM_HALF_PAGE_UP)),
+		keys.Hint(keymap.ACTION_SEARCH, "Search"),
+		keys.Hint(keymap.ACTION_VIM_INSERT, "Compose"),
+		keys.Hint(keymap.ACTION_BACK, "Back"),
+	}, " - ")
+
+	memberInstructions := strings.Join([]string{
+		keys.Hint(keymap.ACTION_MESSAGE_MEMBER, "Message"),
+		keys.Hint(keymap.ACTION_FRIEND_REQUEST, "Send Friend Request"),
+		keys.Hint(keymap.ACTION_WHO_IS, "Who Is"),
+		keys.Hint(keymap.ACTION_CLOSE_MEMBERS_PANEL, "Close Members"),
+	}, " - ")
+
+	tvInstructions.SetText(chatInstructions())
+
+	page.textArea.SetFocusFunc(func() {
+		tvInstructions.SetText(chatInstructions())
+	})
+
+	page.textView.SetFocusFunc(func() {
+		tvInstructions.SetText(historyInstructions)
+	})
+
+	page.tvTyping.SetTextAlign(tview.AlignLeft)
 
-	grid := tview.NewGrid()
+	page.memberTable.SetBorder(true)
+	page.memberTable.SetFixed(1, 0)
+	page.memberTable.SetSelectable(true, false)
 
-	grid.SetRows(0, 6, 2)
+	page.memberTable.SetFocusFunc(func() {
+		tvInstructions.SetText(memberInstructions)
+	})
+
+	page.memberTable.SetBlurFunc(func() {
+		tvInstructions.SetText(chatInstructions())
+	})
+
+	grid := page.grid
+
+	grid.SetRows(0, 1, 6, 2)
 	grid.SetColumns(0)
 
 	grid.AddItem(page.textView, 0, 0, 1, 1, 0, 0, false)
-	grid.AddItem(page.textArea, 1, 0, 1, 1, 0, 0, true)
-	grid.AddItem(tvInstructions, 2, 0, 1, 1, 0, 0, false)
+	grid.AddItem(page.tvTyping, 1, 0, 1, 1, 0, 0, false)
+	grid.AddItem(page.textArea, 2, 0, 1, 1, 0, 0, true)
+	grid.AddItem(tvInstructions, 3, 0, 1, 1, 0, 0, false)
 
 	var pageContext context.Context
 	var cancel context.CancelFunc
 
-	applyTheme := func() {
-		theme := appContext.GetTheme()
+	themes := appContext.GetThemeManager()
 
-		if page.currentThemeCode != theme.Code {
-			page.currentThemeCode = theme.Code
-			grid.SetBackgroundColor(theme.BackgroundColor)
-			page.textView.SetBackgroundColor(theme.BackgroundColor)
-			page.textView.SetBorderColor(theme.BorderColor)
-			page.textView.SetTitleColor(theme.TitleColor)
+	themeBackground(themes, grid)
+	themeTextView(themes, page.tvTyping, statusTextColor)
+	themeTextView(themes, tvInstructions, instructionsTextColor)
 
-			page.textArea.SetTextStyle(theme.TextAreaTextStyle)
-			page.textArea.SetBorderColor(theme.BorderColor)
-			page.textArea.SetTitleColor(theme.TitleColor)
-			page.textArea.SetBorderStyle(theme.TextAreaTextStyle)
+	themes.Register(func(thm theme.Theme) {
+		page.textView.SetBackgroundColor(thm.BackgroundColor)
+		page.textView.SetBorderColor(thm.BorderColor)
+		page.textView.SetTitleColor(thm.TitleColor)
 
-			tvInstructions.SetBackgroundColor(theme.BackgroundColor)
-			tvInstructions.SetTextColor(theme.InfoColor)
-		}
-	}
+		page.textArea.SetTextStyle(thm.TextAreaTextStyle)
+		page.textArea.SetBorderColor(thm.BorderColor)
+		page.textArea.SetTitleColor(thm.TitleColor)
+		page.textArea.SetBorderStyle(thm.TextAreaTextStyle)
 
-	applyTheme()
+		page.memberTable.SetBackgroundColor(thm.BackgroundColor)
+		page.memberTable.SetBorderColor(thm.BorderColor)
+		page.memberTable.SetTitleColor(thm.TitleColor)
+		page.memberTable.SetSelectedStyle(thm.DropdownListSelectedStyle)
+	})
 
 	nav.Register(CHAT_PAGE, grid, true, false,
-		func(param interface{}) {
-			applyTheme()
+		func(param PageParameters) {
 			pageContext, cancel = appContext.GenerateUserSessionBoundContextWithCancel()
 			page.onPageLoad(param, app, appContext, nav, pageContext)
 		},
@@ -100,7 +193,7 @@ func (page *ChatPage) Setup(app *tview.Application, appContext *state.Applicatio
 }
 
 // onPageLoad is called when the chat page is navigated to
-func (page *ChatPage) onPageLoad(param interface{},
+func (page *ChatPage) onPageLoad(param PageParameters,
 	app *tview.Application,
 	appContext *state.ApplicationContext,
 	nav *PageNavigator,
@@ -110,39 +203,63 @@ func (page *ChatPage) onPageLoad(param interface{},
 	chatParam, ok := param.(ChatPageParameters)
 
 	if !ok {
-		nav.AlertFatal(app, "home:chat:alert:err", "Application State Error - Could not get chat params.")
+		slog.Warn("Chat page loaded without chat params. Returning to the previous page.")
+		nav.AlertWithDoneFunc(CHAT_PAGE_ALERT_ERR, "Application State Error - Could not get chat params.", func(buttonIndex int, buttonLabel string) {
+			nav.Back()
+		})
 		return
 	}
 
 	accessToken, ok := appContext.GetAccessToken()
 
 	if !ok {
-		log.Printf("Valid user authentication information not found. Redirecting to login page.")
-		nav.NavigateTo(LOGIN_PAGE, nil)
+		slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+		nav.RedirectToLogin()
 		return
 	}
 
-	// Get the channel
-	getChannelResult := page.brochatClient.GetChannel(accessToken, chatParam.channel_id)
+	channelId := chatParam.channel_id
 
-	err := getChannelResult.Err()
+	// Retrying reloads the page with the same parameters
+	reload := func() {
+		nav.Redirect(CHAT_PAGE, chatParam)
+	}
+
+	runAsync(app, nav, pageContext, "Loading conversation...", func(ctx context.Context) chatConversation {
+		conversation := chatConversation{
+			getChannelResult: page.brochatClient.GetChannel(accessToken, channelId),
+		}
 
-	if err != nil {
-		if len(getChannelResult.ErrorDetails) > 0 {
-			nav.Alert("home:chat:alert:err", getChannelResult.ErrorDetails[0])
+		if conversation.getChannelResult.Err() == nil {
+			conversation.getChannelMessagesResult = page.brochatClient.GetChannelMessages(accessToken, channelId,
+				chat.GetChannelMessages_Page(1),
+				chat.GetChannelMessages_PageSize(chatHistoryPageSize))
+		}
+
+		return conversation
+	}, func(conversation chatConversation) {
+		if err := apperr.FromChatResult(conversation.getChannelResult.BroChatClientResult); err != nil {
+			nav.AlertError(CHAT_PAGE_ALERT_ERR, "The conversation could not be loaded.", err, reload)
 			return
 		}
 
-		if getChannelResult.ResponseCode == chat.BROCHAT_RESPONSE_CODE_FORBIDDEN_ERROR {
-			nav.Alert("home:chat:alert:err", FORBIDDEN_OPERATION_ERROR_MESSAGE)
+		if err := apperr.FromChatResult(conversation.getChannelMessagesResult.BroChatClientResult); err != nil {
+			nav.AlertError(CHAT_PAGE_ALERT_ERR, "The messages could not be loaded.", err, reload)
 			return
 		}
 
-		nav.Alert("home:chat:alert:err", err.Error())
-		return
-	}
+		page.openConversation(chatParam, conversation.getChannelResult.Content, conversation.getChannelMessagesResult.Content, app, appContext, nav, pageContext)
+	})
+}
 
-	channel := getChannelResult.Content
+// openConversation shows the channel and its newest messages and starts listening for updates to the channel
+func (page *ChatPage) openConversation(chatParam ChatPageParameters,
+	channel chat.Channel,
+	messages []chat.ChatMessage,
+	app *tview.Application,
+	appContext *state.ApplicationContext,
+	nav *PageNavigator,
+	pageContext context.Context) {
 
 	if channel.Type == chat.CHANNEL_TYPE_DIRECT_MESSAGE {
 		page.textView.SetTitle(fmt.Sprintf(" %s - %s ", channel.Users[0].Username, channel.Users[1].Username))
@@ -151,37 +268,17 @@ func (page *ChatPage) onPageLoad(param interface{},
 	}
 
 	theme := appContext.GetTheme()
+	keys := appContext.GetKeymap()
+
+	page.feedClient.MarkChannelRead(channel.Id)
 
 	// Get the color manifest
-	colorManifest := getColorManifest(channel.Users, theme)
+	colorManifest := getColorManifest(channel.Users, theme, chatColorOverrides(appContext))
 
-	const pageSize = 100
 	entireConversationLoaded := false
 	oldestMessageId := ""
 
-	// Get the channel messages
-	getChannelMessagesResult := page.brochatClient.GetChannelMessages(accessToken, chatParam.channel_id, chat.GetChannelMessages_Page(1), chat.GetChannelMessages_PageSize(pageSize))
-
-	err = getChannelMessagesResult.Err()
-
-	if err != nil {
-		if len(getChannelMessagesResult.ErrorDetails) > 0 {
-			nav.Alert("home:chat:alert:err", getChannelMessagesResult.ErrorDetails[0])
-			return
-		}
-
-		if getChannelMessagesResult.ResponseCode == chat.BROCHAT_RESPONSE_CODE_FORBIDDEN_ERROR {
-			nav.Alert("home:chat:alert:err", FORBIDDEN_OPERATION_ERROR_MESSAGE)
-			return
-		}
-
-		nav.AlertFatal(app, "home:chat:alert:err", err.Error())
-		return
-	}
-
-	messages := getChannelMessagesResult.Content
-
-	if len(messages) < pageSize {
+	if len(messages) < chatHistoryPageSize {
 		entireConversationLoaded = true
 	} else {
 		oldestMessageId = messages[len(messages)-1].Id
@@ -192,143 +289,218 @@ func (page *ChatPage) onPageLoad(param interface{},
 	defer w.Close()
 	w.Clear()
 
+	brochatUser := appContext.GetBrochatUser()
+
+	// Messages sent by the user, oldest first. Used to place the seen marker in direct message channels.
+	ownMessages := make([]chat.ChatMessage, 0)
+	var seenAt time.Time
+
 	for i := len(messages) - 1; i >= 0; i-- {
+		if isHiddenMessage(appContext, channel, messages[i]) {
+			continue
+		}
+
 		// Write the messages to the text view
-		var senderUsername string
-		var msg = messages[i]
+		fmt.Fprintln(w, page.formatChatMessage(messages[i], channel.Users, colorManifest, theme))
 
-		color := colorManifest[msg.SenderUserId]
+		if messages[i].SenderUserId == brochatUser.Id {
+			ownMessages = append(ownMessages, messages[i])
+		}
+	}
 
-		for _, u := range channel.Users {
-			if u.Id == msg.SenderUserId {
-				senderUsername = u.Username
-				break
-			}
+	page.textView.ScrollToEnd()
+
+	page.populateMemberTable(channel.Users, brochatUser, colorManifest, theme)
+
+	vimNavigation := vimTableNavigation(app, appContext, nav, page.memberTable, 1, nil)
+
+	page.memberTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if vimNavigation(event) {
+			return nil
 		}
 
-		// If for some reason the user info is not found just make the username "Unknown User"
-		if senderUsername == "" {
-			senderUsername = "Unknown User"
+		if keys.Is(event, keymap.ACTION_CLOSE_MEMBERS_PANEL) {
+			page.toggleMemberPanel(app)
+			return nil
 		}
 
-		// If the color is not found then just make it red
-		if color == "" {
-			color = "#FF0000"
+		row, _ := page.memberTable.GetSelection()
+		member, ok := page.members[row]
+
+		if !ok || member.isSelf {
+			return event
 		}
 
-		var dateString string
+		if keys.Is(event, keymap.ACTION_MESSAGE_MEMBER) {
+			if !member.isFriend() {
+				nav.Alert(CHAT_PAGE_ALERT_INFO, fmt.Sprintf("You must be friends with %s to send them a direct message.", member.user.Username))
+				return nil
+			}
+
+			nav.NavigateTo(CHAT_PAGE, ChatPageParameters{
+				channel_id: member.relationship.DirectMessageChannelId,
+			})
 
-		// If the message is from a date in the past (not today) then format the date string differently
-		if msg.RecievedAtUtc.Local().Day() == time.Now().Day() {
-			dateString = msg.RecievedAtUtc.Local().Format(time.Kitchen)
-		} else {
-			dateString = msg.RecievedAtUtc.Local().Format("Jan 2, 2006 3:04 PM")
+			return nil
 		}
 
-		msgString := fmt.Sprintf("[%s]%s [%s][%s]: %s", color, senderUsername, dateString, theme.ChatTextColor.CSS(), msg.Content)
-		fmt.Fprintln(w, msgString)
-	}
+		action, ok := keys.Match(event, keymap.ACTION_WHO_IS, keymap.ACTION_FRIEND_REQUEST)
 
-	page.textView.ScrollToEnd()
+		if !ok {
+			return event
+		}
+
+		switch action {
+		case keymap.ACTION_WHO_IS:
+			cmdContext := &slashCommandContext{
+				app:        app,
+				appContext: appContext,
+				nav:        nav,
+				page:       page,
+				channel:    channel,
+				params:     chatParam,
+			}
+
+			page.slashCommands.Execute(cmdContext, "/whois "+quoteSlashCommandArg(member.user.Username))
+			return nil
+		case keymap.ACTION_FRIEND_REQUEST:
+			if member.relationship != nil {
+				nav.Alert(CHAT_PAGE_ALERT_INFO, fmt.Sprintf("You already have a relationship with %s.", member.user.Username))
+				return nil
+			}
+
+			var sendFriendRequest func()
+
+			sendFriendRequest = func() {
+				accessToken, ok := appContext.GetAccessToken()
+
+				if !ok {
+					slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+					nav.RedirectToLogin()
+					return
+				}
+
+				runAsync(app, nav, pageContext, "Sending friend request...", func(ctx context.Context) chat.BroChatClientResult {
+					return page.brochatClient.SendFriendRequest(accessToken, chat.SendFriendRequestRequest{
+						RequestedUserId: member.user.Id,
+					})
+				}, func(sendFriendRequestResult chat.BroChatClientResult) {
+					if err := apperr.FromChatResult(sendFriendRequestResult); err != nil {
+						nav.AlertError(CHAT_PAGE_ALERT_ERR, "The friend request could not be sent.", err, sendFriendRequest)
+						return
+					}
+
+					nav.Toast(fmt.Sprintf("Friend Request Sent to %s", member.user.Username))
+				})
+			}
+
+			nav.Confirm(CHAT_PAGE_CONFIRM, fmt.Sprintf("Send Friend Request to %s?", member.user.Username), sendFriendRequest)
+
+			return nil
+		}
+
+		return event
+	})
 
 	// Tell the server that this is the active channel
 	page.feedClient.SendFeedMessage(chat.FEED_MESSAGE_TYPE_SET_ACTIVE_CHANNEL_REQUEST, &chat.SetActiveChannelRequest{
 		ChannelId: channel.Id,
 	})
 
+	// Let the other members of the channel know when the user is composing a message
+	page.textArea.SetChangedFunc(func() {
+		text := page.textArea.GetText()
+
+		// Slash commands are not chat messages so they do not count as typing
+		if len(text) == 0 || text[0] == '/' {
+			return
+		}
+
+		if time.Since(page.lastTypingSent) < typingNotificationInterval {
+			return
+		}
+
+		page.lastTypingSent = time.Now()
+
+		page.feedClient.SendFeedMessage(state.FEED_MESSAGE_TYPE_TYPING_REQUEST, &state.TypingRequest{
+			ChannelId: channel.Id,
+		})
+	})
+
 	page.textArea.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyPgUp {
+		if keys.Is(event, keymap.ACTION_SCROLL_UP) {
 			// scroll up 10 lines
 			r, _ := page.textView.GetScrollOffset()
 
 			if r == 0 {
 				if !entireConversationLoaded {
-					getChannelMessagesResult := page.brochatClient.GetChannelMessages(accessToken, chatParam.channel_id,
-						chat.GetChannelMessages_Page(1),
-						chat.GetChannelMessages_PageSize(pageSize),
-						chat.GetChannelMessages_BeforeMessage(oldestMessageId))
-
-					err = getChannelMessagesResult.Err()
-
-					if err != nil {
-						if len(getChannelMessagesResult.ErrorDetails) > 0 {
-							nav.Alert("home:chat:alert:err", getChannelMessagesResult.ErrorDetails[0])
-							return nil
-						}
-
-						if getChannelMessagesResult.ResponseCode == chat.BROCHAT_RESPONSE_CODE_FORBIDDEN_ERROR {
-							nav.Alert("home:chat:alert:err", FORBIDDEN_OPERATION_ERROR_MESSAGE)
-							return nil
-						}
+					accessToken, ok := appContext.GetAccessToken()
 
-						nav.AlertFatal(app, "home:chat:alert:err", err.Error())
+					if !ok {
+						slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+						nav.RedirectToLogin()
 						return nil
 					}
 
-					messages := getChannelMessagesResult.Content
+					beforeMessageId := oldestMessageId
+
+					runAsync(app, nav, pageContext, "Loading older messages...", func(ctx context.Context) chat.BroChatClientContentResult[[]chat.ChatMessage] {
+						return page.brochatClient.GetChannelMessages(accessToken, chatParam.channel_id,
+							chat.GetChannelMessages_Page(1),
+							chat.GetChannelMessages_PageSize(chatHistoryPageSize),
+							chat.GetChannelMessages_BeforeMessage(beforeMessageId))
+					}, func(getChannelMessagesResult chat.BroChatClientContentResult[[]chat.ChatMessage]) {
+						// Older messages are requested again the next time the history is scrolled to the top
+						if err := apperr.FromChatResult(getChannelMessagesResult.BroChatClientResult); err != nil {
+							nav.AlertError(CHAT_PAGE_ALERT_ERR, "Older messages could not be loaded.", err, nil)
+							return
+						}
 
-					page.mu.Lock()
-					defer page.mu.Unlock()
+						messages := getChannelMessagesResult.Content
 
-					if len(messages) < pageSize {
-						entireConversationLoaded = true
-					} else {
-						oldestMessageId = messages[len(messages)-1].Id
-					}
+						page.mu.Lock()
+						defer page.mu.Unlock()
 
-					oldText := []byte(page.textView.GetText(false))
+						if len(messages) < chatHistoryPageSize {
+							entireConversationLoaded = true
+						} else {
+							oldestMessageId = messages[len(messages)-1].Id
+						}
 
-					// Prepend the messages to the text view
-					writer := page.textView.BatchWriter()
-					defer writer.Close()
+						oldText := []byte(page.textView.GetText(false))
 
-					writer.Clear()
+						// Prepend the messages to the text view
+						writer := page.textView.BatchWriter()
+						defer writer.Close()
 
-					for i := len(messages) - 1; i >= 0; i-- {
-						// Write the messages to the text view
-						var senderUsername string
-						var msg = messages[i]
+						writer.Clear()
 
-						color := colorManifest[msg.SenderUserId]
+						olderOwnMessages := make([]chat.ChatMessage, 0)
 
-						for _, u := range channel.Users {
-							if u.Id == msg.SenderUserId {
-								senderUsername = u.Username
-								break
+						for i := len(messages) - 1; i >= 0; i-- {
+							if isHiddenMessage(appContext, channel, messages[i]) {
+								continue
 							}
-						}
 
-						// If for some reason the user info is not found just make the username "Unknown User"
-						if senderUsername == "" {
-							senderUsername = "Unknown User"
-						}
+							// Write the messages to the text view
+							fmt.Fprintln(writer, page.formatChatMessage(messages[i], channel.Users, colorManifest, theme))
 
-						// If the color is not found then just make it red
-						if color == "" {
-							color = "#FF0000"
+							if messages[i].SenderUserId == brochatUser.Id {
+								olderOwnMessages = append(olderOwnMessages, messages[i])
+							}
 						}
 
-						var dateString string
+						ownMessages = append(olderOwnMessages, ownMessages...)
+
+						writer.Write(oldText)
 
-						// If the message is from a date in the past (not today) then format the date string differently
-						if msg.RecievedAtUtc.Local().Day() == time.Now().Day() {
-							dateString = msg.RecievedAtUtc.Local().Format(time.Kitchen)
+						// Scroll to the top if there are less than 10 messages otherwise scroll up the normal 10 lines
+						if len(messages) > 10 {
+							page.textView.ScrollTo(len(messages)-10, 0)
 						} else {
-							dateString = msg.RecievedAtUtc.Local().Format("Jan 2, 2006 3:04 PM")
+							page.textView.ScrollToBeginning()
 						}
-
-						msgString := fmt.Sprintf("[%s]%s [%s][%s]: %s", color, senderUsername, dateString, theme.ChatTextColor.CSS(), msg.Content)
-						fmt.Fprintln(writer, msgString)
-					}
-
-					writer.Write(oldText)
-
-					// Scroll to the top if there are less than 10 messages otherwise scroll up the normal 10 lines
-					if len(messages) > 10 {
-						page.textView.ScrollTo(len(messages)-10, 0)
-					} else {
-						page.textView.ScrollToBeginning()
-					}
+					})
 				}
 
 				return nil
@@ -336,14 +508,62 @@ func (page *ChatPage) onPageLoad(param interface{},
 
 			page.textView.ScrollTo(r-10, 0)
 			return nil
-		} else if event.Key() == tcell.KeyPgDn {
+		} else if keys.Is(event, keymap.ACTION_SCROLL_DOWN) {
 			r, _ := page.textView.GetScrollOffset()
 			page.textView.ScrollTo(r+10, 0)
 			return nil
-		} else if event.Key() == tcell.KeyEnter {
+		} else if keys.Is(event, keymap.ACTION_COMPLETE_COMMAND) {
+			text := page.textArea.GetText()
+
+			// Only slash commands are completed, otherwise let the text area insert the tab
+			if len(text) == 0 || text[0] != '/' {
+				return event
+			}
+
+			cmdContext := &slashCommandContext{
+				app:        app,
+				appContext: appContext,
+				nav:        nav,
+				page:       page,
+				channel:    channel,
+				params:     chatParam,
+			}
+
+			completions := page.slashCommands.Complete(cmdContext, text)
+
+			if len(completions) == 1 {
+				page.textArea.SetText(completions[0], true)
+			} else if len(completions) > 1 {
+				page.textArea.SetText(longestCommonPrefix(completions), true)
+				page.writeSystemMessage(theme, strings.Join(completions, "  "))
+			}
+
+			return nil
+		} else if keys.Is(event, keymap.ACTION_SEND_MESSAGE) {
 			text := page.textArea.GetText()
 
 			if len(text) > 0 {
+				cmdContext := &slashCommandContext{
+					app:        app,
+					appContext: appContext,
+					nav:        nav,
+					page:       page,
+					channel:    channel,
+					params:     chatParam,
+				}
+
+				handled, cmdErr := page.slashCommands.Execute(cmdContext, text)
+
+				if handled {
+					if cmdErr != nil {
+						// Keep the text so that the command can be corrected
+						page.writeSystemMessage(appContext.GetTheme(), cmdErr.Error())
+					} else {
+						page.textArea.SetText("", false)
+					}
+
+					return nil
+				}
 
 				isMacro, macroType := chat.IsMacro(text)
 
@@ -364,8 +584,46 @@ func (page *ChatPage) onPageLoad(param interface{},
 			}
 
 			return nil
-		} else if event.Key() == tcell.KeyEscape {
-			nav.NavigateTo(chatParam.returnPage, nil)
+		} else if keys.Is(event, keymap.ACTION_TOGGLE_MEMBERS) {
+			page.toggleMemberPanel(app)
+			return nil
+		} else if keys.Is(event, keymap.ACTION_BACK) {
+			// In vim mode the back key leaves the composer for the chat history, as escape leaves insert mode in vim
+			if appContext.IsVimModeEnabled() {
+				app.SetFocus(page.textView)
+				return nil
+			}
+
+			nav.Back()
+		}
+
+		return event
+	})
+
+	historyNavigation := vimTextViewNavigation(appContext, page.textView)
+
+	// The chat history can only be focused in vim mode
+	page.textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if historyNavigation(event) {
+			return nil
+		}
+
+		if keys.Is(event, keymap.ACTION_VIM_INSERT) {
+			app.SetFocus(page.textArea)
+			return nil
+		} else if keys.Is(event, keymap.ACTION_SEARCH) {
+			nav.Prompt(app, CHAT_PAGE_SEARCH_PROMPT, "Search Messages", func(text string) {
+				if strings.TrimSpace(text) != "" && !page.searchHistory(text) {
+					page.writeSystemMessage(appContext.GetTheme(), fmt.Sprintf("No messages contain '%s'", text))
+				}
+			})
+			return nil
+		} else if keys.Is(event, keymap.ACTION_TOGGLE_MEMBERS) {
+			page.toggleMemberPanel(app)
+			return nil
+		} else if keys.Is(event, keymap.ACTION_BACK) {
+			nav.Back()
+			return nil
 		}
 
 		return event
@@ -383,13 +641,10 @@ func (page *ChatPage) onPageLoad(param interface{},
 				return
 			case eventChannelId := <-channelUpdateChannel:
 				if eventChannelId == channel.Id {
-					page.mu.Lock()
-					defer page.mu.Unlock()
-
 					accessToken, ok := appContext.GetAccessToken()
 
 					if !ok {
-						log.Println("No valid authentication information available for channel update event processing")
+						slog.Warn("No valid authentication information available for channel update event processing")
 						appContext.CancelUserSession()
 						return
 					}
@@ -399,12 +654,14 @@ func (page *ChatPage) onPageLoad(param interface{},
 					err := getChannelResult.Err()
 
 					if err != nil {
-						log.Printf("Error getting channel during channel update event processing: %s", err.Error())
-						return
+						slog.Error("Error getting channel during channel update event processing", "err", err)
+						continue
 					}
 
 					newChannel := getChannelResult.Content
 
+					page.mu.Lock()
+
 					usersForManifest := newChannel.Users
 
 					for _, u := range newChannel.Users {
@@ -414,9 +671,18 @@ func (page *ChatPage) onPageLoad(param interface{},
 						}
 					}
 
-					colorManifest = getColorManifest(usersForManifest, theme)
+					colorManifest = getColorManifest(usersForManifest, theme, chatColorOverrides(appContext))
 
 					channel = newChannel
+
+					page.mu.Unlock()
+
+					app.QueueUpdateDraw(func() {
+						page.mu.Lock()
+						defer page.mu.Unlock()
+
+						page.populateMemberTable(channel.Users, appContext.GetBrochatUser(), colorManifest, theme)
+					})
 				}
 			}
 		}
@@ -432,76 +698,393 @@ func (page *ChatPage) onPageLoad(param interface{},
 			case <-pageContext.Done():
 				return
 			case msg := <-chatMsgChannel:
-				if msg.ChannelId == ch.Id {
+				if msg.ChannelId == ch.Id && !isHiddenMessage(appContext, *ch, msg) {
 					a.QueueUpdateDraw(func() {
 						page.mu.Lock()
 						defer page.mu.Unlock()
 
-						var senderUsername string
-						color := colorManifest[msg.SenderUserId]
-
-						for _, u := range ch.Users {
-							if u.Id == msg.SenderUserId {
-								senderUsername = u.Username
-								break
-							}
+						if page.typing.Remove(msg.SenderUserId) {
+							page.tvTyping.SetText(page.typing.Text())
 						}
 
-						// If for some reason the user info is not found just make the username "Unknown User"
-						if senderUsername == "" {
-							senderUsername = "Unknown User"
-						}
+						msgString := page.formatChatMessage(msg, ch.Users, colorManifest, theme)
+						tv.Write([]byte(msgString + "\n"))
 
-						// If the color is not found then just make it red
-						if color == "" {
-							color = "#FF0000"
+						if ch.Type == chat.CHANNEL_TYPE_DIRECT_MESSAGE {
+							if msg.SenderUserId == brochatUser.Id {
+								ownMessages = append(ownMessages, msg)
+							} else if msg.RecievedAtUtc.After(seenAt) {
+								// A reply means the other participant has read everything before it
+								seenAt = msg.RecievedAtUtc
+								page.renderSeenMarker(ownMessages, seenAt, theme)
+							}
 						}
 
-						dateString := msg.RecievedAtUtc.Local().Format(time.Kitchen)
-
-						msgString := fmt.Sprintf("[%s]%s [%s][%s]: %s", color, senderUsername, dateString, theme.ChatTextColor.CSS(), msg.Content)
-						tv.Write([]byte(msgString + "\n"))
 						tv.ScrollToEnd()
 					})
 				}
 			}
 		}
 	}(&channel, app, page.textView)
+
+	// Start the listener for relationship updates so that member presence stays current
+	go func() {
+		subscriptionId, userProfileUpdatesChannel := page.feedClient.SubscribeToUserProfileUpdates()
+		defer page.feedClient.UnsubscribeFromUserProfileUpdates(subscriptionId)
+
+		for {
+			select {
+			case <-pageContext.Done():
+				return
+			case updateCode := <-userProfileUpdatesChannel:
+				if updateCode == chat.USER_PROFILE_UPDATE_REASON_RELATIONSHIP_UPDATE {
+					app.QueueUpdateDraw(func() {
+						page.mu.Lock()
+						defer page.mu.Unlock()
+
+						page.populateMemberTable(channel.Users, appContext.GetBrochatUser(), colorManifest, theme)
+					})
+				}
+			}
+		}
+	}()
+
+	// Start the typing event listener
+	go func() {
+		subscriptionId, typingEventChannel := page.feedClient.SubscribeToTypingEvents()
+		defer page.feedClient.UnsubscribeFromTypingEvents(subscriptionId)
+
+		// Periodically check for expired typing indicators
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-pageContext.Done():
+				return
+			case evt, ok := <-typingEventChannel:
+				if !ok {
+					return
+				}
+
+				if evt.ChannelId != channel.Id || evt.UserId == brochatUser.Id {
+					continue
+				}
+
+				if channel.Type != chat.CHANNEL_TYPE_DIRECT_MESSAGE && appContext.IsBlocked(evt.UserId) {
+					continue
+				}
+
+				page.mu.Lock()
+
+				username := ""
+
+				for _, u := range channel.Users {
+					if u.Id == evt.UserId {
+						username = u.Username
+						break
+					}
+				}
+
+				page.mu.Unlock()
+
+				if username == "" {
+					continue
+				}
+
+				page.typing.Touch(evt.UserId, username, time.Now())
+
+				app.QueueUpdateDraw(func() {
+					page.tvTyping.SetText(page.typing.Text())
+				})
+			case now := <-ticker.C:
+				if page.typing.Prune(now) {
+					app.QueueUpdateDraw(func() {
+						page.tvTyping.SetText(page.typing.Text())
+					})
+				}
+			}
+		}
+	}()
+
+	// Read receipts are only shown for direct message channels
+	if channel.Type != chat.CHANNEL_TYPE_DIRECT_MESSAGE {
+		return
+	}
+
+	// Start the channel read event listener
+	go func() {
+		subscriptionId, channelReadChannel := page.feedClient.SubscribeToChannelReadEvents()
+		defer page.feedClient.UnsubscribeFromChannelReadEvents(subscriptionId)
+
+		for {
+			select {
+			case <-pageContext.Done():
+				return
+			case evt, ok := <-channelReadChannel:
+				if !ok {
+					return
+				}
+
+				if evt.ChannelId != channel.Id || evt.UserId == brochatUser.Id {
+					continue
+				}
+
+				app.QueueUpdateDraw(func() {
+					page.mu.Lock()
+					defer page.mu.Unlock()
+
+					if !evt.ReadAtUtc.After(seenAt) {
+						return
+					}
+
+					seenAt = evt.ReadAtUtc
+					page.renderSeenMarker(ownMessages, seenAt, theme)
+				})
+			}
+		}
+	}()
 }
 
 // onPageClose is called when the chat page is navigated away from
 func (page *ChatPage) onPageClose() {
 	page.textView.Clear()
+	page.textView.Highlight()
 	page.textArea.SetText("", false)
+	page.typing.Reset()
+	page.tvTyping.SetText("")
+	page.hideMemberPanel()
+	page.memberTable.Clear()
+	page.members = make(map[int]channelMember, 0)
+	page.lastTypingSent = time.Time{}
 
 	page.feedClient.SendFeedMessage(chat.FEED_MESSAGE_TYPE_SET_ACTIVE_CHANNEL_REQUEST, &chat.SetActiveChannelRequest{
 		ChannelId: "NONE",
 	})
 }
 
+// seenMarkerRegion is the region tag which prefixes the seen marker line in the chat view.
+const seenMarkerRegion = `["seen"]`
+
+// messageRegion returns the region tag used to identify a chat message in the chat view.
+func messageRegion(messageId string) string {
+	return fmt.Sprintf(`["msg:%s"]`, messageId)
+}
+
+// messageRegionPattern matches the region tags of the chat messages in the chat view and captures the region id
+var messageRegionPattern = regexp.MustCompile(`\["(msg:[^"]+)"\]`)
+
+// searchHistory highlights the newest message older than the highlighted message which contains the text, ignoring case.
+// The search wraps around to the newest message. Returns false if no message contains the text.
+func (page *ChatPage) searchHistory(text string) bool {
+	text = strings.ToLower(strings.TrimSpace(text))
+
+	if text == "" {
+		return false
+	}
+
+	regionIds := make([]string, 0)
+
+	for _, match := range messageRegionPattern.FindAllStringSubmatch(page.textView.GetText(false), -1) {
+		regionIds = append(regionIds, match[1])
+	}
+
+	count := len(regionIds)
+
+	if count == 0 {
+		return false
+	}
+
+	// Start from the highlighted message, or after the newest message if nothing is highlighted
+	start := count
+
+	if highlights := page.textView.GetHighlights(); len(highlights) > 0 {
+		for i, regionId := range regionIds {
+			if regionId == highlights[0] {
+				start = i
+				break
+			}
+		}
+	}
+
+	for i := 1; i <= count; i++ {
+		regionId := regionIds[((start-i)%count+count)%count]
+
+		if strings.Contains(strings.ToLower(page.textView.GetRegionText(regionId)), text) {
+			page.textView.Highlight(regionId).ScrollToHighlight()
+			return true
+		}
+	}
+
+	return false
+}
+
+// isHiddenMessage returns true if the message should not be shown. Messages from blocked users are hidden in rooms.
+func isHiddenMessage(appContext *state.ApplicationContext, channel chat.Channel, msg chat.ChatMessage) bool {
+	return channel.Type != chat.CHANNEL_TYPE_DIRECT_MESSAGE && appContext.IsBlocked(msg.SenderUserId)
+}
+
+// formatChatMessage formats a chat message for display in the chat view.
+// The message is wrapped in a region so that it can be located later (for example, to place the seen marker).
+// The username and content are escaped so that they cannot inject style or region tags.
+func (page *ChatPage) formatChatMessage(msg chat.ChatMessage, users []chat.UserInfo, colorManifest map[string]string, thm theme.Theme) string {
+	var senderUsername string
+
+	color := colorManifest[msg.SenderUserId]
+
+	for _, u := range users {
+		if u.Id == msg.SenderUserId {
+			senderUsername = u.Username
+			break
+		}
+	}
+
+	// If for some reason the user info is not found just make the username "Unknown User"
+	if senderUsername == "" {
+		senderUsername = "Unknown User"
+	}
+
+	// If the color is not found then just make it red
+	if color == "" {
+		color = "#FF0000"
+	}
+
+	var dateString string
+
+	// If the message is from a date in the past (not today) then format the date string differently
+	if msg.RecievedAtUtc.Local().Day() == time.Now().Day() {
+		dateString = msg.RecievedAtUtc.Local().Format(time.Kitchen)
+	} else {
+		dateString = msg.RecievedAtUtc.Local().Format("Jan 2, 2006 3:04 PM")
+	}
+
+	return fmt.Sprintf(`%s[%s]%s [%s][%s]: %s[""]`, messageRegion(msg.Id), color, tview.Escape(senderUsername), dateString, thm.ChatTextColor.CSS(), tview.Escape(msg.Content))
+}
+
+// renderSeenMarker moves the seen marker so that it follows the last of the user's own messages recieved at or before seenAt.
+// ownMessages must be ordered from oldest to newest.
+func (page *ChatPage) renderSeenMarker(ownMessages []chat.ChatMessage, seenAt time.Time, thm theme.Theme) {
+	lastSeenRegion := ""
+
+	for i := len(ownMessages) - 1; i >= 0; i-- {
+		if !ownMessages[i].RecievedAtUtc.After(seenAt) {
+			lastSeenRegion = messageRegion(ownMessages[i].Id)
+			break
+		}
+	}
+
+	marker := fmt.Sprintf(`%s[%s]  ✓ Seen %s[-][""]`, seenMarkerRegion, thm.InfoColorTwo.CSS(), seenAt.Local().Format(time.Kitchen))
+
+	lines := strings.Split(page.textView.GetText(false), "\n")
+	updated := make([]string, 0, len(lines)+1)
+	inLastSeenMessage := false
+
+	for _, line := range lines {
+		// Drop the previous marker
+		if strings.HasPrefix(line, seenMarkerRegion) {
+			continue
+		}
+
+		updated = append(updated, line)
+
+		if lastSeenRegion != "" && strings.HasPrefix(line, lastSeenRegion) {
+			inLastSeenMessage = true
+		}
+
+		// Messages may span multiple lines so wait for the end of the region before placing the marker
+		if inLastSeenMessage && strings.HasSuffix(line, `[""]`) {
+			updated = append(updated, marker)
+			inLastSeenMessage = false
+		}
+	}
+
+	page.textView.SetText(strings.Join(updated, "\n"))
+}
+
+// writeSystemMessage writes a client side informational message to the chat view.
+// System messages are only visible locally and are never sent to the server.
+func (page *ChatPage) writeSystemMessage(thm theme.Theme, message string) {
+	msgString := fmt.Sprintf("[%s]* %s[-]", thm.InfoColorTwo.CSS(), tview.Escape(message))
+	page.textView.Write([]byte(msgString + "\n"))
+	page.textView.ScrollToEnd()
+}
+
+// longestCommonPrefix returns the longest prefix shared by all of the provided strings.
+// The strings are compared by rune so that the prefix never ends in the middle of a multibyte character.
+func longestCommonPrefix(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	prefix := []rune(values[0])
+
+	for _, value := range values[1:] {
+		i := 0
+
+		for _, r := range value {
+			if i == len(prefix) || prefix[i] != r {
+				break
+			}
+
+			i++
+		}
+
+		prefix = prefix[:i]
+	}
+
+	return string(prefix)
+}
+
 // ChatPageParameters is load time parameters for the chat page
 type ChatPageParameters struct {
 	channel_id string
 	title      string
-	returnPage PageSlug
+}
+
+// Page returns the page the parameters are for
+func (ChatPageParameters) Page() PageSlug {
+	return CHAT_PAGE
+}
+
+// chatColorOverrides returns the chat label colors which users have chosen for themselves, keyed by user id
+func chatColorOverrides(appContext *state.ApplicationContext) map[string]string {
+	overrides := make(map[string]string)
+
+	if color := appContext.GetChatColor(); color != "" {
+		overrides[appContext.GetBrochatUser().Id] = color
+	}
+
+	return overrides
 }
 
 // getColorManifest takes in a slice of users and assigns each users and a color.
 // The color manifest is a map of user ids to hex colors.
-// The colors are assigned based upon the users index (position) in the slice.
-func getColorManifest(users []chat.UserInfo, thm theme.Theme) map[string]string {
+// Each user's color is chosen by hashing their id onto the theme's palette so that they keep the same color in every channel,
+// whoever else is in it. Two users in a channel may share a color.
+// Users with an entry in overrides are assigned their chosen color instead.
+func getColorManifest(users []chat.UserInfo, thm theme.Theme, overrides map[string]string) map[string]string {
 
 	var possibleColors = thm.ChatLabelColors
 
 	colorManifest := make(map[string]string)
 
-	for i, user := range users {
-		if i >= len(possibleColors) {
-			i = i % len(possibleColors)
+	for _, user := range users {
+		if color, ok := overrides[user.Id]; ok {
+			colorManifest[user.Id] = color
+			continue
 		}
 
-		colorManifest[user.Id] = possibleColors[i]
+		if len(possibleColors) > 0 {
+			colorManifest[user.Id] = possibleColors[userColorIndex(user.Id, len(possibleColors))]
+		}
 	}
 
 	return colorManifest
 }
+
+// userColorIndex hashes a user id onto a palette of the given size
+func userColorIndex(userId string, paletteSize int) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(userId))
+	return int(hash.Sum32() % uint32(paletteSize))
+}
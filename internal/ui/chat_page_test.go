@@ -0,0 +1,26 @@
+package ui
+
+import "testing"
+
+func TestLongestCommonPrefix(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+	}{
+		{name: "no values", values: nil, want: ""},
+		{name: "single value", values: []string{"/join"}, want: "/join"},
+		{name: "shared prefix", values: []string{"/join general", "/join games"}, want: "/join g"},
+		{name: "no shared prefix", values: []string{"/join", "/msg"}, want: "/"},
+		{name: "multibyte runes sharing a leading byte", values: []string{"/join café", "/join cafè"}, want: "/join caf"},
+		{name: "multibyte runes", values: []string{"/msg 日本語", "/msg 日本人"}, want: "/msg 日本"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := longestCommonPrefix(test.values); got != test.want {
+				t.Fatalf("longestCommonPrefix(%q) = %q, want %q", test.values, got, test.want)
+			}
+		})
+	}
+}
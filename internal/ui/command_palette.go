@@ -0,0 +1,339 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/keymap"
+	"github.com/dmars8047/broterm/internal/state"
+	"github.com/dmars8047/broterm/internal/theme"
+	"github.com/dmars8047/idamlib/idam"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const COMMAND_PALETTE = "command_palette"
+
+const COMMAND_PALETTE_CONFIRM = "command_palette:confirm"
+
+// The kinds of entries in the command palette
+const (
+	PALETTE_ENTRY_ACTION = "Action"
+	PALETTE_ENTRY_ROOM   = "Room"
+	PALETTE_ENTRY_DM     = "Direct Message"
+	PALETTE_ENTRY_FRIEND = "Friend"
+	PALETTE_ENTRY_THEME  = "Theme"
+)
+
+// paletteEntry is something the user can jump to or do from the command palette
+type paletteEntry struct {
+	kind  string
+	label string
+	run   func()
+}
+
+// CommandPalette is an overlay which can be opened on any page while the user is logged in.
+// It fuzzy searches the user's rooms, friends, direct messages, the themes and the common actions and runs the selected entry.
+type CommandPalette struct {
+	userAuthClient *idam.UserAuthClient
+	grid           *tview.Grid
+	searchInput    *tview.InputField
+	table          *tview.Table
+	entries        []paletteEntry
+	matches        []paletteEntry
+	previousFocus  tview.Primitive
+}
+
+// NewCommandPalette creates a new command palette
+func NewCommandPalette(userAuthClient *idam.UserAuthClient) *CommandPalette {
+	return &CommandPalette{
+		userAuthClient: userAuthClient,
+		grid:           tview.NewGrid(),
+		searchInput:    tview.NewInputField(),
+		table:          tview.NewTable(),
+		entries:        make([]paletteEntry, 0),
+		matches:        make([]paletteEntry, 0),
+	}
+}
+
+// Setup configures the command palette and installs the key which opens it on every page
+func (palette *CommandPalette) Setup(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	keys := appContext.GetKeymap()
+
+	palette.searchInput.SetBorder(true)
+	palette.searchInput.SetTitle(" Jump To ")
+	palette.searchInput.SetPlaceholder("Rooms, friends, themes and actions")
+
+	palette.searchInput.SetChangedFunc(func(text string) {
+		palette.filter(text, appContext.GetTheme())
+	})
+
+	palette.searchInput.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyDown, tcell.KeyTab:
+			palette.moveSelection(1)
+			return nil
+		case tcell.KeyUp, tcell.KeyBacktab:
+			palette.moveSelection(-1)
+			return nil
+		case tcell.KeyEnter:
+			row, _ := palette.table.GetSelection()
+
+			if row >= 0 && row < len(palette.matches) {
+				entry := palette.matches[row]
+				palette.close(app, nav)
+				entry.run()
+			}
+
+			return nil
+		case tcell.KeyEscape:
+			palette.close(app, nav)
+			return nil
+		}
+
+		return event
+	})
+
+	palette.table.SetBorder(true)
+	palette.table.SetSelectable(true, false)
+
+	palette.grid.SetRows(0, 3, 14, 0)
+	palette.grid.SetColumns(0, 60, 0)
+	palette.grid.AddItem(palette.searchInput, 1, 1, 1, 1, 0, 0, true)
+	palette.grid.AddItem(palette.table, 2, 1, 1, 1, 0, 0, false)
+
+	themes := appContext.GetThemeManager()
+
+	themeSearchInput(themes, palette.searchInput)
+	themeTable(themes, palette.table)
+
+	themes.Register(func(thm theme.Theme) {
+		palette.filter(palette.searchInput.GetText(), thm)
+	})
+
+	// The palette can be opened from any page once the user has logged in, unless a request is still running
+	previousCapture := app.GetInputCapture()
+
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if keys.Is(event, keymap.ACTION_COMMAND_PALETTE) {
+			if _, ok := appContext.GetAccessToken(); ok && !palette.isOpen(nav) && !nav.IsLoading() {
+				palette.open(app, appContext, nav)
+				return nil
+			}
+		}
+
+		if previousCapture != nil {
+			return previousCapture(event)
+		}
+
+		return event
+	})
+}
+
+// open builds the palette entries from the user's current rooms and relationships and shows the palette above the current page
+func (palette *CommandPalette) open(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	palette.previousFocus = app.GetFocus()
+	palette.entries = palette.buildEntries(app, appContext, nav)
+
+	palette.searchInput.SetText("")
+	palette.filter("", appContext.GetTheme())
+
+	nav.Pages.AddPage(COMMAND_PALETTE, palette.grid, true, true)
+	app.SetFocus(palette.searchInput)
+}
+
+// close hides the palette and returns focus to the primitive which had focus when the palette was opened
+func (palette *CommandPalette) close(app *tview.Application, nav *PageNavigator) {
+	nav.Pages.HidePage(COMMAND_PALETTE).RemovePage(COMMAND_PALETTE)
+
+	if palette.previousFocus != nil {
+		app.SetFocus(palette.previousFocus)
+	}
+
+	palette.previousFocus = nil
+}
+
+// isOpen returns true if the palette is being shown above the current page.
+// The palette is hidden without being closed when the page changes, for example when the user session expires.
+func (palette *CommandPalette) isOpen(nav *PageNavigator) bool {
+	frontPage, _ := nav.Pages.GetFrontPage()
+	return frontPage == COMMAND_PALETTE
+}
+
+// buildEntries returns the entries which can be searched in the palette
+func (palette *CommandPalette) buildEntries(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) []paletteEntry {
+	navigateTo := func(slug PageSlug, param PageParameters) func() {
+		return func() {
+			nav.NavigateTo(slug, param)
+		}
+	}
+
+	entries := []paletteEntry{
+		{kind: PALETTE_ENTRY_ACTION, label: "Home", run: navigateTo(HOME_PAGE, nil)},
+		{kind: PALETTE_ENTRY_ACTION, label: "Rooms", run: navigateTo(ROOM_LIST_PAGE, nil)},
+		{kind: PALETTE_ENTRY_ACTION, label: "Create Room", run: navigateTo(ROOM_EDITOR_PAGE, nil)},
+		{kind: PALETTE_ENTRY_ACTION, label: "Find Room", run: navigateTo(ROOM_FINDER_PAGE, nil)},
+		{kind: PALETTE_ENTRY_ACTION, label: "Friends", run: navigateTo(FRIENDS_LIST_PAGE, nil)},
+		{kind: PALETTE_ENTRY_ACTION, label: "Find Friend", run: navigateTo(FRIENDS_FINDER_PAGE, nil)},
+		{kind: PALETTE_ENTRY_ACTION, label: "Pending Friend Requests", run: navigateTo(ACCEPT_FRIEND_REQUEST_PAGE, nil)},
+		{kind: PALETTE_ENTRY_ACTION, label: "Blocked Users", run: navigateTo(BLOCKED_USERS_PAGE, nil)},
+		{kind: PALETTE_ENTRY_ACTION, label: "Profile", run: navigateTo(PROFILE_PAGE, nil)},
+		{kind: PALETTE_ENTRY_ACTION, label: "Settings", run: navigateTo(APP_SETTINGS_PAGE, nil)},
+		{kind: PALETTE_ENTRY_ACTION, label: "Logout", run: func() {
+			nav.Confirm(COMMAND_PALETTE_CONFIRM, "Are you sure you want to logout?", func() {
+				logout(app, appContext, nav, palette.userAuthClient)
+			})
+		}},
+	}
+
+	brochatUser := appContext.GetBrochatUser()
+
+	for _, room := range brochatUser.Rooms {
+		entries = append(entries, paletteEntry{
+			kind:  PALETTE_ENTRY_ROOM,
+			label: room.Name,
+			run: navigateTo(CHAT_PAGE, ChatPageParameters{
+				channel_id: room.ChannelId,
+				title:      room.Name,
+			}),
+		})
+	}
+
+	for _, rel := range brochatUser.Relationships {
+		if rel.Type != chat.RELATIONSHIP_TYPE_FRIEND {
+			continue
+		}
+
+		entries = append(entries,
+			paletteEntry{
+				kind:  PALETTE_ENTRY_DM,
+				label: rel.Username,
+				run: navigateTo(CHAT_PAGE, ChatPageParameters{
+					channel_id: rel.DirectMessageChannelId,
+				}),
+			},
+			paletteEntry{
+				kind:  PALETTE_ENTRY_FRIEND,
+				label: rel.Username,
+				run:   navigateTo(FRIENDS_LIST_PAGE, FriendsListPageParameters{selectedUserId: rel.UserId}),
+			})
+	}
+
+	for _, code := range theme.Codes() {
+		code := code
+
+		entries = append(entries, paletteEntry{
+			kind:  PALETTE_ENTRY_THEME,
+			label: code,
+			run: func() {
+				appContext.SetTheme(code)
+			},
+		})
+	}
+
+	return entries
+}
+
+// filter shows the entries which fuzzy match the text, best matches first. Every entry is shown if the text is empty.
+func (palette *CommandPalette) filter(text string, thm theme.Theme) {
+	query := strings.TrimSpace(text)
+
+	type scoredEntry struct {
+		entry paletteEntry
+		score int
+	}
+
+	scored := make([]scoredEntry, 0, len(palette.entries))
+
+	for _, entry := range palette.entries {
+		// Entries can be found by kind as well as by label, for example "theme matrix"
+		score, ok := fuzzyScore(query, entry.label)
+
+		if kindScore, kindOk := fuzzyScore(query, entry.kind+" "+entry.label); kindOk && (!ok || kindScore > score) {
+			score, ok = kindScore, true
+		}
+
+		if ok {
+			scored = append(scored, scoredEntry{entry: entry, score: score})
+		}
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+
+	palette.matches = make([]paletteEntry, 0, len(scored))
+	palette.table.Clear()
+
+	for row, match := range scored {
+		palette.matches = append(palette.matches, match.entry)
+		palette.table.SetCell(row, 0, tview.NewTableCell(match.entry.label).SetTextColor(thm.ForgroundColor).SetExpansion(1))
+		palette.table.SetCell(row, 1, tview.NewTableCell(match.entry.kind).SetTextColor(thm.InfoColor).SetAlign(tview.AlignRight))
+	}
+
+	palette.table.Select(0, 0)
+	palette.table.ScrollToBeginning()
+}
+
+// moveSelection moves the selected match up or down, wrapping at either end
+func (palette *CommandPalette) moveSelection(delta int) {
+	count := len(palette.matches)
+
+	if count == 0 {
+		return
+	}
+
+	row, _ := palette.table.GetSelection()
+	palette.table.Select(((row+delta)%count+count)%count, 0)
+}
+
+// fuzzyScore returns true if every character of the query appears in the text in order, ignoring case.
+// Higher scores are better matches. Consecutive characters, characters at the start of a word and matches
+// near the start of the text score higher. An empty query matches everything with a score of zero.
+func fuzzyScore(query, text string) (int, bool) {
+	queryRunes := []rune(strings.ToLower(strings.ReplaceAll(query, " ", "")))
+	textRunes := []rune(strings.ToLower(text))
+
+	if len(queryRunes) == 0 {
+		return 0, true
+	}
+
+	score := 0
+	queryIndex := 0
+	previousMatch := -1
+
+	for i, r := range textRunes {
+		if queryIndex == len(queryRunes) {
+			break
+		}
+
+		if r != queryRunes[queryIndex] {
+			continue
+		}
+
+		score++
+
+		if previousMatch >= 0 && previousMatch == i-1 {
+			score += 5
+		}
+
+		if i == 0 || !unicode.IsLetter(textRunes[i-1]) && !unicode.IsDigit(textRunes[i-1]) {
+			score += 3
+		}
+
+		if previousMatch < 0 {
+			score -= min(i, 10)
+		}
+
+		previousMatch = i
+		queryIndex++
+	}
+
+	if queryIndex < len(queryRunes) {
+		return 0, false
+	}
+
+	return score, true
+}
@@ -1,29 +1,5 @@
 package ui
 
-// const (
-// 	BroChatYellowColorCode     = 0xFFC300
-// 	AccentColorTwoColorCode    = 0x222222
-// 	AccentColorFourColorCode   = 0x444444
-// 	DefaultBackgroundColorCode = 0x111111
-// 	DangerBackgroundColorCode  = 0xFF0E0E
-// 	DANGER_TEXT_COLOR          = 0xFF0000
-// )
-
-// var (
-// 	DangerBackgroundColor    = tcell.NewHexColor(DangerBackgroundColorCode)
-// 	BROCHAT_YELLOW_COLOR     = tcell.NewHexColor(BroChatYellowColorCode)
-// 	DEFAULT_BACKGROUND_COLOR = tcell.NewHexColor(DefaultBackgroundColorCode)
-// 	ACCENT_BACKGROUND_COLOR  = tcell.NewHexColor(AccentColorFourColorCode)
-// 	ACCENT_COLOR_TWO_COLOR   = tcell.NewHexColor(AccentColorTwoColorCode)
-// 	DEFAULT_BUTTON_STYLE     = tcell.StyleDefault.Background(tcell.NewHexColor(AccentColorTwoColorCode)).Foreground(tcell.ColorWhite)
-// 	ACTIVATED_BUTTON_STYLE   = tcell.StyleDefault.Background(tcell.NewHexColor(0xFFC300)).Foreground(tcell.ColorBlack)
-// 	TEXT_AREA_STYLE          = tcell.StyleDefault.Reverse(true).Background(tcell.NewHexColor(0x222222)).Foreground(tcell.ColorWhite)
-// )
-
-// var (
-// 	BroChatStyle = tcell.StyleDefault.Background(DEFAULT_BACKGROUND_COLOR).Foreground(tcell.ColorWhite)
-// )
-
 const (
 	FORBIDDEN_OPERATION_ERROR_MESSAGE = "Warning! A forbidden operation was attempted."
 )
@@ -1,11 +1,16 @@
 package ui
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
 
 	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/apperr"
+	"github.com/dmars8047/broterm/internal/keymap"
 	"github.com/dmars8047/broterm/internal/state"
+	"github.com/dmars8047/broterm/internal/theme"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -18,12 +23,37 @@ const (
 	FIND_A_FRIEND_PAGE_CONFIRM    = "home:findafriend:confirm"
 )
 
+// findAFriendPageSize is the number of users requested per page
+const findAFriendPageSize = 10
+
+// findAFriendInstructions returns the instructions for the find a friend page using the keys bound in the keymap
+func findAFriendInstructions(keys *keymap.Keymap) string {
+	return strings.Join([]string{
+		"(enter) Send Friend Request",
+		keys.Hint(keymap.ACTION_SEARCH, "Search"),
+		keys.Hint(keymap.ACTION_NEXT_PAGE, "Next Page"),
+		keys.Hint(keymap.ACTION_PREVIOUS_PAGE, "Previous Page"),
+		keys.Hint(keymap.ACTION_LOAD_MORE_USERS, "Load More"),
+		keys.Hint(keymap.ACTION_BACK, "Quit"),
+	}, " - ")
+}
+
 // FindAFriendPage is the find a friend page
 type FindAFriendPage struct {
 	brochatClient *chat.BroChatClient
 	table         *tview.Table
-	users         map[uint8]chat.UserInfo
-	themeCode     string
+	searchInput   *tview.InputField
+	tvStatus      *tview.TextView
+	users         map[int]chat.UserInfo
+	// loaded is the users currently listed in the table, in the order they were returned
+	loaded []chat.UserInfo
+	// firstPage is the first page of results listed in the table
+	firstPage uint64
+	// lastPage is the last page of results listed in the table. Greater than firstPage when more users have been loaded.
+	lastPage uint64
+	// hasMore is true if the last page requested was full, meaning more users may be available
+	hasMore bool
+	filter  string
 }
 
 // NewFindAFriendPage creates a new find a friend page
@@ -31,22 +61,44 @@ func NewFindAFriendPage(brochatClient *chat.BroChatClient) *FindAFriendPage {
 	return &FindAFriendPage{
 		brochatClient: brochatClient,
 		table:         tview.NewTable(),
-		users:         make(map[uint8]chat.UserInfo, 0),
-		themeCode:     "NOT_SET",
+		searchInput:   tview.NewInputField(),
+		tvStatus:      tview.NewTextView(),
+		users:         make(map[int]chat.UserInfo, 0),
+		loaded:        make([]chat.UserInfo, 0),
+		firstPage:     1,
+		lastPage:      1,
 	}
 }
 
 // Setup sets up the find a friend page and registers it with the page navigator
 func (page *FindAFriendPage) Setup(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	keys := appContext.GetKeymap()
+
 	tvHeader := tview.NewTextView().SetTextAlign(tview.AlignCenter)
 	tvHeader.SetText("Find Friends")
 
+	page.searchInput.SetLabel("Username: ")
+	page.searchInput.SetPlaceholder("press enter to search")
+
+	page.searchInput.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEscape:
+			nav.Back()
+		case tcell.KeyEnter:
+			page.filter = strings.TrimSpace(page.searchInput.GetText())
+			app.SetFocus(page.table)
+			page.loadPage(app, appContext, nav, 1, false)
+		case tcell.KeyTab, tcell.KeyBacktab:
+			app.SetFocus(page.table)
+		}
+	})
+
 	page.table.SetBorders(true)
 	page.table.SetFixed(1, 1)
 	page.table.SetSelectable(true, false)
 
 	page.table.SetSelectedFunc(func(row int, _ int) {
-		selectedUser, ok := page.users[uint8(row)]
+		selectedUser, ok := page.users[row]
 
 		if !ok {
 			return
@@ -55,44 +107,65 @@ func (page *FindAFriendPage) Setup(app *tview.Application, appContext *state.App
 		accessToken, ok := appContext.GetAccessToken()
 
 		if !ok {
-			log.Printf("Valid user authentication information not found. Redirecting to login page.")
-			nav.NavigateTo(LOGIN_PAGE, nil)
+			slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+			nav.RedirectToLogin()
 			return
 		}
 
-		nav.Confirm(FIND_A_FRIEND_PAGE_CONFIRM, fmt.Sprintf("Send Friend Request to %s?", selectedUser.Username), func() {
-			sendFriendRequestResult := page.brochatClient.SendFriendRequest(accessToken, chat.SendFriendRequestRequest{
-				RequestedUserId: selectedUser.Id,
-			})
-
-			err := sendFriendRequestResult.Err()
+		var sendFriendRequest func()
 
-			if err != nil {
-				if len(sendFriendRequestResult.ErrorDetails) > 0 {
-					nav.Alert(FIND_A_FRIEND_PAGE_ALERT_INFO, sendFriendRequestResult.ErrorDetails[0])
+		sendFriendRequest = func() {
+			runSessionAsync(app, appContext, nav, "Sending friend request...", func(ctx context.Context) chat.BroChatClientResult {
+				return page.brochatClient.SendFriendRequest(accessToken, chat.SendFriendRequestRequest{
+					RequestedUserId: selectedUser.Id,
+				})
+			}, func(sendFriendRequestResult chat.BroChatClientResult) {
+				if err := apperr.FromChatResult(sendFriendRequestResult); err != nil {
+					nav.AlertError(FIND_A_FRIEND_PAGE_ALERT_ERR, "The friend request could not be sent.", err, sendFriendRequest)
 					return
 				}
 
-				if sendFriendRequestResult.ResponseCode == chat.BROCHAT_RESPONSE_CODE_FORBIDDEN_ERROR {
-					nav.Alert(FIND_A_FRIEND_PAGE_ALERT_ERR, FORBIDDEN_OPERATION_ERROR_MESSAGE)
-					return
-				}
+				page.removeUser(selectedUser.Id, appContext.GetTheme())
+				nav.Toast(fmt.Sprintf("Friend Request Sent to %s", selectedUser.Username))
+			})
+		}
 
-				nav.AlertFatal(app, FIND_A_FRIEND_PAGE_ALERT_ERR, err.Error())
-				return
-			}
+		nav.Confirm(FIND_A_FRIEND_PAGE_CONFIRM, fmt.Sprintf("Send Friend Request to %s?", selectedUser.Username), sendFriendRequest)
+	})
 
-			page.table.RemoveRow(row)
-			nav.Alert(FIND_A_FRIEND_PAGE_ALERT_INFO, fmt.Sprintf("Friend Request Sent to %s", selectedUser.Username))
-		})
+	vimNavigation := vimTableNavigation(app, appContext, nav, page.table, 2, func() {
+		app.SetFocus(page.searchInput)
 	})
 
 	page.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyEscape {
-			nav.NavigateTo(FRIENDS_LIST_PAGE, nil)
-			page.users = make(map[uint8]chat.UserInfo, 0)
-			page.table.Clear()
-		} else if event.Key() == tcell.KeyTab {
+		if vimNavigation(event) {
+			return nil
+		}
+
+		if keys.Is(event, keymap.ACTION_BACK) {
+			nav.Back()
+		} else if action, ok := keys.Match(event, keymap.ACTION_SEARCH, keymap.ACTION_NEXT_PAGE, keymap.ACTION_PREVIOUS_PAGE, keymap.ACTION_LOAD_MORE_USERS); ok {
+			switch action {
+			case keymap.ACTION_SEARCH:
+				app.SetFocus(page.searchInput)
+				return nil
+			case keymap.ACTION_NEXT_PAGE:
+				if page.hasMore {
+					page.loadPage(app, appContext, nav, page.lastPage+1, false)
+				}
+				return nil
+			case keymap.ACTION_PREVIOUS_PAGE:
+				if page.firstPage > 1 {
+					page.loadPage(app, appContext, nav, page.firstPage-1, false)
+				}
+				return nil
+			case keymap.ACTION_LOAD_MORE_USERS:
+				if page.hasMore {
+					page.loadPage(app, appContext, nav, page.lastPage+1, true)
+				}
+				return nil
+			}
+		} else if keys.Is(event, keymap.ACTION_NEXT) {
 			// Change the selected row to the next row
 			row, _ := page.table.GetSelection()
 			if row+1 >= page.table.GetRowCount() {
@@ -102,7 +175,7 @@ func (page *FindAFriendPage) Setup(app *tview.Application, appContext *state.App
 			}
 
 			page.table.Select(row, 0)
-		} else if event.Key() == tcell.KeyBacktab {
+		} else if keys.Is(event, keymap.ACTION_PREVIOUS) {
 			// Change the selected row to the previous row
 			row, _ := page.table.GetSelection()
 
@@ -119,40 +192,32 @@ func (page *FindAFriendPage) Setup(app *tview.Application, appContext *state.App
 	})
 
 	tvInstructions := tview.NewTextView().SetTextAlign(tview.AlignCenter)
-	tvInstructions.SetText("(esc) Quit")
+	tvInstructions.SetText(findAFriendInstructions(keys))
+
+	page.tvStatus.SetTextAlign(tview.AlignCenter)
 
 	grid := tview.NewGrid()
 
-	grid.SetRows(2, 1, 1, 0, 1, 1, 2)
+	grid.SetRows(2, 1, 1, 1, 1, 0, 1, 1, 2, 2)
 	grid.SetColumns(0, 76, 0)
 
 	grid.AddItem(tvHeader, 1, 1, 1, 1, 0, 0, false)
-	grid.AddItem(page.table, 3, 1, 1, 1, 0, 0, true)
-	grid.AddItem(tvInstructions, 5, 1, 1, 1, 0, 0, false)
-
-	applyTheme := func() {
-		theme := appContext.GetTheme()
-
-		if page.themeCode != theme.Code {
-			page.themeCode = theme.Code
-			grid.SetBackgroundColor(theme.BackgroundColor)
-			tvHeader.SetBackgroundColor(theme.BackgroundColor)
-			tvHeader.SetTextColor(theme.TitleColor)
-			page.table.SetBordersColor(theme.BorderColor)
-			page.table.SetBorderColor(theme.BorderColor)
-			page.table.SetTitleColor(theme.TitleColor)
-			page.table.SetBackgroundColor(theme.BackgroundColor)
-			page.table.SetSelectedStyle(theme.DropdownListSelectedStyle)
-			tvInstructions.SetBackgroundColor(theme.BackgroundColor)
-			tvInstructions.SetTextColor(theme.InfoColor)
-		}
-	}
+	grid.AddItem(page.searchInput, 3, 1, 1, 1, 0, 0, false)
+	grid.AddItem(page.table, 5, 1, 1, 1, 0, 0, true)
+	grid.AddItem(page.tvStatus, 6, 1, 1, 1, 0, 0, false)
+	grid.AddItem(tvInstructions, 8, 1, 1, 1, 0, 0, false)
 
-	applyTheme()
+	themes := appContext.GetThemeManager()
+
+	themeBackground(themes, grid)
+	themeTextView(themes, tvHeader, headerTextColor)
+	themeTable(themes, page.table)
+	themeSearchInput(themes, page.searchInput)
+	themeTextView(themes, page.tvStatus, statusTextColor)
+	themeTextView(themes, tvInstructions, instructionsTextColor)
 
 	nav.Register(FRIENDS_FINDER_PAGE, grid, true, false,
-		func(_ interface{}) {
-			applyTheme()
+		func(_ PageParameters) {
 			page.onPageLoad(app, appContext, nav)
 		},
 		func() {
@@ -162,15 +227,94 @@ func (page *FindAFriendPage) Setup(app *tview.Application, appContext *state.App
 
 // onPageLoad is called when the find a friend page is navigated to
 func (page *FindAFriendPage) onPageLoad(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	page.loadPage(app, appContext, nav, 1, false)
+}
+
+// onPageClose is called when the find a friend page is navigated away from
+func (page *FindAFriendPage) onPageClose() {
+	page.users = make(map[int]chat.UserInfo, 0)
+	page.loaded = make([]chat.UserInfo, 0)
+	page.firstPage = 1
+	page.lastPage = 1
+	page.hasMore = false
+	page.filter = ""
+	page.searchInput.SetText("")
+	page.table.Clear()
+}
+
+// loadPage retrieves a page of users matching the username filter.
+// If appending is true the users are added to those already listed, otherwise they replace them.
+func (page *FindAFriendPage) loadPage(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator, pageNumber uint64, appending bool) {
 	accessToken, ok := appContext.GetAccessToken()
 
 	if !ok {
-		log.Printf("Valid user authentication information not found. Redirecting to login page.")
-		nav.NavigateTo(LOGIN_PAGE, nil)
+		slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+		nav.RedirectToLogin()
 		return
 	}
 
-	thm := appContext.GetTheme()
+	options := []chat.GetUsersOption{
+		chat.GetUsersOption_ExcludeSelf(),
+		chat.GetUsersOption_ExcludeFriends(),
+		chat.GetUsersOption_Page(pageNumber),
+		chat.GetUsersOption_PageSize(findAFriendPageSize),
+	}
+
+	if page.filter != "" {
+		options = append(options, chat.GetUsersOption_UsernameFilter(page.filter))
+	}
+
+	runSessionAsync(app, appContext, nav, "Loading users...", func(ctx context.Context) chat.BroChatClientContentResult[[]chat.UserInfo] {
+		return page.brochatClient.GetUsers(accessToken, options...)
+	}, func(getUsersResult chat.BroChatClientContentResult[[]chat.UserInfo]) {
+		if err := apperr.FromChatResult(getUsersResult.BroChatClientResult); err != nil {
+			nav.AlertError(FIND_A_FRIEND_PAGE_ALERT_ERR, "The users could not be loaded.", err, func() {
+				page.loadPage(app, appContext, nav, pageNumber, appending)
+			})
+			return
+		}
+
+		usrs := getUsersResult.Content
+
+		if appending {
+			page.loaded = append(page.loaded, usrs...)
+		} else {
+			page.loaded = usrs
+			page.firstPage = pageNumber
+		}
+
+		page.lastPage = pageNumber
+		page.hasMore = len(usrs) == findAFriendPageSize
+
+		selectedRow := 1
+
+		if appending {
+			// Keep the selection on the first of the newly loaded users
+			selectedRow = len(page.loaded) - len(usrs) + 1
+		}
+
+		page.populateTable(appContext.GetTheme(), selectedRow)
+	})
+}
+
+// removeUser removes a user from the listed users. Used once a friend request has been sent to them.
+func (page *FindAFriendPage) removeUser(userId string, thm theme.Theme) {
+	selectedRow, _ := page.table.GetSelection()
+
+	for i, usr := range page.loaded {
+		if usr.Id == userId {
+			page.loaded = append(page.loaded[:i], page.loaded[i+1:]...)
+			break
+		}
+	}
+
+	page.populateTable(thm, selectedRow)
+}
+
+// populateTable lists the loaded users in the table
+func (page *FindAFriendPage) populateTable(thm theme.Theme, selectedRow int) {
+	page.table.Clear()
+	page.users = make(map[int]chat.UserInfo, 0)
 
 	page.table.SetCell(0, 0, tview.NewTableCell("Username").
 		SetTextColor(thm.ForgroundColor).
@@ -185,43 +329,39 @@ func (page *FindAFriendPage) onPageLoad(app *tview.Application, appContext *stat
 		SetSelectable(false).
 		SetAttributes(tcell.AttrBold|tcell.AttrUnderline))
 
-	getUsersResult := page.brochatClient.GetUsers(accessToken, chat.GetUsersOption_ExcludeSelf(),
-		chat.GetUsersOption_ExcludeFriends(),
-		chat.GetUsersOption_Page(1),
-		chat.GetUsersOption_PageSize(10))
-
-	err := getUsersResult.Err()
+	for i, usr := range page.loaded {
+		row := i + 1
 
-	if err != nil {
-		if len(getUsersResult.ErrorDetails) > 0 {
-			nav.Alert(FIND_A_FRIEND_PAGE_ALERT_INFO, getUsersResult.ErrorDetails[0])
-			return
-		}
+		page.table.SetCell(row, 0, tview.NewTableCell(usr.Username).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignCenter))
+		var dateString string = usr.LastOnlineUtc.Local().Format("Jan 2, 2006")
+		page.table.SetCell(row, 1, tview.NewTableCell(dateString).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignRight))
 
-		if getUsersResult.ResponseCode == chat.BROCHAT_RESPONSE_CODE_FORBIDDEN_ERROR {
-			nav.Alert(FIND_A_FRIEND_PAGE_ALERT_INFO, FORBIDDEN_OPERATION_ERROR_MESSAGE)
-			return
-		}
+		page.users[row] = usr
+	}
 
-		nav.AlertFatal(app, FIND_A_FRIEND_PAGE_ALERT_ERR, err.Error())
-		return
+	if selectedRow < 1 || selectedRow >= page.table.GetRowCount() {
+		selectedRow = 1
 	}
 
-	usrs := getUsersResult.Content
+	page.table.Select(selectedRow, 0)
 
-	for i, usr := range usrs {
-		row := i + 1
+	var pages string
 
-		page.table.SetCell(row, 0, tview.NewTableCell(usr.Username).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignCenter))
-		var dateString string = usr.LastOnlineUtc.Local().Format("Jan 2, 2006")
-		page.table.SetCell(row, 1, tview.NewTableCell(dateString).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignRight))
+	if page.firstPage == page.lastPage {
+		pages = fmt.Sprintf("Page %d", page.firstPage)
+	} else {
+		pages = fmt.Sprintf("Pages %d-%d", page.firstPage, page.lastPage)
+	}
 
-		page.users[uint8(row)] = usr
+	status := fmt.Sprintf("%s - %d users", pages, len(page.loaded))
+
+	if page.filter != "" {
+		status += fmt.Sprintf(" matching '%s'", page.filter)
 	}
-}
 
-// onPageClose is called when the find a friend page is navigated away from
-func (page *FindAFriendPage) onPageClose() {
-	page.users = make(map[uint8]chat.UserInfo, 0)
-	page.table.Clear()
+	if page.hasMore {
+		status += " - more available"
+	}
+
+	page.tvStatus.SetText(status)
 }
@@ -1,6 +1,9 @@
 package ui
 
 import (
+	"context"
+	"log/slog"
+
 	"github.com/dmars8047/broterm/internal/state"
 	"github.com/dmars8047/idamlib/idam"
 	"github.com/dmars8047/strval"
@@ -19,17 +22,15 @@ const (
 
 // ForgotPasswordPage is the forgot password page
 type ForgotPasswordPage struct {
-	userAuthClient   *idam.UserAuthClient
-	forgotPWForm     *tview.Form
-	currentThemeCode string
+	userAuthClient *idam.UserAuthClient
+	forgotPWForm   *tview.Form
 }
 
 // NewForgotPasswordPage creates a new instance of the forgot password page
 func NewForgotPasswordPage(userAuthClient *idam.UserAuthClient) *ForgotPasswordPage {
 	return &ForgotPasswordPage{
-		userAuthClient:   userAuthClient,
-		forgotPWForm:     tview.NewForm(),
-		currentThemeCode: "NOT_SET",
+		userAuthClient: userAuthClient,
+		forgotPWForm:   tview.NewForm(),
 	}
 }
 
@@ -42,11 +43,14 @@ func (page *ForgotPasswordPage) Setup(app *tview.Application, appContext *state.
 	page.forgotPWForm.SetBorder(true).SetTitle(FORGOT_PW_TITLE).SetTitleAlign(tview.AlignCenter)
 	page.forgotPWForm.AddInputField("Email", "", 0, nil, nil)
 
-	page.forgotPWForm.AddButton("Submit", func() {
+	var submit func()
+
+	submit = func() {
 		emailInput, ok := page.forgotPWForm.GetFormItemByLabel("Email").(*tview.InputField)
 
 		if !ok {
-			panic("email input form clear failure")
+			nav.Alert(FORGOT_PW_MODAL_ERR, "Password Reset Failed - Email Field Unavailable")
+			return
 		}
 
 		email := emailInput.GetText()
@@ -66,38 +70,37 @@ func (page *ForgotPasswordPage) Setup(app *tview.Application, appContext *state.
 			Email: email,
 		}
 
-		err := page.userAuthClient.InitiatePasswordReset("brochat", request)
-
-		if err != nil {
-			errMessage := err.Error()
-
-			idamErr, ok := err.(*idam.ErrorResponse)
-
-			if ok {
-				switch idamErr.Code {
-				case idam.RequestValidationFailure:
-					errMessage = "Request Validation Error"
-					nav.AlertErrors(FORGOT_PW_MODAL_ERR, errMessage, idamErr.Details)
-					return
-				case idam.UserNotFound:
-					errMessage = "User Not Found"
-				case idam.UnhandledError:
-					errMessage = "An Unexpected Error Occurred"
+		runAsync(app, nav, appContext.Context, "Requesting a password reset...", func(ctx context.Context) error {
+			return page.userAuthClient.InitiatePasswordReset("brochat", request)
+		}, func(err error) {
+			if err != nil {
+				idamErr, ok := err.(*idam.ErrorResponse)
+
+				if ok {
+					switch idamErr.Code {
+					case idam.RequestValidationFailure:
+						nav.AlertErrors(FORGOT_PW_MODAL_ERR, "Request Validation Error", idamErr.Details)
+						return
+					case idam.UserNotFound:
+						nav.Alert(FORGOT_PW_MODAL_ERR, "User Not Found")
+						return
+					}
 				}
-			}
 
-			nav.Alert(FORGOT_PW_MODAL_ERR, errMessage)
-			return
-		}
+				nav.AlertError(FORGOT_PW_MODAL_ERR, "Password Reset Failed", err, submit)
+				return
+			}
 
-		nav.AlertWithDoneFunc(FORGOT_PW_MODAL_INFO, FORGOT_PW_SUCCESS_MESSAGE, func(buttonIndex int, buttonLabel string) {
-			nav.Pages.HidePage(FORGOT_PW_MODAL_INFO).RemovePage(FORGOT_PW_MODAL_INFO)
-			nav.NavigateTo(LOGIN_PAGE, nil)
+			nav.AlertWithDoneFunc(FORGOT_PW_MODAL_INFO, FORGOT_PW_SUCCESS_MESSAGE, func(buttonIndex int, buttonLabel string) {
+				nav.Back()
+			})
 		})
-	})
+	}
+
+	page.forgotPWForm.AddButton("Submit", submit)
 
 	page.forgotPWForm.AddButton("Back", func() {
-		nav.NavigateTo(LOGIN_PAGE, nil)
+		nav.Back()
 	})
 
 	tvInstructions := tview.NewTextView().SetTextAlign(tview.AlignCenter)
@@ -106,28 +109,14 @@ func (page *ForgotPasswordPage) Setup(app *tview.Application, appContext *state.
 	grid.AddItem(page.forgotPWForm, 1, 1, 1, 1, 0, 0, true)
 	grid.AddItem(tvInstructions, 3, 1, 1, 1, 0, 0, false)
 
-	applyTheme := func() {
-		theme := appContext.GetTheme()
-
-		if page.currentThemeCode != theme.Code {
-			grid.SetBackgroundColor(theme.BackgroundColor)
-			page.forgotPWForm.SetBackgroundColor(theme.AccentColor)
-			page.forgotPWForm.SetFieldBackgroundColor(theme.AccentColorTwo)
-			page.forgotPWForm.SetLabelColor(theme.HighlightColor)
-			page.forgotPWForm.SetButtonStyle(theme.ButtonStyle)
-			page.forgotPWForm.SetButtonActivatedStyle(theme.ActivatedButtonStyle)
-			page.forgotPWForm.SetBorderColor(theme.BorderColor)
-			page.forgotPWForm.SetTitleColor(theme.TitleColor)
-			tvInstructions.SetBackgroundColor(theme.BackgroundColor)
-			tvInstructions.SetTextColor(theme.InfoColor)
-		}
-	}
+	themes := appContext.GetThemeManager()
 
-	applyTheme()
+	themeBackground(themes, grid)
+	themeForm(themes, page.forgotPWForm)
+	themeTextView(themes, tvInstructions, instructionsTextColor)
 
 	nav.Register(FORGOT_PW_PAGE, grid, true, false,
-		func(param interface{}) {
-			applyTheme()
+		func(param PageParameters) {
 			page.onPageLoad()
 		},
 		func() {
@@ -142,11 +131,9 @@ func (page *ForgotPasswordPage) onPageLoad() {
 
 // onPageClose is called when the forgot password page is navigated away from
 func (page *ForgotPasswordPage) onPageClose() {
-	emailInput, ok := page.forgotPWForm.GetFormItemByLabel("Email").(*tview.InputField)
-
-	if !ok {
-		panic("email input form clear failure")
+	if emailInput, ok := page.forgotPWForm.GetFormItemByLabel("Email").(*tview.InputField); ok {
+		emailInput.SetText("")
+	} else {
+		slog.Error("Email input form clear failure on close for forgot password page")
 	}
-
-	emailInput.SetText("")
 }
@@ -3,8 +3,15 @@ package ui
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/apperr"
+	"github.com/dmars8047/broterm/internal/chatext"
+	"github.com/dmars8047/broterm/internal/keymap"
 	"github.com/dmars8047/broterm/internal/state"
 	"github.com/dmars8047/broterm/internal/theme"
 	"github.com/gdamore/tcell/v2"
@@ -14,31 +21,53 @@ import (
 const FRIENDS_LIST_PAGE PageSlug = "friends_list"
 
 const (
-	FRIENDS_LIST_PAGE_ALERT_INFO = "home:friendlist:alert:info"
-	FRIENDS_LIST_PAGE_ALERT_ERR  = "home:friendlist:alert:err"
+	FRIENDS_LIST_PAGE_ALERT_ERR = "home:friendlist:alert:err"
+	FRIENDS_LIST_PAGE_CONFIRM   = "home:friendlist:confirm"
 )
 
+// friendsListRefreshInterval is how often the relative last seen times are refreshed
+const friendsListRefreshInterval = 30 * time.Second
+
+// friendsListInstructions returns the instructions for the friends list page using the keys bound in the keymap
+func friendsListInstructions(keys *keymap.Keymap, pendingCount int) string {
+	return strings.Join([]string{
+		keys.Hint(keymap.ACTION_FIND_FRIEND, "Find a new Bro"),
+		keys.Hint(keymap.ACTION_VIEW_PENDING, fmt.Sprintf("View Pending [%d]", pendingCount)),
+		keys.Hint(keymap.ACTION_VIEW_BLOCKED, "Blocked"),
+		keys.Hint(keymap.ACTION_BACK, "Quit"),
+	}, " - ") + "\n" + strings.Join([]string{
+		keys.Hint(keymap.ACTION_UNFRIEND, "Unfriend"),
+		keys.Hint(keymap.ACTION_BLOCK_USER, "Block"),
+	}, " - ")
+}
+
 type FriendsListPage struct {
-	brochatClient    *chat.BroChatClient
-	feedClient       *state.FeedClient
-	table            *tview.Table
-	tvInstructions   *tview.TextView
-	userFriends      map[uint8]chat.UserRelationship
-	currentThemeCode string
+	brochatClient  *chat.BroChatClient
+	chatextClient  *chatext.Client
+	feedClient     *state.FeedClient
+	table          *tview.Table
+	tvInstructions *tview.TextView
+	keys           *keymap.Keymap
+	userFriends    map[int]chat.UserRelationship
+	friends        []chat.UserRelationship
 }
 
-func NewFriendsListPage(brochatClient *chat.BroChatClient, feedClient *state.FeedClient) *FriendsListPage {
+func NewFriendsListPage(brochatClient *chat.BroChatClient, chatextClient *chatext.Client, feedClient *state.FeedClient) *FriendsListPage {
 	return &FriendsListPage{
-		brochatClient:    brochatClient,
-		feedClient:       feedClient,
-		table:            tview.NewTable(),
-		tvInstructions:   tview.NewTextView(),
-		userFriends:      make(map[uint8]chat.UserRelationship, 0),
-		currentThemeCode: "NOT_SET",
+		brochatClient:  brochatClient,
+		chatextClient:  chatextClient,
+		feedClient:     feedClient,
+		table:          tview.NewTable(),
+		tvInstructions: tview.NewTextView(),
+		userFriends:    make(map[int]chat.UserRelationship, 0),
+		friends:        make([]chat.UserRelationship, 0),
 	}
 }
 
 func (page *FriendsListPage) Setup(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	keys := appContext.GetKeymap()
+	page.keys = keys
+
 	tvHeader := tview.NewTextView().SetTextAlign(tview.AlignCenter)
 	tvHeader.SetText("Friends List")
 
@@ -47,7 +76,7 @@ func (page *FriendsListPage) Setup(app *tview.Application, appContext *state.App
 	page.table.SetSelectable(true, false)
 
 	page.table.SetSelectedFunc(func(row int, _ int) {
-		rel, ok := page.userFriends[uint8(row)]
+		rel, ok := page.userFriends[row]
 
 		if !ok {
 			return
@@ -55,27 +84,72 @@ func (page *FriendsListPage) Setup(app *tview.Application, appContext *state.App
 
 		nav.NavigateTo(CHAT_PAGE, ChatPageParameters{
 			channel_id: rel.DirectMessageChannelId,
-			returnPage: FRIENDS_LIST_PAGE,
 		})
 	})
 
+	vimNavigation := vimTableNavigation(app, appContext, nav, page.table, 2, nil)
+
 	page.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyRune {
-			switch event.Rune() {
-			case 'p':
+		if vimNavigation(event) {
+			return nil
+		}
+
+		if action, ok := keys.Match(event, keymap.ACTION_VIEW_PENDING, keymap.ACTION_FIND_FRIEND, keymap.ACTION_VIEW_BLOCKED, keymap.ACTION_UNFRIEND, keymap.ACTION_BLOCK_USER); ok {
+			switch action {
+			case keymap.ACTION_VIEW_PENDING:
 				nav.NavigateTo(ACCEPT_FRIEND_REQUEST_PAGE, nil)
-				page.userFriends = make(map[uint8]chat.UserRelationship, 0)
+				page.userFriends = make(map[int]chat.UserRelationship, 0)
 				page.table.Clear()
-			case 'f':
+			case keymap.ACTION_FIND_FRIEND:
 				nav.NavigateTo(FRIENDS_FINDER_PAGE, nil)
-				page.userFriends = make(map[uint8]chat.UserRelationship, 0)
+				page.userFriends = make(map[int]chat.UserRelationship, 0)
+				page.table.Clear()
+			case keymap.ACTION_VIEW_BLOCKED:
+				nav.NavigateTo(BLOCKED_USERS_PAGE, nil)
+				page.userFriends = make(map[int]chat.UserRelationship, 0)
 				page.table.Clear()
+			case keymap.ACTION_UNFRIEND:
+				row, _ := page.table.GetSelection()
+				rel, ok := page.userFriends[row]
+
+				if !ok {
+					return event
+				}
+
+				nav.Confirm(FRIENDS_LIST_PAGE_CONFIRM, fmt.Sprintf("Remove %s from your friends?", rel.Username), func() {
+					page.updateRelationship(app, appContext, nav, fmt.Sprintf("%s is no longer your friend.", rel.Username), func(ctx context.Context, accessToken string) chat.BroChatClientResult {
+						return page.chatextClient.RemoveFriend(ctx, accessToken, rel.UserId)
+					}, nil)
+				})
+
+				return nil
+			case keymap.ACTION_BLOCK_USER:
+				row, _ := page.table.GetSelection()
+				rel, ok := page.userFriends[row]
+
+				if !ok {
+					return event
+				}
+
+				nav.Confirm(FRIENDS_LIST_PAGE_CONFIRM, fmt.Sprintf("Block %s? They will be removed from your friends and their messages will be hidden.", rel.Username), func() {
+					page.updateRelationship(app, appContext, nav, fmt.Sprintf("%s has been blocked.", rel.Username), func(ctx context.Context, accessToken string) chat.BroChatClientResult {
+						return page.chatextClient.BlockUser(ctx, accessToken, rel.UserId)
+					}, func() {
+						appContext.AddBlockedUser(chat.UserInfo{
+							Id:            rel.UserId,
+							Username:      rel.Username,
+							LastOnlineUtc: rel.LastOnlineUtc,
+						})
+					})
+				})
+
+				return nil
 			}
-		} else if event.Key() == tcell.KeyEscape {
-			nav.NavigateTo(HOME_PAGE, nil)
-			page.userFriends = make(map[uint8]chat.UserRelationship, 0)
+		} else if keys.Is(event, keymap.ACTION_BACK) {
+			nav.Back()
+			page.userFriends = make(map[int]chat.UserRelationship, 0)
 			page.table.Clear()
-		} else if event.Key() == tcell.KeyTab {
+		} else if keys.Is(event, keymap.ACTION_NEXT) {
 			// Change the selected row to the next row
 			row, _ := page.table.GetSelection()
 			if row+1 >= page.table.GetRowCount() {
@@ -85,7 +159,7 @@ func (page *FriendsListPage) Setup(app *tview.Application, appContext *state.App
 			}
 
 			page.table.Select(row, 0)
-		} else if event.Key() == tcell.KeyBacktab {
+		} else if keys.Is(event, keymap.ACTION_PREVIOUS) {
 			// Change the selected row to the previous row
 			row, _ := page.table.GetSelection()
 
@@ -102,11 +176,11 @@ func (page *FriendsListPage) Setup(app *tview.Application, appContext *state.App
 	})
 
 	page.tvInstructions.SetTextAlign(tview.AlignCenter)
-	page.tvInstructions.SetText("(f) Find a new Bro - (p) View Pending - (esc) Quit")
+	page.tvInstructions.SetText(friendsListInstructions(keys, 0))
 
 	grid := tview.NewGrid()
 
-	grid.SetRows(2, 1, 1, 0, 1, 1, 2)
+	grid.SetRows(2, 1, 1, 0, 1, 2, 2)
 	grid.SetColumns(0, 76, 0)
 
 	grid.AddItem(tvHeader, 1, 1, 1, 1, 0, 0, false)
@@ -116,31 +190,21 @@ func (page *FriendsListPage) Setup(app *tview.Application, appContext *state.App
 	var pageContext context.Context
 	var cancel context.CancelFunc
 
-	applyTheme := func() {
-		theme := appContext.GetTheme()
-
-		if page.currentThemeCode != theme.Code {
-			page.currentThemeCode = theme.Code
-			grid.SetBackgroundColor(theme.BackgroundColor)
-			page.table.SetBordersColor(theme.BorderColor)
-			page.table.SetBorderColor(theme.BorderColor)
-			page.table.SetTitleColor(theme.TitleColor)
-			page.table.SetBackgroundColor(theme.BackgroundColor)
-			page.table.SetSelectedStyle(theme.DropdownListSelectedStyle)
-			tvHeader.SetBackgroundColor(theme.BackgroundColor)
-			tvHeader.SetTextColor(theme.TitleColor)
-			page.tvInstructions.SetBackgroundColor(theme.BackgroundColor)
-			page.tvInstructions.SetTextColor(theme.InfoColor)
-		}
-	}
+	themes := appContext.GetThemeManager()
 
-	applyTheme()
+	themeBackground(themes, grid)
+	themeTextView(themes, tvHeader, headerTextColor)
+	themeTable(themes, page.table)
+	themeTextView(themes, page.tvInstructions, instructionsTextColor)
 
 	nav.Register(FRIENDS_LIST_PAGE, grid, true, false,
-		func(_ interface{}) {
+		func(param PageParameters) {
 			pageContext, cancel = appContext.GenerateUserSessionBoundContextWithCancel()
-			applyTheme()
 			page.onPageLoad(app, appContext, pageContext)
+
+			if params, ok := param.(FriendsListPageParameters); ok {
+				page.selectFriend(params.selectedUserId)
+			}
 		},
 		func() {
 			cancel()
@@ -148,7 +212,18 @@ func (page *FriendsListPage) Setup(app *tview.Application, appContext *state.App
 		})
 }
 
+// selectFriend selects the row of the friend with the given user id if they are in the table
+func (page *FriendsListPage) selectFriend(userId string) {
+	for row, rel := range page.userFriends {
+		if rel.UserId == userId {
+			page.table.Select(row, 0)
+			return
+		}
+	}
+}
+
 func (page *FriendsListPage) onPageLoad(app *tview.Application, appContext *state.ApplicationContext, pageContext context.Context) {
+	page.table.Select(1, 0)
 	page.populateTable(appContext.GetBrochatUser(), appContext.GetTheme())
 
 	// Create a goroutine to listen for updates to the user's relationships
@@ -164,7 +239,6 @@ func (page *FriendsListPage) onPageLoad(app *tview.Application, appContext *stat
 				return
 			case updateCode := <-userProfileUpdatesChannel:
 				if updateCode == chat.USER_PROFILE_UPDATE_REASON_RELATIONSHIP_UPDATE {
-					page.table.Clear()
 					app.QueueUpdateDraw(func() {
 						page.populateTable(appContext.GetBrochatUser(), appContext.GetTheme())
 					})
@@ -173,14 +247,132 @@ func (page *FriendsListPage) onPageLoad(app *tview.Application, appContext *stat
 		}
 	}()
 
+	// Create a goroutine to listen for friends coming online or going offline
+	// Only the affected friend is updated, and the relative last seen times are kept current
+	go func() {
+		subId, presenceChannel := page.feedClient.SubscribeToPresenceEvents()
+
+		defer page.feedClient.UnsubscribeFromPresenceEvents(subId)
+
+		ticker := time.NewTicker(friendsListRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-pageContext.Done():
+				return
+			case evt, ok := <-presenceChannel:
+				if !ok {
+					return
+				}
+
+				app.QueueUpdateDraw(func() {
+					page.applyPresence(evt, appContext.GetTheme())
+				})
+			case <-ticker.C:
+				app.QueueUpdateDraw(func() {
+					page.refreshLastSeen(appContext.GetTheme())
+				})
+			}
+		}
+	}()
 }
 
 func (page *FriendsListPage) onPageClose() {
-	page.userFriends = make(map[uint8]chat.UserRelationship, 0)
+	page.userFriends = make(map[int]chat.UserRelationship, 0)
+	page.friends = make([]chat.UserRelationship, 0)
 	page.table.Clear()
 }
 
+// populateTable lists the user's friends. The selected friend remains selected if they are still a friend.
 func (page *FriendsListPage) populateTable(brochatUser chat.User, thm theme.Theme) {
+	countOfPendingFriendRequests := 0
+
+	page.friends = make([]chat.UserRelationship, 0, len(brochatUser.Relationships))
+
+	for _, rel := range brochatUser.Relationships {
+		if rel.Type&chat.RELATIONSHIP_TYPE_FRIEND_REQUEST_RECIEVED != 0 {
+			countOfPendingFriendRequests++
+		}
+
+		if rel.Type == chat.RELATIONSHIP_TYPE_FRIEND {
+			page.friends = append(page.friends, rel)
+		}
+	}
+
+	page.tvInstructions.SetText(friendsListInstructions(page.keys, countOfPendingFriendRequests))
+
+	page.renderFriends(thm)
+}
+
+// applyPresence updates a single friend's presence. The friend's row is updated in place unless their position in the list changes.
+func (page *FriendsListPage) applyPresence(evt state.UserPresenceEvent, thm theme.Theme) {
+	index := -1
+
+	for i := range page.friends {
+		if page.friends[i].UserId == evt.UserId {
+			index = i
+			break
+		}
+	}
+
+	if index < 0 {
+		return
+	}
+
+	page.friends[index].IsOnline = evt.IsOnline
+
+	if evt.LastOnlineUtc.After(page.friends[index].LastOnlineUtc) {
+		page.friends[index].LastOnlineUtc = evt.LastOnlineUtc
+	}
+
+	if sort.SliceIsSorted(page.friends, page.lessFriend) {
+		page.setFriendRow(index+1, page.friends[index], thm, time.Now())
+		return
+	}
+
+	page.renderFriends(thm)
+}
+
+// refreshLastSeen updates the relative last seen times without changing the order of the list
+func (page *FriendsListPage) refreshLastSeen(thm theme.Theme) {
+	now := time.Now()
+
+	for row, rel := range page.userFriends {
+		page.setFriendRow(row, rel, thm, now)
+	}
+}
+
+// lessFriend orders friends online first, then by most recently online, then by username
+func (page *FriendsListPage) lessFriend(i, j int) bool {
+	a, b := page.friends[i], page.friends[j]
+
+	if a.IsOnline != b.IsOnline {
+		return a.IsOnline
+	}
+
+	if !a.LastOnlineUtc.Equal(b.LastOnlineUtc) {
+		return a.LastOnlineUtc.After(b.LastOnlineUtc)
+	}
+
+	return strings.ToLower(a.Username) < strings.ToLower(b.Username)
+}
+
+// renderFriends sorts the friends and redraws the table, keeping the selected friend selected
+func (page *FriendsListPage) renderFriends(thm theme.Theme) {
+	selectedUserId := ""
+
+	if row, _ := page.table.GetSelection(); row > 0 {
+		if rel, ok := page.userFriends[row]; ok {
+			selectedUserId = rel.UserId
+		}
+	}
+
+	sort.SliceStable(page.friends, page.lessFriend)
+
+	page.table.Clear()
+	page.userFriends = make(map[int]chat.UserRelationship, 0)
+
 	page.table.SetCell(0, 0, tview.NewTableCell("Username").
 		SetTextColor(thm.ForgroundColor).
 		SetAlign(tview.AlignCenter).
@@ -200,37 +392,94 @@ func (page *FriendsListPage) populateTable(brochatUser chat.User, thm theme.Them
 		SetSelectable(false).
 		SetAttributes(tcell.AttrBold|tcell.AttrUnderline))
 
-	countOfPendingFriendRequests := 0
+	now := time.Now()
+	selectedRow := 1
 
-	for _, rel := range brochatUser.Relationships {
-		if rel.Type&chat.RELATIONSHIP_TYPE_FRIEND_REQUEST_RECIEVED != 0 {
-			countOfPendingFriendRequests++
+	for i, rel := range page.friends {
+		row := i + 1
+
+		page.setFriendRow(row, rel, thm, now)
+
+		if rel.UserId == selectedUserId {
+			selectedRow = row
 		}
 	}
 
-	page.tvInstructions.SetText(fmt.Sprintf("(f) Find a new Bro - (p) View Pending [%d] - (esc) Quit", countOfPendingFriendRequests))
+	page.table.Select(selectedRow, 0)
+}
 
-	row := 1
+// setFriendRow writes a friend to a row of the table
+func (page *FriendsListPage) setFriendRow(row int, rel chat.UserRelationship, thm theme.Theme, now time.Time) {
+	page.table.SetCell(row, 0, tview.NewTableCell(rel.Username).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignCenter))
 
-	for _, rel := range brochatUser.Relationships {
+	if rel.IsOnline {
+		page.table.SetCell(row, 1, tview.NewTableCell("Online").SetTextColor(thm.HighlightColor).SetAlign(tview.AlignCenter))
+		page.table.SetCell(row, 2, tview.NewTableCell("now").SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignRight))
+	} else {
+		page.table.SetCell(row, 1, tview.NewTableCell("Offline").SetTextColor(thm.InfoColorTwo).SetAlign(tview.AlignCenter))
+		page.table.SetCell(row, 2, tview.NewTableCell(formatLastSeen(rel.LastOnlineUtc, now)).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignRight))
+	}
 
-		if rel.Type != chat.RELATIONSHIP_TYPE_FRIEND {
-			continue
-		}
+	page.userFriends[row] = rel
+}
+
+// formatLastSeen formats the time a user was last online relative to now. Example: "last seen 5m ago"
+// Times more than a week ago are shown as a date.
+func formatLastSeen(lastOnline time.Time, now time.Time) string {
+	elapsed := now.Sub(lastOnline)
+
+	switch {
+	case lastOnline.IsZero():
+		return "never"
+	case elapsed < time.Minute:
+		return "last seen just now"
+	case elapsed < time.Hour:
+		return fmt.Sprintf("last seen %dm ago", int(elapsed.Minutes()))
+	case elapsed < 24*time.Hour:
+		return fmt.Sprintf("last seen %dh ago", int(elapsed.Hours()))
+	case elapsed < 7*24*time.Hour:
+		return fmt.Sprintf("last seen %dd ago", int(elapsed.Hours()/24))
+	default:
+		return lastOnline.Local().Format("Jan 2, 2006")
+	}
+}
+
+// updateRelationship performs a relationship change and alerts the user to the outcome. onSuccess, which may be nil, is called if the change succeeded.
+// The table is redrawn when the change is reflected back through a user profile update event.
+func (page *FriendsListPage) updateRelationship(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator, successMessage string, change func(ctx context.Context, accessToken string) chat.BroChatClientResult, onSuccess func()) {
+	accessToken, ok := appContext.GetAccessToken()
+
+	if !ok {
+		slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+		nav.RedirectToLogin()
+		return
+	}
 
-		page.table.SetCell(row, 0, tview.NewTableCell(rel.Username).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignCenter))
-		if rel.IsOnline {
-			page.table.SetCell(row, 1, tview.NewTableCell("Online").SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignCenter))
-		} else {
-			page.table.SetCell(row, 1, tview.NewTableCell("Offline").SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignCenter))
+	runSessionAsync(app, appContext, nav, "Updating relationship...", func(ctx context.Context) chat.BroChatClientResult {
+		return change(ctx, accessToken)
+	}, func(result chat.BroChatClientResult) {
+		if err := apperr.FromChatResult(result); err != nil {
+			nav.AlertError(FRIENDS_LIST_PAGE_ALERT_ERR, "The relationship could not be updated.", err, func() {
+				page.updateRelationship(app, appContext, nav, successMessage, change, onSuccess)
+			})
+			return
 		}
 
-		var dateString string = rel.LastOnlineUtc.Local().Format("Jan 2, 2006")
+		nav.Toast(successMessage)
 
-		page.table.SetCell(row, 2, tview.NewTableCell(dateString).SetTextColor(tcell.ColorWhite).SetAlign(tview.AlignRight))
+		if onSuccess != nil {
+			onSuccess()
+		}
+	})
+}
 
-		page.userFriends[uint8(row)] = rel
+// FriendsListPageParameters is load time parameters for the friends list page
+type FriendsListPageParameters struct {
+	// selectedUserId is the user id of the friend to select when the page is loaded
+	selectedUserId string
+}
 
-		row++
-	}
+// Page returns the page the parameters are for
+func (FriendsListPageParameters) Page() PageSlug {
+	return FRIENDS_LIST_PAGE
 }
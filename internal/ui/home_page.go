@@ -1,9 +1,12 @@
 package ui
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"time"
 
+	"github.com/dmars8047/broterm/internal/apperr"
+	"github.com/dmars8047/broterm/internal/keymap"
 	"github.com/dmars8047/broterm/internal/state"
 	"github.com/dmars8047/idamlib/idam"
 	"github.com/gdamore/tcell/v2"
@@ -12,15 +15,15 @@ import (
 
 const HOME_PAGE PageSlug = "home"
 
+const HOME_PAGE_ALERT_ERR = "home:menu:alert:err"
+
 type HomePage struct {
-	userAuthClient   *idam.UserAuthClient
-	currentThemeCode string
+	userAuthClient *idam.UserAuthClient
 }
 
 func NewHomePage(userAuthClient *idam.UserAuthClient) *HomePage {
 	return &HomePage{
-		userAuthClient:   userAuthClient,
-		currentThemeCode: "NOT_SET",
+		userAuthClient: userAuthClient,
 	}
 }
 
@@ -67,27 +70,16 @@ CC |  CC\ HH |  HH |AA  __AA | TT |TT\
 		nav.NavigateTo(ROOM_LIST_PAGE, nil)
 	})
 
-	logoutButton := tview.NewButton("Logout")
-
-	logoutButton.SetSelectedFunc(func() {
-		accessToken, ok := appContext.GetAccessToken()
-
-		if !ok {
-			log.Printf("Valid user authentication information not found. Redirecting to login page.")
-			nav.NavigateTo(LOGIN_PAGE, nil)
-			return
-		}
-
-		err := page.userAuthClient.Logout(accessToken)
+	profileButton := tview.NewButton("Profile")
 
-		if err != nil {
-			nav.AlertFatal(app, "home:menu:alert:err", err.Error())
-			return
-		}
+	profileButton.SetSelectedFunc(func() {
+		nav.NavigateTo(PROFILE_PAGE, nil)
+	})
 
-		appContext.CancelUserSession()
+	logoutButton := tview.NewButton("Logout")
 
-		nav.NavigateTo(WELCOME_PAGE, nil)
+	logoutButton.SetSelectedFunc(func() {
+		logout(app, appContext, nav, page.userAuthClient)
 	})
 
 	buttonGrid := tview.NewGrid()
@@ -106,12 +98,20 @@ CC |  CC\ HH |  HH |AA  __AA | TT |TT\
 		tvInstructions.SetText("Talk to your Bros or find new ones!")
 	})
 
+	profileButton.SetFocusFunc(func() {
+		tvInstructions.SetText("View your profile and set your status.")
+	})
+
+	keys := appContext.GetKeymap()
+
 	buttonGrid.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 
 		goRight := func() {
 			if brosButton.HasFocus() {
 				app.SetFocus(chatButton)
 			} else if chatButton.HasFocus() {
+				app.SetFocus(profileButton)
+			} else if profileButton.HasFocus() {
 				app.SetFocus(logoutButton)
 			} else if logoutButton.HasFocus() {
 				app.SetFocus(brosButton)
@@ -120,6 +120,8 @@ CC |  CC\ HH |  HH |AA  __AA | TT |TT\
 
 		goLeft := func() {
 			if logoutButton.HasFocus() {
+				app.SetFocus(profileButton)
+			} else if profileButton.HasFocus() {
 				app.SetFocus(chatButton)
 			} else if chatButton.HasFocus() {
 				app.SetFocus(brosButton)
@@ -128,17 +130,9 @@ CC |  CC\ HH |  HH |AA  __AA | TT |TT\
 			}
 		}
 
-		// vim movement keys
-		if event.Key() == tcell.KeyRune {
-			switch event.Rune() {
-			case 'l':
-				goRight()
-			case 'h':
-				goLeft()
-			}
-		} else if event.Key() == tcell.KeyTab || event.Key() == tcell.KeyRight {
+		if keys.Is(event, keymap.ACTION_MENU_RIGHT) {
 			goRight()
-		} else if event.Key() == tcell.KeyBacktab || event.Key() == tcell.KeyLeft {
+		} else if keys.Is(event, keymap.ACTION_MENU_LEFT) {
 			goLeft()
 		}
 
@@ -146,50 +140,31 @@ CC |  CC\ HH |  HH |AA  __AA | TT |TT\
 	})
 
 	buttonGrid.SetRows(3, 1, 1).
-		SetColumns(0, 1, 0, 1, 0)
+		SetColumns(0, 1, 0, 1, 0, 1, 0)
 
 	buttonGrid.AddItem(brosButton, 0, 0, 1, 1, 0, 0, true).
 		AddItem(chatButton, 0, 2, 1, 1, 0, 0, true).
-		AddItem(logoutButton, 0, 4, 1, 1, 0, 0, true).
-		AddItem(tvInstructions, 2, 0, 1, 5, 0, 0, false)
+		AddItem(profileButton, 0, 4, 1, 1, 0, 0, true).
+		AddItem(logoutButton, 0, 6, 1, 1, 0, 0, true).
+		AddItem(tvInstructions, 2, 0, 1, 7, 0, 0, false)
 
 	grid.AddItem(logoBro, 1, 1, 1, 1, 0, 0, false).
 		AddItem(logoChat, 1, 2, 1, 1, 0, 0, false).
 		AddItem(buttonGrid, 2, 1, 1, 2, 0, 0, true)
 
-	// Apply all colors and styles
-	applyTheme := func() {
-		theme := appContext.GetTheme()
-
-		if theme.Code != page.currentThemeCode {
-			page.currentThemeCode = theme.Code
-			grid.SetBackgroundColor(theme.BackgroundColor)
+	themes := appContext.GetThemeManager()
 
-			logoBro.SetBackgroundColor(theme.BackgroundColor)
-			logoBro.SetTextColor(tcell.ColorWhite)
-			logoChat.SetBackgroundColor(theme.BackgroundColor)
-			logoChat.SetBackgroundColor(theme.BackgroundColor)
-			logoChat.SetTextColor(theme.HighlightColor)
-
-			brosButton.SetActivatedStyle(theme.ActivatedButtonStyle)
-			brosButton.SetStyle(theme.ButtonStyle)
-
-			chatButton.SetActivatedStyle(theme.ActivatedButtonStyle)
-			chatButton.SetStyle(theme.ButtonStyle)
-
-			logoutButton.SetActivatedStyle(theme.ActivatedButtonStyle)
-			logoutButton.SetStyle(theme.ButtonStyle)
-
-			tvInstructions.SetBackgroundColor(theme.BackgroundColor)
-			tvInstructions.SetTextColor(theme.ForgroundColor)
-		}
-	}
-
-	applyTheme()
+	themeBackground(themes, grid)
+	themeTextView(themes, logoBro, foregroundTextColor)
+	themeTextView(themes, logoChat, highlightTextColor)
+	themeButton(themes, brosButton)
+	themeButton(themes, chatButton)
+	themeButton(themes, profileButton)
+	themeButton(themes, logoutButton)
+	themeTextView(themes, tvInstructions, foregroundTextColor)
 
 	nav.Register(HOME_PAGE, grid, true, false,
-		func(_ interface{}) {
-			applyTheme()
+		func(_ PageParameters) {
 			page.onPageLoad(appContext, nav)
 		}, func() {
 			page.onPageClose()
@@ -200,10 +175,37 @@ func (page *HomePage) onPageLoad(appContext *state.ApplicationContext, nav *Page
 	// Make sure the session is still valid
 	if appContext.GetUserAuth().TokenExpiration.Before(time.Now()) {
 		appContext.CancelUserSession()
-		nav.NavigateTo(LOGIN_PAGE, nil)
+		nav.RedirectToLogin()
 	}
 }
 
 func (page *HomePage) onPageClose() {
 	// Nothing to do here
 }
+
+// logout ends the user session and returns to the welcome page.
+// If the server rejects the token the session has already ended on the server, so the user is logged out locally.
+func logout(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator, userAuthClient *idam.UserAuthClient) {
+	accessToken, ok := appContext.GetAccessToken()
+
+	if !ok {
+		slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+		nav.RedirectToLogin()
+		return
+	}
+
+	runAsync(app, nav, appContext.Context, "Logging out...", func(ctx context.Context) error {
+		return userAuthClient.Logout(accessToken)
+	}, func(err error) {
+		if err != nil && apperr.KindOf(err) != apperr.KIND_AUTH {
+			nav.AlertError(HOME_PAGE_ALERT_ERR, "Logout Failed", err, func() {
+				logout(app, appContext, nav, userAuthClient)
+			})
+			return
+		}
+
+		appContext.CancelUserSession()
+
+		nav.ResetTo(WELCOME_PAGE, nil)
+	})
+}
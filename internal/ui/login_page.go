@@ -1,9 +1,15 @@
 package ui
 
 import (
+	"context"
+	"log/slog"
 	"time"
 
 	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/apperr"
+	"github.com/dmars8047/broterm/internal/chatext"
+	"github.com/dmars8047/broterm/internal/config"
+	"github.com/dmars8047/broterm/internal/keymap"
 	"github.com/dmars8047/broterm/internal/state"
 	"github.com/dmars8047/idamlib/idam"
 	"github.com/dmars8047/strval"
@@ -15,25 +21,26 @@ const LOGIN_PAGE PageSlug = "login"
 
 // LoginPage is the login page
 type LoginPage struct {
-	userAuthClient   *idam.UserAuthClient
-	brochatClient    *chat.BroChatClient
-	feedClient       *state.FeedClient
-	loginForm        *tview.Form
-	currentThemeCode string
+	userAuthClient *idam.UserAuthClient
+	brochatClient  *chat.BroChatClient
+	chatextClient  *chatext.Client
+	feedClient     *state.FeedClient
+	loginForm      *tview.Form
 }
 
 // NewLoginPage creates a new instance of the login page
-func NewLoginPage(userAuthClient *idam.UserAuthClient, brochatClient *chat.BroChatClient, feedClient *state.FeedClient) *LoginPage {
+func NewLoginPage(userAuthClient *idam.UserAuthClient, brochatClient *chat.BroChatClient, chatextClient *chatext.Client, feedClient *state.FeedClient) *LoginPage {
 	return &LoginPage{
-		userAuthClient:   userAuthClient,
-		brochatClient:    brochatClient,
-		feedClient:       feedClient,
-		loginForm:        tview.NewForm(),
-		currentThemeCode: "NOT_SET",
+		userAuthClient: userAuthClient,
+		brochatClient:  brochatClient,
+		chatextClient:  chatextClient,
+		feedClient:     feedClient,
+		loginForm:      tview.NewForm(),
 	}
 }
 
 func (page *LoginPage) Setup(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	keys := appContext.GetKeymap()
 
 	const title = " BroChat - Login "
 
@@ -46,23 +53,26 @@ func (page *LoginPage) Setup(app *tview.Application, appContext *state.Applicati
 	page.loginForm.AddPasswordField("Password", "", 0, '*', nil)
 
 	page.loginForm.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyEscape {
-			nav.NavigateTo(WELCOME_PAGE, nil)
+		if keys.Is(event, keymap.ACTION_BACK) {
+			nav.Back()
 			return nil
-		} else if event.Key() == tcell.KeyCtrlF {
+		} else if keys.Is(event, keymap.ACTION_FORGOT_PASSWORD) {
 			nav.NavigateTo(FORGOT_PW_PAGE, nil)
 		}
 
 		return event
 	})
 
-	page.loginForm.AddButton("Login", func() {
+	var login func()
+
+	login = func() {
 		emailInput, ok := page.loginForm.GetFormItemByLabel("Email").(*tview.InputField)
 
 		formValidationErrors := make([]string, 0)
 
 		if !ok {
-			panic("email input form access failure")
+			nav.Alert("auth:login:alert:err", "Login Failed - Email Field Unavailable")
+			return
 		}
 
 		email := emailInput.GetText()
@@ -80,7 +90,8 @@ func (page *LoginPage) Setup(app *tview.Application, appContext *state.Applicati
 		passwordInput, ok := page.loginForm.GetFormItemByLabel("Password").(*tview.InputField)
 
 		if !ok {
-			panic("password input form access failure")
+			nav.Alert("auth:login:alert:err", "Login Failed - Password Field Unavailable")
+			return
 		}
 
 		password := passwordInput.GetText()
@@ -104,120 +115,140 @@ func (page *LoginPage) Setup(app *tview.Application, appContext *state.Applicati
 			Password: password,
 		}
 
-		loginResponse, err := page.userAuthClient.Login("brochat", request)
-
-		if err != nil {
-			errMessage := err.Error()
-
-			idamErr, ok := err.(*idam.ErrorResponse)
-
-			if ok {
-				switch idamErr.Code {
-				case idam.RequestValidationFailure:
-					errMessage = "Login Failed - Request Validation Error"
-					nav.AlertErrors("auth:login:alert:err", errMessage, idamErr.Details)
-					return
-				case idam.UserNotFound:
-					errMessage = "Login Failed - User Not Found"
-				case idam.InvalidCredentials:
-					errMessage = "Login Failed - Invalid Credentials"
-				case idam.UserAccountLockout:
-					errMessage = "User Account Lockout - Too Many Failed Login Requests"
-				case idam.UnhandledError:
-					errMessage = "Login Failed - An Unexpected Error Occurred"
+		runAsync(app, nav, appContext.Context, "Logging in...", func(ctx context.Context) asyncResult[*idam.UserLoginResponse] {
+			loginResponse, err := page.userAuthClient.Login("brochat", request)
+			return asyncResult[*idam.UserLoginResponse]{value: loginResponse, err: err}
+		}, func(response asyncResult[*idam.UserLoginResponse]) {
+			if err := response.err; err != nil {
+				idamErr, ok := err.(*idam.ErrorResponse)
+
+				if ok {
+					switch idamErr.Code {
+					case idam.RequestValidationFailure:
+						nav.AlertErrors("auth:login:alert:err", "Login Failed - Request Validation Error", idamErr.Details)
+						return
+					case idam.UserNotFound:
+						nav.Alert("auth:login:alert:err", "Login Failed - User Not Found")
+						return
+					case idam.InvalidCredentials:
+						nav.Alert("auth:login:alert:err", "Login Failed - Invalid Credentials")
+						return
+					case idam.UserAccountLockout:
+						nav.Alert("auth:login:alert:err", "User Account Lockout - Too Many Failed Login Requests")
+						return
+					}
 				}
+
+				nav.AlertError("auth:login:alert:err", "Login Failed", err, login)
+				return
 			}
 
-			nav.Alert("auth:login:alert:err", errMessage)
+			page.loadAccount(app, appContext, nav, email, response.value, login)
+		})
+	}
+
+	page.loginForm.AddButton("Login", login)
+
+	page.loginForm.AddButton("Back", func() {
+		nav.Back()
+	})
+
+	tvInstructions := tview.NewTextView().SetTextAlign(tview.AlignCenter)
+	tvInstructions.SetText(keys.Hint(keymap.ACTION_FORGOT_PASSWORD, "Forgot Password?"))
+
+	grid.AddItem(page.loginForm, 1, 1, 1, 1, 0, 0, true)
+	grid.AddItem(tvInstructions, 3, 1, 1, 1, 0, 0, false)
+
+	themes := appContext.GetThemeManager()
+
+	themeBackground(themes, grid)
+	themeForm(themes, page.loginForm)
+	themeTextView(themes, tvInstructions, instructionsTextColor)
+
+	nav.Register(LOGIN_PAGE, grid, true, false, func(param PageParameters) {
+		page.onPageLoad(appContext)
+	}, func() {
+		page.onPageClose()
+	})
+}
+
+// loginAccount is the account of the user who has just logged in
+type loginAccount struct {
+	getUserResult         chat.BroChatClientContentResult[chat.User]
+	getBlockedUsersResult chat.BroChatClientContentResult[[]chat.UserInfo]
+}
+
+// loadAccount retrieves the account of the user who has just logged in, starts the user session and connects the chat feed.
+// retry logs in again.
+func (page *LoginPage) loadAccount(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator, email string, loginResponse *idam.UserLoginResponse, retry func()) {
+	runAsync(app, nav, appContext.Context, "Loading your account...", func(ctx context.Context) loginAccount {
+		return loginAccount{
+			getUserResult:         page.brochatClient.GetUser(loginResponse.Token, loginResponse.UserId),
+			getBlockedUsersResult: page.chatextClient.GetBlockedUsers(ctx, loginResponse.Token),
+		}
+	}, func(account loginAccount) {
+		if err := apperr.FromChatResult(account.getUserResult.BroChatClientResult); err != nil {
+			nav.AlertError("auth:login:alert:err", "Login Failed - Your account could not be retrieved.", err, retry)
 			return
 		}
 
 		userAuth := state.UserAuth{
 			AccessToken:     loginResponse.Token,
 			TokenExpiration: time.Now().Add(time.Duration(loginResponse.ExpiresIn * int64(time.Second))),
+			Email:           email,
 		}
 
 		appContext.SetUserSession(userAuth, func() {
 			app.QueueUpdateDraw(
 				func() {
-					nav.NavigateTo(WELCOME_PAGE, WelcomePageParams{isRedirect: true, redirectMessage: "Your session has expired. Please login again."})
+					nav.ResetTo(WELCOME_PAGE, WelcomePageParams{isRedirect: true, redirectMessage: "Your session has expired. Please login again."})
 				},
 			)
 		})
 
-		passwordInput.SetText("")
-		emailInput.SetText("")
-
-		getUserResult := page.brochatClient.GetUser(loginResponse.Token, loginResponse.UserId)
+		page.clearForm()
 
-		err = getUserResult.Err()
-
-		if err != nil {
-			if len(getUserResult.ErrorDetails) > 0 {
-				nav.Alert("auth:login:alert:err", getUserResult.ErrorDetails[0])
-				return
-			}
-
-			if getUserResult.ResponseCode == chat.BROCHAT_RESPONSE_CODE_FORBIDDEN_ERROR {
-				nav.Alert("auth:login:alert:err", FORBIDDEN_OPERATION_ERROR_MESSAGE)
-				return
-			}
-
-			nav.Alert("auth:login:alert:err", err.Error())
-			return
-		}
-
-		brochatUser := getUserResult.Content
+		brochatUser := account.getUserResult.Content
 
 		appContext.SetBrochatUser(brochatUser)
 
-		err = page.feedClient.Connect()
+		profilePreferences, err := config.LoadProfilePreferences()
 
 		if err != nil {
-			nav.Alert("auth:login:alert:err", err.Error())
-			return
+			slog.Warn("Error loading profile preferences", "err", err)
 		}
 
-		nav.NavigateTo(HOME_PAGE, nil)
-	})
+		appContext.SetChatColor(profilePreferences.ChatColor(brochatUser.Id))
 
-	page.loginForm.AddButton("Back", func() {
-		nav.NavigateTo(WELCOME_PAGE, nil)
-	})
+		// The blocked users are only used to hide messages so a failure here should not prevent the login
+		if err := account.getBlockedUsersResult.Err(); err != nil {
+			slog.Error("An error occurred while retrieving blocked users", "err", err)
+			appContext.SetBlockedUsers(nil)
+		} else {
+			appContext.SetBlockedUsers(account.getBlockedUsersResult.Content)
+		}
 
-	tvInstructions := tview.NewTextView().SetTextAlign(tview.AlignCenter)
-	tvInstructions.SetText("(CTRL + F) Forgot Password?")
+		page.connectFeed(app, appContext, nav)
+	})
+}
 
-	grid.AddItem(page.loginForm, 1, 1, 1, 1, 0, 0, true)
-	grid.AddItem(tvInstructions, 3, 1, 1, 1, 0, 0, false)
+// connectFeed connects the chat feed for the user session and goes to the home page once it is connected
+func (page *LoginPage) connectFeed(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	ctx, cancel := appContext.GenerateUserSessionBoundContextWithCancel()
 
-	applyTheme := func() {
-		theme := appContext.GetTheme()
-
-		if page.currentThemeCode != theme.Code {
-			page.currentThemeCode = theme.Code
-			grid.SetBackgroundColor(theme.BackgroundColor)
-			page.loginForm.SetBackgroundColor(theme.AccentColor)
-			page.loginForm.SetFieldBackgroundColor(theme.AccentColorTwo)
-			page.loginForm.SetFieldTextColor(theme.ForgroundColor)
-			page.loginForm.SetLabelColor(theme.HighlightColor)
-			page.loginForm.SetButtonStyle(theme.ButtonStyle)
-			page.loginForm.SetButtonActivatedStyle(theme.ActivatedButtonStyle)
-			page.loginForm.SetBorderColor(theme.BorderColor)
-			page.loginForm.SetTitleColor(theme.TitleColor)
-			tvInstructions.SetBackgroundColor(theme.BackgroundColor)
-			tvInstructions.SetTextColor(theme.InfoColor)
+	runAsync(app, nav, ctx, "Connecting to the chat feed...", func(ctx context.Context) error {
+		return page.feedClient.Connect(ctx)
+	}, func(err error) {
+		cancel()
 
+		if err != nil {
+			nav.AlertError("auth:login:alert:err", "Login Failed - The chat feed could not be connected.", err, func() {
+				page.connectFeed(app, appContext, nav)
+			})
+			return
 		}
-	}
-
-	applyTheme()
 
-	nav.Register(LOGIN_PAGE, grid, true, false, func(param interface{}) {
-		applyTheme()
-		page.onPageLoad(appContext)
-	}, func() {
-		page.onPageClose()
+		nav.ResetTo(HOME_PAGE, nil)
 	})
 }
 
@@ -227,19 +258,20 @@ func (page *LoginPage) onPageLoad(appContext *state.ApplicationContext) {
 }
 
 func (page *LoginPage) onPageClose() {
-	emailInput, ok := page.loginForm.GetFormItemByLabel("Email").(*tview.InputField)
+	page.clearForm()
+}
 
-	if !ok {
-		panic("email input form clear failure")
+// clearForm clears the email and password inputs
+func (page *LoginPage) clearForm() {
+	if emailInput, ok := page.loginForm.GetFormItemByLabel("Email").(*tview.InputField); ok {
+		emailInput.SetText("")
+	} else {
+		slog.Error("Email input form clear failure for login page")
 	}
 
-	emailInput.SetText("")
-
-	pwInput, ok := page.loginForm.GetFormItemByLabel("Password").(*tview.InputField)
-
-	if !ok {
-		panic("password input form clear failure")
+	if pwInput, ok := page.loginForm.GetFormItemByLabel("Password").(*tview.InputField); ok {
+		pwInput.SetText("")
+	} else {
+		slog.Error("Password input form clear failure for login page")
 	}
-
-	pwInput.SetText("")
 }
@@ -0,0 +1,173 @@
+package ui
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/dmars8047/broterm/internal/theme"
+	"github.com/rivo/tview"
+)
+
+// queuedModal is an alert or confirmation which is being shown or is waiting to be shown
+type queuedModal struct {
+	id       string
+	pageName string
+	message  string
+	modal    *tview.Modal
+}
+
+// modalQueue shows alert and confirmation modals one at a time in the order they were opened.
+// Each modal is added as its own page so modals opened with the same id do not replace each other.
+// Once the last modal is dismissed focus is returned to the primitive which had focus before the first modal was shown.
+type modalQueue struct {
+	app           *tview.Application
+	pages         *tview.Pages
+	mu            sync.Mutex
+	active        *queuedModal
+	pending       []*queuedModal
+	previousFocus tview.Primitive
+	sequence      int
+}
+
+// newModalQueue creates an empty modal queue which shows its modals above the pages
+func newModalQueue(app *tview.Application, pages *tview.Pages) *modalQueue {
+	return &modalQueue{
+		app:     app,
+		pages:   pages,
+		pending: make([]*queuedModal, 0),
+	}
+}
+
+// push queues the modal and shows it if no other modal is being shown.
+// Returns nil if a modal with the same id and message is already being shown or waiting, in which case the modal is dropped.
+func (queue *modalQueue) push(id, message string, modal *tview.Modal) *queuedModal {
+	queue.mu.Lock()
+
+	if queue.isQueued(id, message) {
+		queue.mu.Unlock()
+		return nil
+	}
+
+	queue.sequence++
+
+	entry := &queuedModal{
+		id:       id,
+		pageName: fmt.Sprintf("%s#%d", id, queue.sequence),
+		message:  message,
+		modal:    modal,
+	}
+
+	if queue.active != nil {
+		queue.pending = append(queue.pending, entry)
+		queue.mu.Unlock()
+		return entry
+	}
+
+	queue.active = entry
+	queue.mu.Unlock()
+
+	queue.previousFocus = queue.app.GetFocus()
+	queue.show(entry)
+
+	return entry
+}
+
+// dismiss removes the modal and shows the next modal in the queue.
+// Focus is restored once there are no more modals to show.
+func (queue *modalQueue) dismiss(entry *queuedModal) {
+	if entry == nil {
+		return
+	}
+
+	queue.mu.Lock()
+
+	if queue.active != entry {
+		// The modal was never shown so it only needs to be taken out of the queue
+		for i, pending := range queue.pending {
+			if pending == entry {
+				queue.pending = append(queue.pending[:i], queue.pending[i+1:]...)
+				break
+			}
+		}
+
+		queue.mu.Unlock()
+		return
+	}
+
+	var next *queuedModal
+
+	if len(queue.pending) > 0 {
+		next = queue.pending[0]
+		queue.pending = queue.pending[1:]
+	}
+
+	queue.active = next
+	queue.mu.Unlock()
+
+	queue.pages.HidePage(entry.pageName).RemovePage(entry.pageName)
+
+	if next != nil {
+		queue.show(next)
+		return
+	}
+
+	if queue.previousFocus != nil {
+		queue.app.SetFocus(queue.previousFocus)
+		queue.previousFocus = nil
+	}
+}
+
+// keepOnTop shows the active modal above a page which has just been switched to.
+// The page's focused primitive is remembered so that focus is returned to the new page once the modals are dismissed.
+func (queue *modalQueue) keepOnTop() {
+	queue.mu.Lock()
+	active := queue.active
+	queue.mu.Unlock()
+
+	if active == nil {
+		return
+	}
+
+	queue.previousFocus = queue.app.GetFocus()
+	queue.show(active)
+}
+
+// style re-themes the modal being shown and the modals waiting to be shown
+func (queue *modalQueue) style(thm theme.Theme) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	if queue.active != nil {
+		styleModal(queue.active.modal, thm)
+	}
+
+	for _, entry := range queue.pending {
+		styleModal(entry.modal, thm)
+	}
+}
+
+// show adds the modal's page above every other page and focuses it
+func (queue *modalQueue) show(entry *queuedModal) {
+	if queue.pages.HasPage(entry.pageName) {
+		queue.pages.ShowPage(entry.pageName).SendToFront(entry.pageName)
+	} else {
+		queue.pages.AddPage(entry.pageName, entry.modal, false, true)
+	}
+
+	queue.app.SetFocus(entry.modal)
+}
+
+// isQueued returns true if a modal with the id and message is being shown or waiting. Must be called with the lock held.
+func (queue *modalQueue) isQueued(id, message string) bool {
+	if queue.active != nil && queue.active.id == id && queue.active.message == message {
+		return true
+	}
+
+	for _, entry := range queue.pending {
+		if entry.id == id && entry.message == message {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,92 @@
+package ui
+
+// maxHistoryEntries is the number of pages which can be gone back to. The oldest entries are forgotten first.
+const maxHistoryEntries = 100
+
+// HistoryEntry is a visit to a page along with the parameters the page was loaded with
+type HistoryEntry struct {
+	Page   PageSlug
+	Params PageParameters
+}
+
+// NavigationHistory is a back and forward stack of page visits, like the history of a web browser.
+// It only records visits and has no dependency on the terminal, the page navigator shows the pages.
+type NavigationHistory struct {
+	current HistoryEntry
+	back    []HistoryEntry
+	forward []HistoryEntry
+}
+
+// NewNavigationHistory creates a history which starts at the given entry
+func NewNavigationHistory(start HistoryEntry) *NavigationHistory {
+	return &NavigationHistory{
+		current: start,
+		back:    make([]HistoryEntry, 0),
+		forward: make([]HistoryEntry, 0),
+	}
+}
+
+// Current returns the entry of the page currently being visited
+func (history *NavigationHistory) Current() HistoryEntry {
+	return history.current
+}
+
+// Visit records a visit to a new page. The current page can be gone back to and the forward history is discarded.
+func (history *NavigationHistory) Visit(entry HistoryEntry) {
+	history.back = append(history.back, history.current)
+
+	if len(history.back) > maxHistoryEntries {
+		history.back = history.back[len(history.back)-maxHistoryEntries:]
+	}
+
+	history.forward = history.forward[:0]
+	history.current = entry
+}
+
+// Replace replaces the current entry without recording a visit. Going back skips the replaced page.
+func (history *NavigationHistory) Replace(entry HistoryEntry) {
+	history.current = entry
+}
+
+// Reset discards the back and forward history and starts again at the given entry
+func (history *NavigationHistory) Reset(entry HistoryEntry) {
+	history.back = history.back[:0]
+	history.forward = history.forward[:0]
+	history.current = entry
+}
+
+// Back moves to the previous entry and returns it. Returns false if there is no entry to go back to.
+func (history *NavigationHistory) Back() (HistoryEntry, bool) {
+	if len(history.back) == 0 {
+		return HistoryEntry{}, false
+	}
+
+	history.forward = append(history.forward, history.current)
+	history.current = history.back[len(history.back)-1]
+	history.back = history.back[:len(history.back)-1]
+
+	return history.current, true
+}
+
+// Forward moves to the entry which was last gone back from and returns it. Returns false if there is no entry to go forward to.
+func (history *NavigationHistory) Forward() (HistoryEntry, bool) {
+	if len(history.forward) == 0 {
+		return HistoryEntry{}, false
+	}
+
+	history.back = append(history.back, history.current)
+	history.current = history.forward[len(history.forward)-1]
+	history.forward = history.forward[:len(history.forward)-1]
+
+	return history.current, true
+}
+
+// CanGoBack returns true if there is an entry to go back to
+func (history *NavigationHistory) CanGoBack() bool {
+	return len(history.back) > 0
+}
+
+// CanGoForward returns true if there is an entry to go forward to
+func (history *NavigationHistory) CanGoForward() bool {
+	return len(history.forward) > 0
+}
@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+)
+
+func historyEntry(page string) HistoryEntry {
+	return HistoryEntry{Page: PageSlug(page)}
+}
+
+func assertCurrent(t *testing.T, history *NavigationHistory, want string) {
+	t.Helper()
+
+	if got := history.Current().Page; got != PageSlug(want) {
+		t.Fatalf("current page is %q, want %q", got, want)
+	}
+}
+
+func TestNavigationHistoryVisit(t *testing.T) {
+	history := NewNavigationHistory(historyEntry("a"))
+
+	if history.CanGoBack() || history.CanGoForward() {
+		t.Fatal("a new history should have nothing to go back or forward to")
+	}
+
+	history.Visit(historyEntry("b"))
+	history.Visit(historyEntry("c"))
+
+	assertCurrent(t, history, "c")
+
+	if !history.CanGoBack() {
+		t.Fatal("expected to be able to go back after visiting a page")
+	}
+
+	if history.CanGoForward() {
+		t.Fatal("expected nothing to go forward to after visiting a page")
+	}
+}
+
+func TestNavigationHistoryBackAndForward(t *testing.T) {
+	history := NewNavigationHistory(historyEntry("a"))
+	history.Visit(historyEntry("b"))
+	history.Visit(historyEntry("c"))
+
+	for _, want := range []string{"b", "a"} {
+		got, ok := history.Back()
+
+		if !ok || got.Page != PageSlug(want) {
+			t.Fatalf("Back() = %q, %v, want %q, true", got.Page, ok, want)
+		}
+
+		assertCurrent(t, history, want)
+	}
+
+	if _, ok := history.Back(); ok {
+		t.Fatal("expected Back() to fail at the start of the history")
+	}
+
+	assertCurrent(t, history, "a")
+
+	for _, want := range []string{"b", "c"} {
+		got, ok := history.Forward()
+
+		if !ok || got.Page != PageSlug(want) {
+			t.Fatalf("Forward() = %q, %v, want %q, true", got.Page, ok, want)
+		}
+
+		assertCurrent(t, history, want)
+	}
+
+	if _, ok := history.Forward(); ok {
+		t.Fatal("expected Forward() to fail at the end of the history")
+	}
+}
+
+func TestNavigationHistoryVisitDiscardsForward(t *testing.T) {
+	history := NewNavigationHistory(historyEntry("a"))
+	history.Visit(historyEntry("b"))
+	history.Back()
+
+	history.Visit(historyEntry("c"))
+
+	if history.CanGoForward() {
+		t.Fatal("expected visiting a page to discard the forward history")
+	}
+
+	if got, _ := history.Back(); got.Page != "a" {
+		t.Fatalf("Back() = %q, want %q", got.Page, "a")
+	}
+}
+
+func TestNavigationHistoryReplace(t *testing.T) {
+	history := NewNavigationHistory(historyEntry("a"))
+	history.Visit(historyEntry("b"))
+
+	history.Replace(historyEntry("c"))
+
+	assertCurrent(t, history, "c")
+
+	got, ok := history.Back()
+
+	if !ok || got.Page != "a" {
+		t.Fatalf("Back() = %q, %v, want %q, true", got.Page, ok, "a")
+	}
+
+	if got, _ := history.Forward(); got.Page != "c" {
+		t.Fatalf("Forward() = %q, want the replacing page %q", got.Page, "c")
+	}
+}
+
+func TestNavigationHistoryReset(t *testing.T) {
+	history := NewNavigationHistory(historyEntry("a"))
+	history.Visit(historyEntry("b"))
+	history.Visit(historyEntry("c"))
+	history.Back()
+
+	history.Reset(historyEntry("d"))
+
+	assertCurrent(t, history, "d")
+
+	if history.CanGoBack() || history.CanGoForward() {
+		t.Fatal("expected Reset() to discard the back and forward history")
+	}
+}
+
+func TestNavigationHistoryTrimsOldestEntries(t *testing.T) {
+	history := NewNavigationHistory(historyEntry("0"))
+
+	visits := maxHistoryEntries + 10
+
+	for i := 1; i <= visits; i++ {
+		history.Visit(historyEntry(fmt.Sprint(i)))
+	}
+
+	backs := 0
+	var oldest HistoryEntry
+
+	for {
+		got, ok := history.Back()
+
+		if !ok {
+			break
+		}
+
+		oldest = got
+		backs++
+	}
+
+	if backs != maxHistoryEntries {
+		t.Fatalf("went back %d times, want %d", backs, maxHistoryEntries)
+	}
+
+	if want := PageSlug(fmt.Sprint(visits - maxHistoryEntries)); oldest.Page != want {
+		t.Fatalf("oldest entry is %q, want %q", oldest.Page, want)
+	}
+}
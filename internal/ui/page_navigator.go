@@ -2,41 +2,76 @@ package ui
 
 import (
 	"fmt"
+	"log/slog"
 	"strings"
 
+	"github.com/dmars8047/broterm/internal/apperr"
+	"github.com/dmars8047/broterm/internal/keymap"
 	"github.com/dmars8047/broterm/internal/state"
+	"github.com/dmars8047/broterm/internal/theme"
+	"github.com/dmars8047/broterm/internal/transport"
+	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
 type PageSlug string
 
+// PageParameters are the load time parameters of a page. Each page which takes parameters has its own parameters type.
+// The parameters are recorded in the navigation history so the page can be loaded the same way when it is gone back to.
+type PageParameters interface {
+	// Page returns the page the parameters are for
+	Page() PageSlug
+}
+
 // PageNavigator is a page navigator
 type PageNavigator struct {
+	app        *tview.Application
 	current    PageSlug
 	Pages      *tview.Pages
 	appContext *state.ApplicationContext
-	openFuncs  map[PageSlug]func(interface{})
+	history    *NavigationHistory
+	openFuncs  map[PageSlug]func(PageParameters)
 	closeFuncs map[PageSlug]func()
+	modals     *modalQueue
+	toasts     *toastArea
+	loading    *loadingIndicator
+	breaker    *transport.CircuitBreaker
+	// generation counts the pages shown so that a navigation made while a page is opening can be detected
+	generation uint64
 }
 
 // NewNavigator creates a new page navigator
-func NewNavigator(appContext *state.ApplicationContext) *PageNavigator {
+func NewNavigator(app *tview.Application, appContext *state.ApplicationContext) *PageNavigator {
 	pages := tview.NewPages()
 
-	return &PageNavigator{
+	nav := &PageNavigator{
+		app:        app,
 		appContext: appContext,
 		current:    WELCOME_PAGE,
 		Pages:      pages,
-		openFuncs:  make(map[PageSlug]func(interface{})),
+		history:    NewNavigationHistory(HistoryEntry{Page: WELCOME_PAGE}),
+		openFuncs:  make(map[PageSlug]func(PageParameters)),
 		closeFuncs: make(map[PageSlug]func()),
+		modals:     newModalQueue(app, pages),
+		toasts:     newToastArea(app, appContext),
+		loading:    newLoadingIndicator(app, pages, appContext),
 	}
+
+	// The globals and page background are shared by every page. Modals are re-themed while they are open.
+	appContext.GetThemeManager().Register(func(thm theme.Theme) {
+		thm.ApplyGlobals()
+		pages.SetBackgroundColor(thm.BackgroundColor)
+		nav.modals.style(thm)
+	})
+
+	return nav
 }
 
 // Register registers a page with the page navigator
 func (nav *PageNavigator) Register(page PageSlug,
 	primitive tview.Primitive,
 	resize, visible bool,
-	openFunc func(interface{}),
+	openFunc func(PageParameters),
 	closeFunc func()) {
 
 	nav.Pages.AddPage(string(page), primitive, resize, visible)
@@ -50,136 +85,294 @@ func (nav *PageNavigator) Register(page PageSlug,
 	}
 }
 
-// NavigateTo navigates to a page
-func (nav *PageNavigator) NavigateTo(pageName PageSlug, param interface{}) {
-	close, ok := nav.closeFuncs[nav.current]
+// Current returns the page which is being shown
+func (nav *PageNavigator) Current() PageSlug {
+	return nav.current
+}
+
+// NavigateTo navigates to a page and records the visit in the navigation history.
+// The params may be nil for pages which do not take parameters.
+func (nav *PageNavigator) NavigateTo(pageName PageSlug, params PageParameters) {
+	nav.history.Visit(nav.entryFor(pageName, params))
+	nav.show(nav.history.Current())
+}
+
+// Redirect navigates to a page in place of the current page. Going back skips the page which was redirected from.
+// Used to reload a page, for example to retry loading a conversation.
+func (nav *PageNavigator) Redirect(pageName PageSlug, params PageParameters) {
+	nav.history.Replace(nav.entryFor(pageName, params))
+	nav.show(nav.history.Current())
+}
+
+// ResetTo navigates to a page and discards the navigation history, for example after logging in or out
+func (nav *PageNavigator) ResetTo(pageName PageSlug, params PageParameters) {
+	nav.history.Reset(nav.entryFor(pageName, params))
+	nav.show(nav.history.Current())
+}
+
+// RedirectToLogin navigates to the login page when the user session is no longer valid.
+// The navigation history is discarded so that going back from the login page returns to the welcome page.
+func (nav *PageNavigator) RedirectToLogin() {
+	nav.history.Reset(HistoryEntry{Page: WELCOME_PAGE})
+	nav.NavigateTo(LOGIN_PAGE, nil)
+}
+
+// Back returns to the previous page in the navigation history, loading it with the parameters it was last loaded with.
+// Returns false and stays on the current page if there is no page to go back to.
+func (nav *PageNavigator) Back() bool {
+	entry, ok := nav.history.Back()
 
 	if ok {
-		close()
+		nav.show(entry)
 	}
 
-	open, ok := nav.openFuncs[pageName]
+	return ok
+}
+
+// Forward returns to the page which was last gone back from. Returns false if there is no page to go forward to.
+func (nav *PageNavigator) Forward() bool {
+	entry, ok := nav.history.Forward()
 
 	if ok {
-		open(param)
+		nav.show(entry)
 	}
 
-	nav.Pages.SwitchToPage(string(pageName))
+	return ok
+}
 
-	nav.current = pageName
+// IsLoading returns true if a request started by the current page is still running
+func (nav *PageNavigator) IsLoading() bool {
+	return nav.loading.isLoading()
 }
 
-// Confirm creates a confirmation modal
-func (nav *PageNavigator) Confirm(id string, massage string, yesFunc func()) *tview.Pages {
-	theme := nav.appContext.GetTheme()
+// SetCircuitBreaker sets the circuit breaker of the http transport so that errors raised while the circuit is open are shown as the server being unavailable
+func (nav *PageNavigator) SetCircuitBreaker(breaker *transport.CircuitBreaker) {
+	nav.breaker = breaker
+}
 
-	modal := tview.NewModal().
-		SetText(massage).
-		AddButtons([]string{"Yes", "No"}).
-		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-			if buttonLabel == "Yes" {
-				yesFunc()
-			}
-			nav.Pages.HidePage(id).RemovePage(id)
-		})
+// History returns the navigation history
+func (nav *PageNavigator) History() *NavigationHistory {
+	return nav.history
+}
 
-	modal.SetBackgroundColor(theme.BackgroundColor)
-	modal.SetTextColor(theme.ForgroundColor)
-	modal.SetButtonStyle(theme.ButtonStyle)
-	modal.SetButtonActivatedStyle(theme.ActivatedButtonStyle)
-	modal.SetBorderColor(theme.BorderColor)
-	modal.SetBorderStyle(theme.TextAreaTextStyle)
-	modal.SetTitleColor(theme.TitleColor)
+// InputCapture handles the history keys on every page. It is meant to be used as, or called from, the application's input capture.
+// The history keys are left to text inputs while one has focus so that they can be used for editing, such as moving by word.
+func (nav *PageNavigator) InputCapture(event *tcell.EventKey) *tcell.EventKey {
+	if isTextInput(nav.app.GetFocus()) {
+		return event
+	}
+
+	keys := nav.appContext.GetKeymap()
+
+	if keys.Is(event, keymap.ACTION_HISTORY_BACK) {
+		nav.Back()
+		return nil
+	} else if keys.Is(event, keymap.ACTION_HISTORY_FORWARD) {
+		nav.Forward()
+		return nil
+	}
 
-	return nav.Pages.AddPage(
-		id,
-		modal,
-		false,
-		true,
-	)
+	return event
 }
 
-// Alert creates an alert modal
-func (nav *PageNavigator) Alert(id string, message string) *tview.Pages {
-	theme := nav.appContext.GetTheme()
+// isTextInput returns true if the primitive is a text input
+func isTextInput(primitive tview.Primitive) bool {
+	switch primitive.(type) {
+	case *tview.InputField, *tview.TextArea:
+		return true
+	}
 
-	modal := tview.NewModal().
-		SetText(message).
-		AddButtons([]string{"Close"}).
-		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-			nav.Pages.HidePage(id).RemovePage(id)
-		})
+	return false
+}
 
-	modal.SetBackgroundColor(theme.BackgroundColor)
-	modal.SetTextColor(theme.ForgroundColor)
-	modal.SetButtonStyle(theme.ButtonStyle)
-	modal.SetButtonActivatedStyle(theme.ActivatedButtonStyle)
-	modal.SetBorderColor(theme.BorderColor)
-	modal.SetBorderStyle(theme.TextAreaTextStyle)
-	modal.SetTitleColor(theme.TitleColor)
+// entryFor returns the history entry for a visit to the page. Parameters meant for a different page are dropped.
+func (nav *PageNavigator) entryFor(pageName PageSlug, params PageParameters) HistoryEntry {
+	if params != nil && params.Page() != pageName {
+		slog.Warn("Page parameters were passed to the wrong page and have been ignored", "params_page", params.Page(), "page", pageName)
+		params = nil
+	}
 
-	return nav.Pages.AddPage(
-		id,
-		modal,
-		false,
-		true,
-	)
+	return HistoryEntry{Page: pageName, Params: params}
 }
 
-// AlertWithDoneFunc creates an alert modal with a done function
-func (nav *PageNavigator) AlertWithDoneFunc(id string, message string, doneFunc func(buttonIndex int, buttonLabel string)) *tview.Pages {
-	theme := nav.appContext.GetTheme()
+// show closes the current page and opens the page of the history entry.
+// A request which is still running is cancelled so that its response is not applied to the new page.
+func (nav *PageNavigator) show(entry HistoryEntry) {
+	nav.loading.cancelActive()
 
-	modal := tview.NewModal().
-		SetText(message).
-		AddButtons([]string{"Close"}).
-		SetDoneFunc(doneFunc)
+	close, ok := nav.closeFuncs[nav.current]
+
+	if ok {
+		close()
+	}
+
+	// The page is current while it opens so that a navigation made by its open func, such as a redirect to the login page, closes it
+	nav.current = entry.Page
+	nav.generation++
+	generation := nav.generation
+
+	open, ok := nav.openFuncs[entry.Page]
+
+	if ok {
+		open(entry.Params)
+	}
+
+	// The open func navigated to another page, which has already been shown
+	if nav.generation != generation {
+		return
+	}
 
-	modal.SetBackgroundColor(theme.BackgroundColor)
-	modal.SetTextColor(theme.ForgroundColor)
-	modal.SetButtonStyle(theme.ButtonStyle)
-	modal.SetButtonActivatedStyle(theme.ActivatedButtonStyle)
-	modal.SetBorderColor(theme.BorderColor)
-	modal.SetBorderStyle(theme.TextAreaTextStyle)
-	modal.SetTitleColor(theme.TitleColor)
+	nav.Pages.SwitchToPage(string(entry.Page))
 
-	return nav.Pages.AddPage(
-		id,
-		modal,
-		false,
-		true,
-	)
+	// Switching pages hides the loading indicator and the modals so they are brought back above the new page
+	nav.loading.keepOnTop()
+	nav.modals.keepOnTop()
 }
 
-// AlertFatal creates a fatal alert modal
-func (nav *PageNavigator) AlertFatal(app *tview.Application, id string, message string) *tview.Pages {
-	theme := nav.appContext.GetTheme()
+// Confirm queues a confirmation modal. The yes function is called after the modal is dismissed with the yes button.
+func (nav *PageNavigator) Confirm(id string, massage string, yesFunc func()) {
+	nav.showModal(id, massage, []string{"Yes", "No"}, func(buttonIndex int, buttonLabel string) {
+		if buttonLabel == "Yes" {
+			yesFunc()
+		}
+	})
+}
 
-	modal := tview.NewModal().
-		SetText("Fatal Error: " + message).
-		AddButtons([]string{"Exit"}).
-		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-			app.Stop()
-		})
+// Alert queues an alert modal
+func (nav *PageNavigator) Alert(id string, message string) {
+	nav.showModal(id, message, []string{"Close"}, nil)
+}
+
+// AlertWithDoneFunc queues an alert modal. The done function is called after the modal is dismissed.
+func (nav *PageNavigator) AlertWithDoneFunc(id string, message string, doneFunc func(buttonIndex int, buttonLabel string)) {
+	nav.showModal(id, message, []string{"Close"}, doneFunc)
+}
 
-	modal.SetBackgroundColor(theme.BackgroundColor)
-	modal.SetTextColor(theme.ForgroundColor)
-	modal.SetButtonStyle(theme.ButtonStyle)
-	modal.SetButtonActivatedStyle(theme.ActivatedButtonStyle)
-	modal.SetBorderColor(theme.BorderColor)
-	modal.SetBorderStyle(theme.TextAreaTextStyle)
-	modal.SetTitleColor(theme.TitleColor)
+// AlertFatal queues a fatal alert modal which stops the application when dismissed.
+// Only used for unrecoverable state, errors the user can recover from are shown with AlertError.
+func (nav *PageNavigator) AlertFatal(app *tview.Application, id string, message string) {
+	nav.showModal(id, "Fatal Error: "+message, []string{"Exit"}, func(buttonIndex int, buttonLabel string) {
+		app.Stop()
+	})
+}
 
-	return nav.Pages.AddPage(
-		id,
-		modal,
-		false,
-		true,
-	)
+// Toast briefly shows an informational message in the corner of the screen.
+// Toasts do not take focus or need to be dismissed so they are used for confirming that something succeeded.
+func (nav *PageNavigator) Toast(message string) {
+	nav.toasts.show(message)
 }
 
 // AlertErrors creates an alert modal with a list of errors
 func (nav *PageNavigator) AlertErrors(id, errMessage string, messages []string) {
+	nav.Alert(id, appendErrorList(errMessage, messages))
+}
+
+// AlertError classifies the error and queues an alert describing it. failure describes what failed, for example "Room Creation Failed".
+// Errors which may succeed if the operation is tried again offer a retry button which calls retry. retry may be nil if the operation cannot be retried.
+// Authentication errors while the user is logged in mean the session is no longer valid, so the user is sent to the login page once the alert is dismissed.
+func (nav *PageNavigator) AlertError(id, failure string, err error, retry func()) {
+	classified := apperr.Classify(err)
+
+	if classified == nil {
+		return
+	}
+
+	// The BroChat client reports every transport error as a connection error, so an open circuit is checked for here
+	if classified.Kind == apperr.KIND_NETWORK && nav.breaker != nil && nav.breaker.IsOpen() {
+		classified = &apperr.Error{Kind: apperr.KIND_UNAVAILABLE, Details: classified.Details, Err: err}
+	}
+
+	slog.Error(failure, "kind", classified.Kind.String(), "err", err)
+
+	message := failure
+
+	if len(classified.Details) > 0 {
+		message = appendErrorList(message, classified.Details)
+	} else {
+		message += "\n\n" + classified.Summary()
+	}
+
+	if classified.Kind == apperr.KIND_AUTH {
+		if _, ok := nav.appContext.GetAccessToken(); ok {
+			nav.AlertWithDoneFunc(id, message+"\n\nPlease login again.", func(buttonIndex int, buttonLabel string) {
+				nav.RedirectToLogin()
+			})
+			return
+		}
+	}
+
+	if classified.Retryable() && retry != nil {
+		nav.showModal(id, message, []string{"Retry", "Close"}, func(buttonIndex int, buttonLabel string) {
+			if buttonLabel == "Retry" {
+				retry()
+			}
+		})
+		return
+	}
+
+	nav.Alert(id, message)
+}
+
+// Prompt shows a single line input above the current page. The done function is called with the text entered when enter is pressed.
+// Pressing escape closes the prompt without calling the done function.
+// Focus is returned to the primitive which had focus when the prompt was opened before the done function is called.
+func (nav *PageNavigator) Prompt(app *tview.Application, id, title string, done func(text string)) {
+	previousFocus := app.GetFocus()
+
+	input := tview.NewInputField()
+	input.SetBorder(true)
+	input.SetTitle(fmt.Sprintf(" %s ", title))
+	styleSearchInput(input, nav.appContext.GetTheme())
+
+	closePrompt := func() {
+		nav.Pages.HidePage(id).RemovePage(id)
+		app.SetFocus(previousFocus)
+	}
+
+	input.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			text := input.GetText()
+			closePrompt()
+			done(text)
+		case tcell.KeyEscape:
+			closePrompt()
+		}
+	})
+
+	grid := tview.NewGrid()
+	grid.SetRows(0, 3, 0)
+	grid.SetColumns(0, 50, 0)
+	grid.AddItem(input, 1, 1, 1, 1, 0, 0, true)
+
+	nav.Pages.AddPage(id, grid, true, true)
+	app.SetFocus(input)
+}
+
+// showModal styles the modal with the active theme and queues it to be shown above the current page.
+// The modal is removed and focus restored before the done function is called, so the done function is free to open another modal or navigate.
+func (nav *PageNavigator) showModal(id, message string, buttons []string, doneFunc func(buttonIndex int, buttonLabel string)) {
+	modal := tview.NewModal().
+		SetText(message).
+		AddButtons(buttons)
+
+	styleModal(modal, nav.appContext.GetTheme())
+
+	var entry *queuedModal
+
+	modal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		nav.modals.dismiss(entry)
+
+		if doneFunc != nil {
+			doneFunc(buttonIndex, buttonLabel)
+		}
+	})
+
+	entry = nav.modals.push(id, message, modal)
+}
+
+// appendErrorList appends the messages to the error message as a bulleted list. Messages too short to be meaningful are skipped.
+func appendErrorList(errMessage string, messages []string) string {
 	added := false
 
 	for _, message := range messages {
@@ -193,5 +386,5 @@ func (nav *PageNavigator) AlertErrors(id, errMessage string, messages []string)
 		}
 	}
 
-	nav.Alert(id, errMessage)
+	return errMessage
 }
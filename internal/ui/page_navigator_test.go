@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dmars8047/broterm/internal/state"
+	"github.com/dmars8047/broterm/internal/theme"
+	"github.com/rivo/tview"
+)
+
+func TestPageNavigatorRedirectWhileOpening(t *testing.T) {
+	app := tview.NewApplication()
+	appContext := state.NewApplicationContext(context.Background(), "", theme.TRUE_COLOR_DEPTH)
+	nav := NewNavigator(app, appContext)
+
+	const (
+		pageFrom     PageSlug = "from"
+		pageRedirect PageSlug = "redirect"
+		pageTarget   PageSlug = "target"
+	)
+
+	closed := make(map[PageSlug]int)
+
+	register := func(page PageSlug, open func()) {
+		nav.Register(page, tview.NewBox(), true, false, func(_ PageParameters) {
+			if open != nil {
+				open()
+			}
+		}, func() {
+			closed[page]++
+		})
+	}
+
+	register(pageFrom, nil)
+	register(pageTarget, nil)
+	register(pageRedirect, func() {
+		nav.Redirect(pageTarget, nil)
+	})
+
+	nav.NavigateTo(pageFrom, nil)
+	nav.NavigateTo(pageRedirect, nil)
+
+	if got := nav.History().Current().Page; got != pageTarget {
+		t.Fatalf("history is at %q, want %q", got, pageTarget)
+	}
+
+	if nav.current != pageTarget {
+		t.Fatalf("current page is %q, want %q", nav.current, pageTarget)
+	}
+
+	if front, _ := nav.Pages.GetFrontPage(); front != string(pageTarget) {
+		t.Fatalf("front page is %q, want %q", front, pageTarget)
+	}
+
+	if closed[pageFrom] != 1 || closed[pageRedirect] != 1 {
+		t.Fatalf("closed %v, want the page navigated from and the redirecting page closed once each", closed)
+	}
+}
@@ -0,0 +1,306 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/apperr"
+	"github.com/dmars8047/broterm/internal/chatext"
+	"github.com/dmars8047/broterm/internal/config"
+	"github.com/dmars8047/broterm/internal/state"
+	"github.com/dmars8047/broterm/internal/theme"
+	"github.com/dmars8047/strval"
+	"github.com/rivo/tview"
+)
+
+const PROFILE_PAGE PageSlug = "profile"
+
+const (
+	PROFILE_PAGE_ALERT_ERR = "home:profile:alert:err"
+)
+
+// automaticChatColor is the chat color option used when the user has no preferred chat color
+const automaticChatColor = "automatic"
+
+// ProfilePage is the page where the user can view and edit their own profile
+type ProfilePage struct {
+	chatextClient *chatext.Client
+	preferences   *config.ProfilePreferences
+	tvSummary     *tview.TextView
+	form          *tview.Form
+}
+
+// NewProfilePage creates a new profile page
+func NewProfilePage(chatextClient *chatext.Client) *ProfilePage {
+	preferences, err := config.LoadProfilePreferences()
+
+	if err != nil {
+		slog.Warn("Error loading profile preferences", "err", err)
+	}
+
+	return &ProfilePage{
+		chatextClient: chatextClient,
+		preferences:   preferences,
+		tvSummary:     tview.NewTextView(),
+		form:          tview.NewForm(),
+	}
+}
+
+// Setup sets up the profile page and registers it with the page navigator
+func (page *ProfilePage) Setup(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	page.tvSummary.SetBorder(true)
+	page.tvSummary.SetTitle(" BroChat - Profile ")
+	page.tvSummary.SetTitleAlign(tview.AlignCenter)
+	page.tvSummary.SetDynamicColors(true)
+	page.tvSummary.SetBorderPadding(0, 0, 1, 1)
+
+	page.form.SetBorder(true)
+	page.form.SetTitle(" Edit Profile ")
+	page.form.SetTitleAlign(tview.AlignCenter)
+
+	page.form.AddInputField("Status Message", "", 0, nil, nil)
+	page.form.AddCheckbox("Away", false, nil)
+	page.form.AddDropDown("Chat Color", append([]string{automaticChatColor}, config.ChatColors...), 0, nil)
+
+	page.form.AddButton("Save", func() {
+		page.saveProfile(app, appContext, nav)
+	})
+
+	page.form.AddButton("Back", func() {
+		nav.Back()
+	})
+
+	page.form.SetCancelFunc(func() {
+		nav.Back()
+	})
+
+	tvInstructions := tview.NewTextView().SetTextAlign(tview.AlignCenter)
+	tvInstructions.SetText("(tab) Next Field - (esc) Back")
+
+	grid := tview.NewGrid()
+	grid.SetRows(2, 10, 11, 1, 1, 0)
+	grid.SetColumns(0, 70, 0)
+
+	grid.AddItem(page.tvSummary, 1, 1, 1, 1, 0, 0, false)
+	grid.AddItem(page.form, 2, 1, 1, 1, 0, 0, true)
+	grid.AddItem(tvInstructions, 4, 1, 1, 1, 0, 0, false)
+
+	themes := appContext.GetThemeManager()
+
+	themeBackground(themes, grid)
+	themeForm(themes, page.form)
+	themeTextView(themes, tvInstructions, instructionsTextColor)
+
+	// The summary is part of the form so it shares the form's background
+	themes.Register(func(thm theme.Theme) {
+		page.tvSummary.SetBackgroundColor(thm.AccentColor)
+		page.tvSummary.SetTextColor(thm.ForgroundColor)
+		page.tvSummary.SetBorderColor(thm.BorderColor)
+		page.tvSummary.SetTitleColor(thm.TitleColor)
+	})
+
+	nav.Register(PROFILE_PAGE, grid, true, false,
+		func(_ PageParameters) {
+			page.onPageLoad(app, appContext, nav)
+		},
+		func() {
+			page.onPageClose()
+		})
+}
+
+// onPageLoad is called when the profile page is navigated to
+func (page *ProfilePage) onPageLoad(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	accessToken, ok := appContext.GetAccessToken()
+
+	if !ok {
+		slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+		nav.RedirectToLogin()
+		return
+	}
+
+	page.populateSummary(appContext)
+
+	chatColorDropdown, ok := page.form.GetFormItemByLabel("Chat Color").(*tview.DropDown)
+
+	if ok {
+		chatColorDropdown.SetCurrentOption(0)
+
+		chatColor := appContext.GetChatColor()
+
+		for i, color := range config.ChatColors {
+			if color == chatColor {
+				chatColorDropdown.SetCurrentOption(i + 1)
+				break
+			}
+		}
+	}
+
+	page.form.SetFocus(0)
+
+	runSessionAsync(app, appContext, nav, "Loading your profile...", func(ctx context.Context) chat.BroChatClientContentResult[chatext.UserProfile] {
+		return page.chatextClient.GetProfile(ctx, accessToken)
+	}, func(result chat.BroChatClientContentResult[chatext.UserProfile]) {
+		if err := apperr.FromChatResult(result.BroChatClientResult); err != nil {
+			nav.AlertError(PROFILE_PAGE_ALERT_ERR, "Your profile could not be retrieved.", err, func() {
+				page.onPageLoad(app, appContext, nav)
+			})
+			return
+		}
+
+		statusInput, ok := page.form.GetFormItemByLabel("Status Message").(*tview.InputField)
+
+		if ok {
+			statusInput.SetText(result.Content.StatusMessage)
+		}
+
+		awayCheckbox, ok := page.form.GetFormItemByLabel("Away").(*tview.Checkbox)
+
+		if ok {
+			awayCheckbox.SetChecked(result.Content.IsAway)
+		}
+	})
+}
+
+// onPageClose is called when the profile page is navigated away from
+func (page *ProfilePage) onPageClose() {
+	page.tvSummary.Clear()
+
+	statusInput, ok := page.form.GetFormItemByLabel("Status Message").(*tview.InputField)
+
+	if ok {
+		statusInput.SetText("")
+	}
+
+	awayCheckbox, ok := page.form.GetFormItemByLabel("Away").(*tview.Checkbox)
+
+	if ok {
+		awayCheckbox.SetChecked(false)
+	}
+}
+
+// populateSummary shows the user's account details and a summary of their relationships and rooms
+func (page *ProfilePage) populateSummary(appContext *state.ApplicationContext) {
+	brochatUser := appContext.GetBrochatUser()
+	thm := appContext.GetTheme()
+
+	friends, online, received, sent := 0, 0, 0, 0
+
+	for _, rel := range brochatUser.Relationships {
+		switch {
+		case rel.Type == chat.RELATIONSHIP_TYPE_FRIEND:
+			friends++
+
+			if rel.IsOnline {
+				online++
+			}
+		case rel.Type&chat.RELATIONSHIP_TYPE_FRIEND_REQUEST_RECIEVED != 0:
+			received++
+		case rel.Type&chat.RELATIONSHIP_TYPE_FRIENDSHIP_REQUESTED != 0:
+			sent++
+		}
+	}
+
+	owned := 0
+
+	for _, room := range brochatUser.Rooms {
+		if room.Owner.Id == brochatUser.Id {
+			owned++
+		}
+	}
+
+	label := fmt.Sprintf("[#%06x]", thm.HighlightColor.Hex())
+	value := fmt.Sprintf("[#%06x]", thm.ForgroundColor.Hex())
+
+	lines := []string{
+		fmt.Sprintf("%sUsername:%s %s", label, value, tview.Escape(brochatUser.Username)),
+		fmt.Sprintf("%sEmail:%s %s", label, value, tview.Escape(appContext.GetUserAuth().Email)),
+		fmt.Sprintf("%sMember Since:%s %s", label, value, brochatUser.CreatedAtUtc.Local().Format("Jan 2, 2006")),
+		"",
+		fmt.Sprintf("%sFriends:%s %d (%d online)", label, value, friends, online),
+		fmt.Sprintf("%sFriend Requests:%s %d received, %d sent", label, value, received, sent),
+		fmt.Sprintf("%sBlocked Users:%s %d", label, value, len(appContext.GetBlockedUsers())),
+		fmt.Sprintf("%sRooms:%s %d (%d owned)", label, value, len(brochatUser.Rooms), owned),
+	}
+
+	page.tvSummary.SetText(strings.Join(lines, "\n"))
+}
+
+// saveProfile validates the profile form, updates the profile and saves the chat color preference
+func (page *ProfilePage) saveProfile(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	accessToken, ok := appContext.GetAccessToken()
+
+	if !ok {
+		slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+		nav.RedirectToLogin()
+		return
+	}
+
+	statusInput, ok := page.form.GetFormItemByLabel("Status Message").(*tview.InputField)
+
+	if !ok {
+		nav.Alert(PROFILE_PAGE_ALERT_ERR, "Profile Update Failed - Status Message Field Unavailable")
+		return
+	}
+
+	awayCheckbox, ok := page.form.GetFormItemByLabel("Away").(*tview.Checkbox)
+
+	if !ok {
+		nav.Alert(PROFILE_PAGE_ALERT_ERR, "Profile Update Failed - Away Field Unavailable")
+		return
+	}
+
+	chatColorDropdown, ok := page.form.GetFormItemByLabel("Chat Color").(*tview.DropDown)
+
+	if !ok {
+		nav.Alert(PROFILE_PAGE_ALERT_ERR, "Profile Update Failed - Chat Color Field Unavailable")
+		return
+	}
+
+	statusMessage := strings.TrimSpace(statusInput.GetText())
+
+	valResult := strval.ValidateStringWithName(statusMessage, "Status Message",
+		strval.MustHaveMaxLengthOf(64),
+	)
+
+	if !valResult.Valid {
+		nav.AlertErrors(PROFILE_PAGE_ALERT_ERR, "Profile Update Failed - Form Validation Error", valResult.Messages)
+		return
+	}
+
+	request := chatext.UpdateProfileRequest{
+		StatusMessage: statusMessage,
+		IsAway:        awayCheckbox.IsChecked(),
+	}
+
+	runSessionAsync(app, appContext, nav, "Updating your profile...", func(ctx context.Context) chat.BroChatClientResult {
+		return page.chatextClient.UpdateProfile(ctx, accessToken, request)
+	}, func(result chat.BroChatClientResult) {
+		if err := apperr.FromChatResult(result); err != nil {
+			nav.AlertError(PROFILE_PAGE_ALERT_ERR, "Profile Update Failed", err, func() {
+				page.saveProfile(app, appContext, nav)
+			})
+			return
+		}
+
+		_, chatColor := chatColorDropdown.GetCurrentOption()
+
+		if chatColor == automaticChatColor {
+			chatColor = ""
+		}
+
+		page.preferences.SetChatColor(appContext.GetBrochatUser().Id, chatColor)
+		appContext.SetChatColor(chatColor)
+
+		if err := page.preferences.Save(); err != nil {
+			nav.AlertError(PROFILE_PAGE_ALERT_ERR, "Your profile was updated but your chat color could not be saved.", apperr.Local(err), func() {
+				page.saveProfile(app, appContext, nav)
+			})
+			return
+		}
+
+		nav.Toast("Your profile has been updated.")
+	})
+}
@@ -1,6 +1,10 @@
 package ui
 
 import (
+	"context"
+	"log/slog"
+
+	"github.com/dmars8047/broterm/internal/keymap"
 	"github.com/dmars8047/broterm/internal/state"
 	"github.com/dmars8047/idamlib/idam"
 	"github.com/dmars8047/strval"
@@ -19,7 +23,6 @@ const (
 type RegistrationPage struct {
 	userAuthClient   *idam.UserAuthClient
 	registrationForm *tview.Form
-	currentThemCode  string
 }
 
 // NewRegistrationPage creates a new instance of the registration page
@@ -27,11 +30,12 @@ func NewRegistrationPage(userAuthClient *idam.UserAuthClient) *RegistrationPage
 	return &RegistrationPage{
 		userAuthClient:   userAuthClient,
 		registrationForm: tview.NewForm(),
-		currentThemCode:  "NOT_SET",
 	}
 }
 
 func (page *RegistrationPage) Setup(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	keys := appContext.GetKeymap()
+
 	grid := tview.NewGrid()
 
 	grid.SetRows(4, 0, 6)
@@ -41,164 +45,155 @@ func (page *RegistrationPage) Setup(app *tview.Application, appContext *state.Ap
 
 	// If the user presses the escape key, navigate back to the welcome page
 	page.registrationForm.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyEscape {
-			nav.NavigateTo(WELCOME_PAGE, nil)
+		if keys.Is(event, keymap.ACTION_BACK) {
+			nav.Back()
 			return nil
 		}
 
 		return event
 	})
 
-	page.registrationForm.
-		AddInputField("Email", "", 0, nil, nil).
-		AddInputField("Username", "", 0, nil, nil).
-		AddPasswordField("Password", "", 0, '*', nil).
-		AddPasswordField("Confirm Password", "", 0, '*', nil).
-		AddButton("Register", func() {
-			formValidationErrors := make([]string, 0)
+	var register func()
 
-			emailInput, ok := page.registrationForm.GetFormItemByLabel("Email").(*tview.InputField)
+	register = func() {
+		formValidationErrors := make([]string, 0)
 
-			if !ok {
-				panic("email input form clear failure")
-			}
+		emailInput, ok := page.registrationForm.GetFormItemByLabel("Email").(*tview.InputField)
 
-			email := emailInput.GetText()
+		if !ok {
+			nav.Alert(REGISTRATION_MODAL_ERR, "Registration Failed - Email Field Unavailable")
+			return
+		}
 
-			valResult := strval.ValidateStringWithName(email,
-				"Email",
-				strval.MustNotBeEmpty(),
-				strval.MustBeValidEmailFormat(),
-			)
+		email := emailInput.GetText()
 
-			if !valResult.Valid {
-				formValidationErrors = append(formValidationErrors, valResult.Messages...)
-			}
+		valResult := strval.ValidateStringWithName(email,
+			"Email",
+			strval.MustNotBeEmpty(),
+			strval.MustBeValidEmailFormat(),
+		)
 
-			passwordInput, ok := page.registrationForm.GetFormItemByLabel("Password").(*tview.InputField)
+		if !valResult.Valid {
+			formValidationErrors = append(formValidationErrors, valResult.Messages...)
+		}
 
-			if !ok {
-				panic("password input form clear failure")
-			}
+		passwordInput, ok := page.registrationForm.GetFormItemByLabel("Password").(*tview.InputField)
 
-			password := passwordInput.GetText()
-
-			valResult = strval.ValidateStringWithName(password,
-				"Password",
-				strval.MustNotBeEmpty(),
-				strval.MustHaveMinLengthOf(idam.MinPasswordLength),
-				strval.MustHaveMaxLengthOf(idam.MaxPasswordLength),
-				strval.MustContainAtLeastOne([]rune(idam.AllowablePasswordSpecialCharacters)),
-				strval.MustNotContainAnyOf([]rune(idam.DisallowedPassowrdSpecialCharacters)),
-				strval.MustContainNumbers(),
-				strval.MustContainUppercaseLetter(),
-				strval.MustContainLowercaseLetter(),
-				strval.MustOnlyContainPrintableCharacters(),
-				strval.MustOnlyContainASCIICharacters(),
-			)
-
-			if !valResult.Valid {
-				formValidationErrors = append(formValidationErrors, valResult.Messages...)
-			}
+		if !ok {
+			nav.Alert(REGISTRATION_MODAL_ERR, "Registration Failed - Password Field Unavailable")
+			return
+		}
 
-			confirmPasswordInput, ok := page.registrationForm.GetFormItemByLabel("Confirm Password").(*tview.InputField)
+		password := passwordInput.GetText()
+
+		valResult = strval.ValidateStringWithName(password,
+			"Password",
+			strval.MustNotBeEmpty(),
+			strval.MustHaveMinLengthOf(idam.MinPasswordLength),
+			strval.MustHaveMaxLengthOf(idam.MaxPasswordLength),
+			strval.MustContainAtLeastOne([]rune(idam.AllowablePasswordSpecialCharacters)),
+			strval.MustNotContainAnyOf([]rune(idam.DisallowedPassowrdSpecialCharacters)),
+			strval.MustContainNumbers(),
+			strval.MustContainUppercaseLetter(),
+			strval.MustContainLowercaseLetter(),
+			strval.MustOnlyContainPrintableCharacters(),
+			strval.MustOnlyContainASCIICharacters(),
+		)
+
+		if !valResult.Valid {
+			formValidationErrors = append(formValidationErrors, valResult.Messages...)
+		}
 
-			if !ok {
-				panic("confirm password input form clear failure")
-			}
+		confirmPasswordInput, ok := page.registrationForm.GetFormItemByLabel("Confirm Password").(*tview.InputField)
 
-			confirmPassword := confirmPasswordInput.GetText()
+		if !ok {
+			nav.Alert(REGISTRATION_MODAL_ERR, "Registration Failed - Confirm Password Field Unavailable")
+			return
+		}
 
-			if password != confirmPassword {
-				formValidationErrors = append(formValidationErrors, "Passwords do not match")
-			}
+		confirmPassword := confirmPasswordInput.GetText()
 
-			usernameInput, ok := page.registrationForm.GetFormItemByLabel("Username").(*tview.InputField)
+		if password != confirmPassword {
+			formValidationErrors = append(formValidationErrors, "Passwords do not match")
+		}
 
-			if !ok {
-				panic("username input form clear failure")
-			}
+		usernameInput, ok := page.registrationForm.GetFormItemByLabel("Username").(*tview.InputField)
 
-			username := usernameInput.GetText()
+		if !ok {
+			nav.Alert(REGISTRATION_MODAL_ERR, "Registration Failed - Username Field Unavailable")
+			return
+		}
 
-			valResult = strval.ValidateStringWithName(username,
-				"Username",
-				strval.MustNotBeEmpty(),
-				strval.MustBeAlphaNumeric(),
-				strval.MustHaveMinLengthOf(3),
-				strval.MustHaveMaxLengthOf(20),
-			)
+		username := usernameInput.GetText()
 
-			if !valResult.Valid {
-				formValidationErrors = append(formValidationErrors, valResult.Messages...)
-			}
+		valResult = strval.ValidateStringWithName(username,
+			"Username",
+			strval.MustNotBeEmpty(),
+			strval.MustBeAlphaNumeric(),
+			strval.MustHaveMinLengthOf(3),
+			strval.MustHaveMaxLengthOf(20),
+		)
 
-			if len(formValidationErrors) > 0 {
-				nav.AlertErrors(REGISTRATION_MODAL_ERR, "Login Failed - Form Validation Error", formValidationErrors)
-				return
-			}
+		if !valResult.Valid {
+			formValidationErrors = append(formValidationErrors, valResult.Messages...)
+		}
 
-			request := &idam.UserRegistrationRequest{
-				Email:    email,
-				Password: password,
-				Username: username,
-			}
+		if len(formValidationErrors) > 0 {
+			nav.AlertErrors(REGISTRATION_MODAL_ERR, "Login Failed - Form Validation Error", formValidationErrors)
+			return
+		}
 
-			_, err := page.userAuthClient.Register("brochat", request)
+		request := &idam.UserRegistrationRequest{
+			Email:    email,
+			Password: password,
+			Username: username,
+		}
 
+		runAsync(app, nav, appContext.Context, "Registering...", func(ctx context.Context) error {
+			_, err := page.userAuthClient.Register("brochat", request)
+			return err
+		}, func(err error) {
 			if err != nil {
-				errMessage := err.Error()
-
 				idamErr, ok := err.(*idam.ErrorResponse)
 
 				if ok {
 					switch idamErr.Code {
 					case idam.RequestValidationFailure:
-						errMessage = "Registration Failed - Request Validation Error"
-						nav.AlertErrors(REGISTRATION_MODAL_ERR, errMessage, idamErr.Details)
+						nav.AlertErrors(REGISTRATION_MODAL_ERR, "Registration Failed - Request Validation Error", idamErr.Details)
 						return
 					case idam.InvalidCredentials:
-						errMessage = "Registration Failed - Invalid Credentials"
-					case idam.UnhandledError:
-						errMessage = "Registration Failed - An Unexpected Error Occurred"
+						nav.Alert(REGISTRATION_MODAL_ERR, "Registration Failed - Invalid Credentials")
+						return
 					}
 				}
 
-				nav.Alert(REGISTRATION_MODAL_ERR, errMessage)
+				nav.AlertError(REGISTRATION_MODAL_ERR, "Registration Failed", err, register)
 				return
 			}
 
 			nav.AlertWithDoneFunc(REGISTRATION_MODAL_INFO, REGISTRATION_SUCCESS_MESSAGE, func(buttonIndex int, buttonLabel string) {
-				nav.Pages.HidePage(REGISTRATION_MODAL_INFO).RemovePage(REGISTRATION_MODAL_INFO)
-				nav.NavigateTo(WELCOME_PAGE, nil)
+				nav.Back()
 			})
-		}).
-		AddButton("Back", func() { nav.NavigateTo(WELCOME_PAGE, nil) })
+		})
+	}
+
+	page.registrationForm.
+		AddInputField("Email", "", 0, nil, nil).
+		AddInputField("Username", "", 0, nil, nil).
+		AddPasswordField("Password", "", 0, '*', nil).
+		AddPasswordField("Confirm Password", "", 0, '*', nil).
+		AddButton("Register", register).
+		AddButton("Back", func() { nav.Back() })
 
 	grid.AddItem(page.registrationForm, 1, 1, 1, 1, 0, 0, true)
 
-	applyTheme := func() {
-		theme := appContext.GetTheme()
-
-		if page.currentThemCode != theme.Code {
-			page.currentThemCode = theme.Code
-			grid.SetBackgroundColor(theme.BackgroundColor)
-			page.registrationForm.SetBackgroundColor(theme.AccentColor)
-			page.registrationForm.SetFieldBackgroundColor(theme.AccentColorTwo)
-			page.registrationForm.SetFieldTextColor(theme.ForgroundColor)
-			page.registrationForm.SetLabelColor(theme.HighlightColor)
-			page.registrationForm.SetButtonStyle(theme.ButtonStyle)
-			page.registrationForm.SetButtonActivatedStyle(theme.ActivatedButtonStyle)
-			page.registrationForm.SetBorderColor(theme.BorderColor)
-			page.registrationForm.SetTitleColor(theme.TitleColor)
-		}
-	}
+	themes := appContext.GetThemeManager()
 
-	applyTheme()
+	themeBackground(themes, grid)
+	themeForm(themes, page.registrationForm)
 
 	nav.Register(REGISTER_PAGE, grid, true, false,
-		func(param interface{}) {
-			applyTheme()
+		func(param PageParameters) {
 			page.onPageLoad()
 		},
 		func() {
@@ -211,35 +206,11 @@ func (page *RegistrationPage) onPageLoad() {
 }
 
 func (page *RegistrationPage) onPageClose() {
-	emailInput, ok := page.registrationForm.GetFormItemByLabel("Email").(*tview.InputField)
-
-	if !ok {
-		panic("email input form clear failure")
-	}
-
-	emailInput.SetText("")
-
-	passwordInput, ok := page.registrationForm.GetFormItemByLabel("Password").(*tview.InputField)
-
-	if !ok {
-		panic("password input form clear failure")
-	}
-
-	passwordInput.SetText("")
-
-	confirmPasswordInput, ok := page.registrationForm.GetFormItemByLabel("Confirm Password").(*tview.InputField)
-
-	if !ok {
-		panic("confirm password input form clear failure")
-	}
-
-	confirmPasswordInput.SetText("")
-
-	usernameInput, ok := page.registrationForm.GetFormItemByLabel("Username").(*tview.InputField)
-
-	if !ok {
-		panic("username input form clear failure")
+	for _, label := range []string{"Email", "Password", "Confirm Password", "Username"} {
+		if input, ok := page.registrationForm.GetFormItemByLabel(label).(*tview.InputField); ok {
+			input.SetText("")
+		} else {
+			slog.Error("Input form clear failure on close for registration page", "label", label)
+		}
 	}
-
-	usernameInput.SetText("")
 }
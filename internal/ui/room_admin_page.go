@@ -0,0 +1,666 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/apperr"
+	"github.com/dmars8047/broterm/internal/chatext"
+	"github.com/dmars8047/broterm/internal/keymap"
+	"github.com/dmars8047/broterm/internal/state"
+	"github.com/dmars8047/broterm/internal/theme"
+	"github.com/dmars8047/strval"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const ROOM_ADMIN_PAGE PageSlug = "room_admin"
+
+const (
+	ROOM_ADMIN_PAGE_ALERT_INFO = "home:roomadmin:alert:info"
+	ROOM_ADMIN_PAGE_ALERT_ERR  = "home:roomadmin:alert:err"
+	ROOM_ADMIN_PAGE_CONFIRM    = "home:roomadmin:confirm"
+)
+
+const roomAdminFormInstructions = "Change the room settings or manage its members."
+
+// roomAdminMembersInstructions returns the instructions for the member table using the keys bound in the keymap
+func roomAdminMembersInstructions(keys *keymap.Keymap) string {
+	return strings.Join([]string{
+		keys.Hint(keymap.ACTION_REMOVE_MEMBER, "Remove Member"),
+		keys.Hint(keymap.ACTION_TRANSFER_OWNERSHIP, "Transfer Ownership"),
+		keys.Hint(keymap.ACTION_BACK, "Back to Settings"),
+	}, " - ")
+}
+
+// roomAdminInviteInstructions returns the instructions for the invite table using the keys bound in the keymap
+func roomAdminInviteInstructions(keys *keymap.Keymap) string {
+	return "(enter) Invite Friend - " + keys.Hint(keymap.ACTION_BACK, "Back to Settings")
+}
+
+// RoomAdminPage is the page where a room owner can administer their room
+type RoomAdminPage struct {
+	brochatClient    *chat.BroChatClient
+	chatextClient    *chatext.Client
+	feedClient       *state.FeedClient
+	form             *tview.Form
+	table            *tview.Table
+	tvInstructions   *tview.TextView
+	keys             *keymap.Keymap
+	room             chat.Room
+	members          map[int]chat.UserInfo
+	inviteCandidates map[int]chat.UserRelationship
+	invitedUserIds   map[string]struct{}
+	inviteMode       bool
+}
+
+// RoomAdminPageParameters is load time parameters for the room admin page
+type RoomAdminPageParameters struct {
+	room chat.Room
+}
+
+// Page returns the page the parameters are for
+func (RoomAdminPageParameters) Page() PageSlug {
+	return ROOM_ADMIN_PAGE
+}
+
+// NewRoomAdminPage creates a new room admin page
+func NewRoomAdminPage(brochatClient *chat.BroChatClient, chatextClient *chatext.Client, feedClient *state.FeedClient) *RoomAdminPage {
+	return &RoomAdminPage{
+		brochatClient:    brochatClient,
+		chatextClient:    chatextClient,
+		feedClient:       feedClient,
+		form:             tview.NewForm(),
+		table:            tview.NewTable(),
+		tvInstructions:   tview.NewTextView(),
+		members:          make(map[int]chat.UserInfo, 0),
+		inviteCandidates: make(map[int]chat.UserRelationship, 0),
+		invitedUserIds:   make(map[string]struct{}, 0),
+	}
+}
+
+// Setup sets up the room admin page and registers it with the page navigator
+func (page *RoomAdminPage) Setup(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	keys := appContext.GetKeymap()
+	page.keys = keys
+
+	var pageContext context.Context
+	var cancel context.CancelFunc
+
+	page.form.SetBorder(true)
+	page.form.SetTitle(" BroChat - Room Administration ")
+	page.form.SetTitleAlign(tview.AlignCenter)
+
+	page.form.AddInputField("Room Name", "", 0, nil, nil)
+	page.form.AddDropDown("Membership Model", []string{string(chat.PUBLIC_MEMBERSHIP_MODEL), string(chat.FRIENDS_MEMBERSHIP_MODEL)}, 0, nil)
+
+	page.form.AddButton("Save", func() {
+		page.saveSettings(app, appContext, nav, pageContext)
+	})
+
+	page.form.AddButton("Members", func() {
+		page.showMembers(appContext)
+		app.SetFocus(page.table)
+	})
+
+	page.form.AddButton("Invite Friends", func() {
+		if page.room.MembershipModel != chat.FRIENDS_MEMBERSHIP_MODEL {
+			nav.Alert(ROOM_ADMIN_PAGE_ALERT_INFO, "Anyone can join a public room. Invites are only needed for friends rooms.")
+			return
+		}
+
+		page.showInviteCandidates(appContext)
+		app.SetFocus(page.table)
+	})
+
+	page.form.AddButton("Delete Room", func() {
+		var deleteRoom func()
+
+		deleteRoom = func() {
+			accessToken, ok := appContext.GetAccessToken()
+
+			if !ok {
+				slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+				nav.RedirectToLogin()
+				return
+			}
+
+			roomId := page.room.Id
+
+			runAsync(app, nav, pageContext, "Deleting room...", func(ctx context.Context) chat.BroChatClientResult {
+				return page.chatextClient.DeleteRoom(ctx, accessToken, roomId)
+			}, func(result chat.BroChatClientResult) {
+				if page.alertResultError(nav, result, "The room could not be deleted.", deleteRoom) {
+					return
+				}
+
+				nav.Toast(fmt.Sprintf("The room '%s' has been deleted.", page.room.Name))
+				nav.Back()
+			})
+		}
+
+		nav.Confirm(ROOM_ADMIN_PAGE_CONFIRM, fmt.Sprintf("Delete the room '%s'? This cannot be undone.", page.room.Name), deleteRoom)
+	})
+
+	page.form.AddButton("Back", func() {
+		nav.Back()
+	})
+
+	page.form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if keys.Is(event, keymap.ACTION_BACK) {
+			nav.Back()
+			return nil
+		}
+
+		return event
+	})
+
+	page.form.SetFocusFunc(func() {
+		page.tvInstructions.SetText(roomAdminFormInstructions)
+	})
+
+	page.table.SetBorder(true)
+	page.table.SetFixed(1, 0)
+	page.table.SetSelectable(true, false)
+
+	page.table.SetFocusFunc(func() {
+		if page.inviteMode {
+			page.tvInstructions.SetText(roomAdminInviteInstructions(keys))
+		} else {
+			page.tvInstructions.SetText(roomAdminMembersInstructions(keys))
+		}
+	})
+
+	page.table.SetSelectedFunc(func(row int, _ int) {
+		if !page.inviteMode {
+			return
+		}
+
+		rel, ok := page.inviteCandidates[row]
+
+		if !ok {
+			return
+		}
+
+		var invite func()
+
+		invite = func() {
+			accessToken, ok := appContext.GetAccessToken()
+
+			if !ok {
+				slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+				nav.RedirectToLogin()
+				return
+			}
+
+			request := chat.InviteUserToRoomRequest{
+				RoomId: page.room.Id,
+				UserId: rel.UserId,
+			}
+
+			runAsync(app, nav, pageContext, fmt.Sprintf("Inviting %s...", rel.Username), func(ctx context.Context) chat.BroChatClientResult {
+				return page.chatextClient.InviteUserToRoom(ctx, accessToken, request)
+			}, func(result chat.BroChatClientResult) {
+				if page.alertResultError(nav, result, "The user could not be invited.", invite) {
+					return
+				}
+
+				// An invited friend is not a member until they accept, so they are tracked separately to keep them out of the candidates
+				page.invitedUserIds[rel.UserId] = struct{}{}
+				page.showInviteCandidates(appContext)
+				nav.Toast(fmt.Sprintf("%s has been invited to '%s'.", rel.Username, page.room.Name))
+			})
+		}
+
+		nav.Confirm(ROOM_ADMIN_PAGE_CONFIRM, fmt.Sprintf("Invite %s to '%s'?", rel.Username, page.room.Name), invite)
+	})
+
+	vimNavigation := vimTableNavigation(app, appContext, nav, page.table, 1, nil)
+
+	page.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if vimNavigation(event) {
+			return nil
+		}
+
+		if keys.Is(event, keymap.ACTION_BACK) {
+			page.showMembers(appContext)
+			app.SetFocus(page.form)
+			return nil
+		}
+
+		if page.inviteMode {
+			return event
+		}
+
+		action, ok := keys.Match(event, keymap.ACTION_REMOVE_MEMBER, keymap.ACTION_TRANSFER_OWNERSHIP)
+
+		if !ok {
+			return event
+		}
+
+		row, _ := page.table.GetSelection()
+		member, ok := page.members[row]
+
+		if !ok {
+			return event
+		}
+
+		switch action {
+		case keymap.ACTION_REMOVE_MEMBER:
+			if member.Id == page.room.Owner.Id {
+				nav.Alert(ROOM_ADMIN_PAGE_ALERT_INFO, "The owner cannot be removed from the room. Transfer ownership first.")
+				return nil
+			}
+
+			var removeMember func()
+
+			removeMember = func() {
+				accessToken, ok := appContext.GetAccessToken()
+
+				if !ok {
+					slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+					nav.RedirectToLogin()
+					return
+				}
+
+				roomId := page.room.Id
+
+				runAsync(app, nav, pageContext, fmt.Sprintf("Removing %s...", member.Username), func(ctx context.Context) chat.BroChatClientResult {
+					return page.chatextClient.RemoveRoomMember(ctx, accessToken, roomId, member.Id)
+				}, func(result chat.BroChatClientResult) {
+					if page.alertResultError(nav, result, "The member could not be removed.", removeMember) {
+						return
+					}
+
+					nav.Toast(fmt.Sprintf("%s has been removed from '%s'.", member.Username, page.room.Name))
+					page.loadMembers(app, appContext, nav, pageContext)
+				})
+			}
+
+			nav.Confirm(ROOM_ADMIN_PAGE_CONFIRM, fmt.Sprintf("Remove %s from '%s'?", member.Username, page.room.Name), removeMember)
+
+			return nil
+		case keymap.ACTION_TRANSFER_OWNERSHIP:
+			if member.Id == page.room.Owner.Id {
+				return nil
+			}
+
+			var transferOwnership func()
+
+			transferOwnership = func() {
+				accessToken, ok := appContext.GetAccessToken()
+
+				if !ok {
+					slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+					nav.RedirectToLogin()
+					return
+				}
+
+				request := chatext.TransferRoomOwnershipRequest{
+					NewOwnerUserId: member.Id,
+				}
+
+				roomId := page.room.Id
+
+				runAsync(app, nav, pageContext, "Transferring ownership...", func(ctx context.Context) chat.BroChatClientResult {
+					return page.chatextClient.TransferRoomOwnership(ctx, accessToken, roomId, request)
+				}, func(result chat.BroChatClientResult) {
+					if page.alertResultError(nav, result, "Ownership could not be transferred.", transferOwnership) {
+						return
+					}
+
+					nav.Toast(fmt.Sprintf("%s is now the owner of '%s'.", member.Username, page.room.Name))
+					nav.Back()
+				})
+			}
+
+			nav.Confirm(ROOM_ADMIN_PAGE_CONFIRM, fmt.Sprintf("Make %s the owner of '%s'? You will no longer be able to administer the room.", member.Username, page.room.Name), transferOwnership)
+
+			return nil
+		}
+
+		return event
+	})
+
+	page.tvInstructions.SetTextAlign(tview.AlignCenter)
+
+	grid := tview.NewGrid()
+	grid.SetRows(2, 11, 0, 1, 1, 2)
+	grid.SetColumns(0, 76, 0)
+
+	grid.AddItem(page.form, 1, 1, 1, 1, 0, 0, true)
+	grid.AddItem(page.table, 2, 1, 1, 1, 0, 0, false)
+	grid.AddItem(page.tvInstructions, 4, 1, 1, 1, 0, 0, false)
+
+	themes := appContext.GetThemeManager()
+
+	themeBackground(themes, grid)
+	themeForm(themes, page.form)
+	themeTable(themes, page.table)
+	themeTextView(themes, page.tvInstructions, instructionsTextColor)
+
+	nav.Register(ROOM_ADMIN_PAGE, grid, true, false,
+		func(param PageParameters) {
+			pageContext, cancel = appContext.GenerateUserSessionBoundContextWithCancel()
+			page.onPageLoad(param, app, appContext, nav, pageContext)
+		},
+		func() {
+			cancel()
+			page.onPageClose()
+		})
+}
+
+// onPageLoad is called when the room admin page is navigated to
+func (page *RoomAdminPage) onPageLoad(param PageParameters,
+	app *tview.Application,
+	appContext *state.ApplicationContext,
+	nav *PageNavigator,
+	pageContext context.Context) {
+
+	adminParams, ok := param.(RoomAdminPageParameters)
+
+	if !ok {
+		slog.Warn("Room admin page loaded without room admin params. Returning to the previous page.")
+		nav.AlertWithDoneFunc(ROOM_ADMIN_PAGE_ALERT_ERR, "Application State Error - Could not get room admin params.", func(buttonIndex int, buttonLabel string) {
+			nav.Back()
+		})
+		return
+	}
+
+	page.room = adminParams.room
+
+	if page.room.Owner.Id != appContext.GetBrochatUser().Id {
+		nav.AlertWithDoneFunc(ROOM_ADMIN_PAGE_ALERT_ERR, "Only the owner of a room can administer it.", func(buttonIndex int, buttonLabel string) {
+			nav.Back()
+		})
+		return
+	}
+
+	nameInput, ok := page.form.GetFormItemByLabel("Room Name").(*tview.InputField)
+
+	if ok {
+		nameInput.SetText(page.room.Name)
+	}
+
+	membershipModelDropdown, ok := page.form.GetFormItemByLabel("Membership Model").(*tview.DropDown)
+
+	if ok {
+		if page.room.MembershipModel == chat.FRIENDS_MEMBERSHIP_MODEL {
+			membershipModelDropdown.SetCurrentOption(1)
+		} else {
+			membershipModelDropdown.SetCurrentOption(0)
+		}
+	}
+
+	page.form.SetFocus(0)
+
+	page.loadMembers(app, appContext, nav, pageContext)
+
+	roomId := page.room.Id
+	channelId := page.room.ChannelId
+
+	// Keep the member list current while the page is open. The members are retrieved on this goroutine without the loading indicator so that updates do not interrupt the user.
+	go func() {
+		subscriptionId, channelUpdateChannel := page.feedClient.SubscribeToChannelUpdates()
+		defer page.feedClient.UnsubscribeFromChannelUpdates(subscriptionId)
+
+		for {
+			select {
+			case <-pageContext.Done():
+				return
+			case eventChannelId := <-channelUpdateChannel:
+				if eventChannelId != channelId {
+					continue
+				}
+
+				accessToken, ok := appContext.GetAccessToken()
+
+				if !ok {
+					continue
+				}
+
+				getChannelResult := page.brochatClient.GetChannel(accessToken, channelId)
+
+				if err := getChannelResult.Err(); err != nil {
+					slog.Error("An error occurred while refreshing the members of a room", "room_id", roomId, "err", err)
+					continue
+				}
+
+				app.QueueUpdateDraw(func() {
+					if pageContext.Err() == nil {
+						page.setMembers(appContext, getChannelResult.Content.Users)
+					}
+				})
+			}
+		}
+	}()
+}
+
+// onPageClose is called when the room admin page is navigated away from
+func (page *RoomAdminPage) onPageClose() {
+	page.room = chat.Room{}
+	page.inviteMode = false
+	page.members = make(map[int]chat.UserInfo, 0)
+	page.inviteCandidates = make(map[int]chat.UserRelationship, 0)
+	page.invitedUserIds = make(map[string]struct{}, 0)
+	page.table.Clear()
+}
+
+// saveSettings validates the settings form and updates the room
+func (page *RoomAdminPage) saveSettings(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator, pageContext context.Context) {
+	accessToken, ok := appContext.GetAccessToken()
+
+	if !ok {
+		slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+		nav.RedirectToLogin()
+		return
+	}
+
+	nameInput, ok := page.form.GetFormItemByLabel("Room Name").(*tview.InputField)
+
+	if !ok {
+		nav.Alert(ROOM_ADMIN_PAGE_ALERT_ERR, "Room Update Failed - Room Name Field Unavailable")
+		return
+	}
+
+	name := nameInput.GetText()
+
+	valResult := strval.ValidateStringWithName(name, "Room Name",
+		strval.MustNotBeEmpty(),
+		strval.MustHaveMinLengthOf(3),
+		strval.MustHaveMaxLengthOf(32),
+	)
+
+	if !valResult.Valid {
+		nav.AlertErrors(ROOM_ADMIN_PAGE_ALERT_ERR, "Room Update Failed - Form Validation Error", valResult.Messages)
+		return
+	}
+
+	membershipModelDropdown, ok := page.form.GetFormItemByLabel("Membership Model").(*tview.DropDown)
+
+	if !ok {
+		nav.Alert(ROOM_ADMIN_PAGE_ALERT_ERR, "Room Update Failed - Membership Model Field Unavailable")
+		return
+	}
+
+	optIndex, optstr := membershipModelDropdown.GetCurrentOption()
+
+	if optIndex < 0 || optstr == "" {
+		nav.Alert(ROOM_ADMIN_PAGE_ALERT_ERR, "Room Update Failed - Membership Model Selection Invalid")
+		return
+	}
+
+	request := chatext.UpdateRoomRequest{
+		Name:            name,
+		MembershipModel: chat.RoomMembershipModel(optstr),
+	}
+
+	roomId := page.room.Id
+
+	runAsync(app, nav, pageContext, "Updating room...", func(ctx context.Context) chat.BroChatClientContentResult[chat.Room] {
+		return page.chatextClient.UpdateRoom(ctx, accessToken, roomId, request)
+	}, func(result chat.BroChatClientContentResult[chat.Room]) {
+		if page.alertResultError(nav, result.BroChatClientResult, "Room Update Failed", func() {
+			page.saveSettings(app, appContext, nav, pageContext)
+		}) {
+			return
+		}
+
+		page.room.Name = name
+		page.room.MembershipModel = chat.RoomMembershipModel(optstr)
+
+		nav.Toast(fmt.Sprintf("The room '%s' has been updated.", name))
+	})
+}
+
+// loadMembers retrieves the room's channel and shows its members in the table
+func (page *RoomAdminPage) loadMembers(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator, pageContext context.Context) {
+	accessToken, ok := appContext.GetAccessToken()
+
+	if !ok {
+		slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+		nav.RedirectToLogin()
+		return
+	}
+
+	channelId := page.room.ChannelId
+
+	runAsync(app, nav, pageContext, "Loading members...", func(ctx context.Context) chat.BroChatClientContentResult[chat.Channel] {
+		return page.brochatClient.GetChannel(accessToken, channelId)
+	}, func(getChannelResult chat.BroChatClientContentResult[chat.Channel]) {
+		if page.alertResultError(nav, getChannelResult.BroChatClientResult, "The room members could not be retrieved.", func() {
+			page.loadMembers(app, appContext, nav, pageContext)
+		}) {
+			return
+		}
+
+		page.setMembers(appContext, getChannelResult.Content.Users)
+	})
+}
+
+// setMembers replaces the room members and redraws the table
+func (page *RoomAdminPage) setMembers(appContext *state.ApplicationContext, users []chat.UserInfo) {
+	page.members = make(map[int]chat.UserInfo, 0)
+
+	for i, u := range users {
+		page.members[i+1] = u
+	}
+
+	if !page.inviteMode {
+		page.showMembers(appContext)
+	} else {
+		page.showInviteCandidates(appContext)
+	}
+}
+
+// showMembers populates the table with the current members of the room
+func (page *RoomAdminPage) showMembers(appContext *state.ApplicationContext) {
+	thm := appContext.GetTheme()
+
+	page.inviteMode = false
+	page.table.Clear()
+	page.table.SetTitle(fmt.Sprintf(" Members (%d) ", len(page.members)))
+
+	page.setTableHeader(thm, "Username", "Role")
+
+	for row := 1; row <= len(page.members); row++ {
+		member := page.members[row]
+
+		role := "Member"
+
+		if member.Id == page.room.Owner.Id {
+			role = "Owner"
+		}
+
+		page.table.SetCell(row, 0, tview.NewTableCell(member.Username).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignCenter))
+		page.table.SetCell(row, 1, tview.NewTableCell(role).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignRight))
+	}
+
+	page.tvInstructions.SetText(roomAdminMembersInstructions(page.keys))
+}
+
+// showInviteCandidates populates the table with the user's friends who are not yet members of the room and have not been invited while the page is open
+func (page *RoomAdminPage) showInviteCandidates(appContext *state.ApplicationContext) {
+	thm := appContext.GetTheme()
+
+	page.inviteMode = true
+	page.table.Clear()
+	page.table.SetTitle(" Invite Friends ")
+	page.inviteCandidates = make(map[int]chat.UserRelationship, 0)
+
+	page.setTableHeader(thm, "Username", "Last Active")
+
+	row := 1
+
+	for _, rel := range appContext.GetBrochatUser().Relationships {
+		if rel.Type != chat.RELATIONSHIP_TYPE_FRIEND {
+			continue
+		}
+
+		isMember := false
+
+		for _, member := range page.members {
+			if member.Id == rel.UserId {
+				isMember = true
+				break
+			}
+		}
+
+		if isMember {
+			continue
+		}
+
+		if _, invited := page.invitedUserIds[rel.UserId]; invited {
+			continue
+		}
+
+		page.table.SetCell(row, 0, tview.NewTableCell(rel.Username).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignCenter))
+		page.table.SetCell(row, 1, tview.NewTableCell(rel.LastOnlineUtc.Local().Format("Jan 2, 2006")).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignRight))
+
+		page.inviteCandidates[row] = rel
+		row++
+	}
+
+	page.tvInstructions.SetText(roomAdminInviteInstructions(page.keys))
+}
+
+// setTableHeader sets the header row of the table
+func (page *RoomAdminPage) setTableHeader(thm theme.Theme, first, second string) {
+	page.table.SetCell(0, 0, tview.NewTableCell(first).
+		SetTextColor(thm.ForgroundColor).
+		SetAlign(tview.AlignCenter).
+		SetExpansion(1).
+		SetSelectable(false).
+		SetAttributes(tcell.AttrBold|tcell.AttrUnderline))
+
+	page.table.SetCell(0, 1, tview.NewTableCell(second).
+		SetTextColor(thm.ForgroundColor).
+		SetAlign(tview.AlignRight).
+		SetSelectable(false).
+		SetAttributes(tcell.AttrBold|tcell.AttrUnderline))
+}
+
+// alertResultError alerts the user if the result is an error. Returns true if the result was an error.
+// A forbidden error means the user is no longer allowed to administer the room, so they are returned to the room list.
+// Errors which may succeed if tried again offer to call retry.
+func (page *RoomAdminPage) alertResultError(nav *PageNavigator, result chat.BroChatClientResult, failure string, retry func()) bool {
+	err := apperr.FromChatResult(result)
+
+	if err == nil {
+		return false
+	}
+
+	if err.Kind == apperr.KIND_FORBIDDEN {
+		nav.AlertWithDoneFunc(ROOM_ADMIN_PAGE_ALERT_ERR, "You do not have permission to administer this room.", func(buttonIndex int, buttonLabel string) {
+			nav.Back()
+		})
+
+		return true
+	}
+
+	nav.AlertError(ROOM_ADMIN_PAGE_ALERT_ERR, failure, err, retry)
+	return true
+}
@@ -1,10 +1,12 @@
 package ui
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"log/slog"
 
 	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/apperr"
+	"github.com/dmars8047/broterm/internal/keymap"
 	"github.com/dmars8047/broterm/internal/state"
 	"github.com/dmars8047/strval"
 	"github.com/gdamore/tcell/v2"
@@ -14,29 +16,28 @@ import (
 const ROOM_EDITOR_PAGE PageSlug = "room_editor"
 
 const (
-	ROOM_EDITOR_PAGE_ALERT_INFO = "home:roomeditor:alert:info"
-	ROOM_EDITOR_PAGE_ALERT_ERR  = "home:roomeditor:alert:err"
-	ROOM_EDITOR_PAGE_CONFIRM    = "home:roomeditor:confirm"
+	ROOM_EDITOR_PAGE_ALERT_ERR = "home:roomeditor:alert:err"
+	ROOM_EDITOR_PAGE_CONFIRM   = "home:roomeditor:confirm"
 )
 
 // RoomEditorPage is the room editor page
 type RoomEditorPage struct {
-	brochatClient    *chat.BroChatClient
-	form             *tview.Form
-	currentThemeCode string
+	brochatClient *chat.BroChatClient
+	form          *tview.Form
 }
 
 // NewRoomEditorPage creates a new room editor page
 func NewRoomEditorPage(brochatClient *chat.BroChatClient) *RoomEditorPage {
 	return &RoomEditorPage{
-		brochatClient:    brochatClient,
-		form:             tview.NewForm(),
-		currentThemeCode: "NOT_SET",
+		brochatClient: brochatClient,
+		form:          tview.NewForm(),
 	}
 }
 
 // Setup sets up the room editor page and registers it with the page navigator
 func (page *RoomEditorPage) Setup(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	keys := appContext.GetKeymap()
+
 	grid := tview.NewGrid()
 	grid.SetRows(4, 0, 1, 3, 4)
 	grid.SetColumns(0, 70, 0)
@@ -49,19 +50,22 @@ func (page *RoomEditorPage) Setup(app *tview.Application, appContext *state.Appl
 	page.form.AddInputField("Room Name", "", 0, nil, nil)
 	page.form.AddDropDown("Membership Model", []string{string(chat.PUBLIC_MEMBERSHIP_MODEL), string(chat.FRIENDS_MEMBERSHIP_MODEL)}, 0, nil)
 
-	page.form.AddButton("Submit", func() {
+	var submit func()
+
+	submit = func() {
 		accessToken, ok := appContext.GetAccessToken()
 
 		if !ok {
-			log.Printf("Valid user authentication information not found. Redirecting to login page.")
-			nav.NavigateTo(LOGIN_PAGE, nil)
+			slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+			nav.RedirectToLogin()
 			return
 		}
 
 		nameInput, ok := page.form.GetFormItemByLabel("Room Name").(*tview.InputField)
 
 		if !ok {
-			panic("room name input form access failure")
+			nav.Alert(ROOM_EDITOR_PAGE_ALERT_ERR, "Room Creation Failed - Room Name Field Unavailable")
+			return
 		}
 
 		name := nameInput.GetText()
@@ -80,7 +84,8 @@ func (page *RoomEditorPage) Setup(app *tview.Application, appContext *state.Appl
 		membershipModelDropdown, ok := page.form.GetFormItemByLabel("Membership Model").(*tview.DropDown)
 
 		if !ok {
-			panic("membership model dropdown form access failure")
+			nav.Alert(ROOM_EDITOR_PAGE_ALERT_ERR, "Room Creation Failed - Membership Model Field Unavailable")
+			return
 		}
 
 		optIndex, optstr := membershipModelDropdown.GetCurrentOption()
@@ -95,37 +100,29 @@ func (page *RoomEditorPage) Setup(app *tview.Application, appContext *state.Appl
 			MembershipModel: optstr,
 		}
 
-		createRoomResult := page.brochatClient.CreateRoom(accessToken, request)
-
-		createRoomErr := createRoomResult.Err()
-
-		if createRoomErr != nil {
-			if len(createRoomResult.ErrorDetails) > 0 {
-				nav.Alert(ROOM_EDITOR_PAGE_ALERT_ERR, createRoomResult.ErrorDetails[0])
+		runSessionAsync(app, appContext, nav, "Creating room...", func(ctx context.Context) chat.BroChatClientContentResult[chat.Room] {
+			return page.brochatClient.CreateRoom(accessToken, request)
+		}, func(createRoomResult chat.BroChatClientContentResult[chat.Room]) {
+			if err := apperr.FromChatResult(createRoomResult.BroChatClientResult); err != nil {
+				nav.AlertError(ROOM_EDITOR_PAGE_ALERT_ERR, "Room Creation Failed", err, submit)
 				return
 			}
 
-			if createRoomResult.ResponseCode == chat.BROCHAT_RESPONSE_CODE_FORBIDDEN_ERROR {
-				nav.Alert(ROOM_EDITOR_PAGE_ALERT_ERR, FORBIDDEN_OPERATION_ERROR_MESSAGE)
-				return
-			}
-
-			nav.Alert(ROOM_EDITOR_PAGE_ALERT_ERR, fmt.Sprintf("An error occurred while creating user room: %s", createRoomErr.Error()))
-			return
-		}
-
-		nav.AlertWithDoneFunc(ROOM_EDITOR_PAGE_ALERT_INFO, "Room creation successful!", func(buttonIndex int, buttonLabel string) {
-			nav.NavigateTo(ROOM_LIST_PAGE, nil)
+			nav.Toast("Room creation successful!")
+			nav.Back()
 		})
-	})
+	}
+
+	page.form.AddButton("Submit", submit)
 
 	page.form.AddButton("Back", func() {
-		nav.NavigateTo(ROOM_LIST_PAGE, nil)
+		nav.Back()
 	})
 
 	page.form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyEscape {
-			nav.NavigateTo(ROOM_LIST_PAGE, nil)
+		if keys.Is(event, keymap.ACTION_BACK) {
+			nav.Back()
+			return nil
 		}
 
 		return event
@@ -137,28 +134,13 @@ func (page *RoomEditorPage) Setup(app *tview.Application, appContext *state.Appl
 	grid.AddItem(page.form, 1, 1, 1, 1, 0, 0, true)
 	grid.AddItem(tvInstructions, 3, 1, 1, 1, 0, 0, false)
 
-	applyTheme := func() {
-		theme := appContext.GetTheme()
-
-		if page.currentThemeCode != theme.Code {
-			page.currentThemeCode = theme.Code
-			grid.SetBackgroundColor(theme.BackgroundColor)
-			page.form.SetBackgroundColor(theme.AccentColor)
-			page.form.SetFieldBackgroundColor(theme.AccentColorTwo)
-			page.form.SetLabelColor(theme.HighlightColor)
-			page.form.SetButtonStyle(theme.ButtonStyle)
-			page.form.SetButtonActivatedStyle(theme.ActivatedButtonStyle)
-			page.form.SetBorderColor(theme.BorderColor)
-			page.form.SetTitleColor(theme.TitleColor)
-			tvInstructions.SetBackgroundColor(theme.BackgroundColor)
-			tvInstructions.SetTextColor(theme.InfoColor)
-		}
-	}
+	themes := appContext.GetThemeManager()
 
-	applyTheme()
+	themeBackground(themes, grid)
+	themeForm(themes, page.form)
+	themeTextView(themes, tvInstructions, instructionsTextColor)
 
-	nav.Register(ROOM_EDITOR_PAGE, grid, true, false, func(_ interface{}) {
-		applyTheme()
+	nav.Register(ROOM_EDITOR_PAGE, grid, true, false, func(_ PageParameters) {
 		page.onPageLoad()
 	}, func() {
 		page.onPageClose()
@@ -172,19 +154,15 @@ func (page *RoomEditorPage) onPageLoad() {
 
 // onPageClose is called when the page is navigated away from
 func (page *RoomEditorPage) onPageClose() {
-	roomNameInput, ok := page.form.GetFormItemByLabel("Room Name").(*tview.InputField)
-
-	if !ok {
-		panic("room name input form clear failure")
+	if roomNameInput, ok := page.form.GetFormItemByLabel("Room Name").(*tview.InputField); ok {
+		roomNameInput.SetText("")
+	} else {
+		slog.Error("Room name input form clear failure on close for room editor page")
 	}
 
-	roomNameInput.SetText("")
-
-	membModelDropdown, ok := page.form.GetFormItemByLabel("Membership Model").(*tview.DropDown)
-
-	if !ok {
-		panic("membership model dropdown form clear failure")
+	if membModelDropdown, ok := page.form.GetFormItemByLabel("Membership Model").(*tview.DropDown); ok {
+		membModelDropdown.SetCurrentOption(0)
+	} else {
+		slog.Error("Membership model dropdown form clear failure on close for room editor page")
 	}
-
-	membModelDropdown.SetCurrentOption(0)
 }
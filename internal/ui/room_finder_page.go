@@ -1,11 +1,19 @@
 package ui
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/apperr"
+	"github.com/dmars8047/broterm/internal/keymap"
 	"github.com/dmars8047/broterm/internal/state"
+	"github.com/dmars8047/broterm/internal/theme"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -13,34 +21,118 @@ import (
 const ROOM_FINDER_PAGE PageSlug = "room_finder"
 
 const (
-	ROOM_FINDER_PAGE_ALERT_INFO = "home:roomfinder:alert:info"
-	ROOM_FINDER_PAGE_ALERT_ERR  = "home:roomfinder:alert:err"
-	ROOM_FINDER_PAGE_CONFIRM    = "home:roomfinder:confirm"
+	ROOM_FINDER_PAGE_ALERT_ERR = "home:roomfinder:alert:err"
+	ROOM_FINDER_PAGE_CONFIRM   = "home:roomfinder:confirm"
 )
 
+const (
+	// roomFinderPageSize is the number of rooms shown on each page of results
+	roomFinderPageSize = 12
+	// roomFinderMemberCountWorkers is the number of member counts retrieved concurrently
+	roomFinderMemberCountWorkers = 4
+)
+
+// roomFinderInstructions returns the instructions for the room finder using the keys bound in the keymap
+func roomFinderInstructions(keys *keymap.Keymap) string {
+	return strings.Join([]string{
+		"(enter) Join room",
+		keys.Hint(keymap.ACTION_SEARCH, "Search"),
+		keys.Hint(keymap.ACTION_SORT_ROOMS, "Sort"),
+		keys.Hint(keymap.ACTION_NEXT_PAGE, "Next Page"),
+		keys.Hint(keymap.ACTION_PREVIOUS_PAGE, "Previous Page"),
+		keys.Hint(keymap.ACTION_BACK, "Quit"),
+	}, " - ")
+}
+
+// roomSortOrder is the order rooms are listed in on the room finder page
+type roomSortOrder int
+
+const (
+	ROOM_SORT_BY_NAME roomSortOrder = iota
+	ROOM_SORT_BY_OWNER
+)
+
+// roomSortOrderCount is the number of sort orders the sort key cycles through
+const roomSortOrderCount = 2
+
+// String returns the display name of the sort order
+func (order roomSortOrder) String() string {
+	switch order {
+	case ROOM_SORT_BY_OWNER:
+		return "Owner"
+	default:
+		return "Name"
+	}
+}
+
 // RoomFinderPage is the room finder page
 type RoomFinderPage struct {
-	brochatClient    *chat.BroChatClient
-	table            *tview.Table
-	publicRooms      map[int]chat.Room
-	currentThemeCode string
+	brochatClient *chat.BroChatClient
+	table         *tview.Table
+	searchInput   *tview.InputField
+	tvStatus      *tview.TextView
+	allRooms      []chat.Room
+	publicRooms   map[int]chat.Room
+	memberCounts  map[string]int
+	countsMu      sync.Mutex
+	sortOrder     roomSortOrder
+	pageIndex     int
 }
 
 // NewRoomFinderPage creates a new room finder page
 func NewRoomFinderPage(brochatClient *chat.BroChatClient) *RoomFinderPage {
 	return &RoomFinderPage{
-		brochatClient:    brochatClient,
-		table:            tview.NewTable(),
-		publicRooms:      make(map[int]chat.Room, 0),
-		currentThemeCode: "NOT_SET",
+		brochatClient: brochatClient,
+		table:         tview.NewTable(),
+		searchInput:   tview.NewInputField(),
+		tvStatus:      tview.NewTextView(),
+		allRooms:      make([]chat.Room, 0),
+		publicRooms:   make(map[int]chat.Room, 0),
+		memberCounts:  make(map[string]int, 0),
 	}
 }
 
 // Setup sets up the room finder page and registers it with the page navigator
 func (page *RoomFinderPage) Setup(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	keys := appContext.GetKeymap()
+
 	tvHeader := tview.NewTextView().SetTextAlign(tview.AlignCenter)
 	tvHeader.SetText("Find Rooms")
 
+	var pageContext context.Context
+	var cancel context.CancelFunc
+
+	// refresh redraws the table and retrieves the member counts of the rooms shown
+	refresh := func() {
+		shown := page.populateTable(appContext)
+		page.loadMemberCounts(app, appContext, pageContext, shown)
+	}
+
+	page.searchInput.SetLabel("Search: ")
+
+	page.searchInput.SetChangedFunc(func(_ string) {
+		page.pageIndex = 0
+		refresh()
+	})
+
+	page.searchInput.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEscape:
+			nav.Back()
+		case tcell.KeyEnter, tcell.KeyTab, tcell.KeyBacktab:
+			app.SetFocus(page.table)
+		}
+	})
+
+	page.searchInput.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyDown {
+			app.SetFocus(page.table)
+			return nil
+		}
+
+		return event
+	})
+
 	page.table.SetBorders(true)
 	page.table.SetFixed(1, 1)
 	page.table.SetSelectable(true, false)
@@ -52,51 +144,56 @@ func (page *RoomFinderPage) Setup(app *tview.Application, appContext *state.Appl
 			return
 		}
 
+		if page.isMember(appContext.GetBrochatUser(), room) {
+			nav.NavigateTo(CHAT_PAGE, ChatPageParameters{
+				channel_id: room.ChannelId,
+				title:      room.Name,
+			})
+			return
+		}
+
 		accessToken, ok := appContext.GetAccessToken()
 
 		if !ok {
-			log.Printf("Valid user authentication information not found. Redirecting to login page.")
-			nav.NavigateTo(LOGIN_PAGE, nil)
+			slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+			nav.RedirectToLogin()
 			return
 		}
 
-		nav.Confirm(ROOM_FINDER_PAGE_CONFIRM, fmt.Sprintf("Join %s?", room.Name), func() {
-			joinRoomResult := page.brochatClient.JoinRoom(accessToken, room.Id)
+		var joinRoom func()
 
-			joinRoomErr := joinRoomResult.Err()
-
-			if joinRoomErr != nil {
-				if len(joinRoomResult.ErrorDetails) > 0 {
-					nav.Alert(ROOM_FINDER_PAGE_ALERT_INFO, joinRoomResult.ErrorDetails[0])
+		joinRoom = func() {
+			runAsync(app, nav, pageContext, fmt.Sprintf("Joining '%s'...", room.Name), func(ctx context.Context) chat.BroChatClientResult {
+				return page.brochatClient.JoinRoom(accessToken, room.Id)
+			}, func(joinRoomResult chat.BroChatClientResult) {
+				if err := apperr.FromChatResult(joinRoomResult); err != nil {
+					nav.AlertError(ROOM_FINDER_PAGE_ALERT_ERR, fmt.Sprintf("The room '%s' could not be joined.", room.Name), err, joinRoom)
 					return
 				}
 
-				if joinRoomResult.ResponseCode == chat.BROCHAT_RESPONSE_CODE_FORBIDDEN_ERROR {
-					nav.Alert(ROOM_FINDER_PAGE_ALERT_INFO, FORBIDDEN_OPERATION_ERROR_MESSAGE)
-					return
-				}
-
-				nav.AlertFatal(app, ROOM_FINDER_PAGE_ALERT_ERR, fmt.Sprintf("An error occurred while joining room: %s", joinRoomErr.Error()))
-				return
-			}
-
-			nav.AlertWithDoneFunc(ROOM_FINDER_PAGE_ALERT_INFO, fmt.Sprintf("You have successfuly joined the room '%s'.", room.Name), func(buttonIndex int, buttonLabel string) {
-				nav.Pages.HidePage(ROOM_FINDER_PAGE_ALERT_INFO).RemovePage(ROOM_FINDER_PAGE_ALERT_INFO)
+				nav.Toast(fmt.Sprintf("You have successfully joined the room '%s'.", room.Name))
 				nav.NavigateTo(CHAT_PAGE, ChatPageParameters{
 					channel_id: room.ChannelId,
 					title:      room.Name,
-					returnPage: ROOM_LIST_PAGE,
 				})
 			})
-		})
+		}
+
+		nav.Confirm(ROOM_FINDER_PAGE_CONFIRM, fmt.Sprintf("Join %s?", room.Name), joinRoom)
+	})
+
+	vimNavigation := vimTableNavigation(app, appContext, nav, page.table, 2, func() {
+		app.SetFocus(page.searchInput)
 	})
 
 	page.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyEscape {
-			nav.NavigateTo(ROOM_LIST_PAGE, nil)
-			page.publicRooms = make(map[int]chat.Room, 0)
-			page.table.Clear()
-		} else if event.Key() == tcell.KeyTab {
+		if vimNavigation(event) {
+			return nil
+		}
+
+		if keys.Is(event, keymap.ACTION_BACK) {
+			nav.Back()
+		} else if keys.Is(event, keymap.ACTION_NEXT) {
 			// Change the selected row to the next row
 			row, _ := page.table.GetSelection()
 			if row+1 >= page.table.GetRowCount() {
@@ -106,7 +203,7 @@ func (page *RoomFinderPage) Setup(app *tview.Application, appContext *state.Appl
 			}
 
 			page.table.Select(row, 0)
-		} else if event.Key() == tcell.KeyBacktab {
+		} else if keys.Is(event, keymap.ACTION_PREVIOUS) {
 			// Change the selected row to the previous row
 			row, _ := page.table.GetSelection()
 
@@ -117,101 +214,331 @@ func (page *RoomFinderPage) Setup(app *tview.Application, appContext *state.Appl
 			}
 
 			page.table.Select(row, 0)
+		} else if action, ok := keys.Match(event, keymap.ACTION_SEARCH, keymap.ACTION_SORT_ROOMS, keymap.ACTION_NEXT_PAGE, keymap.ACTION_PREVIOUS_PAGE); ok {
+			switch action {
+			case keymap.ACTION_SEARCH:
+				app.SetFocus(page.searchInput)
+				return nil
+			case keymap.ACTION_SORT_ROOMS:
+				page.sortOrder = (page.sortOrder + 1) % roomSortOrderCount
+				page.pageIndex = 0
+				refresh()
+				return nil
+			case keymap.ACTION_NEXT_PAGE:
+				if page.pageIndex+1 < page.pageCount(len(page.filteredRooms(appContext.GetBrochatUser()))) {
+					page.pageIndex++
+					refresh()
+				}
+				return nil
+			case keymap.ACTION_PREVIOUS_PAGE:
+				if page.pageIndex > 0 {
+					page.pageIndex--
+					refresh()
+				}
+				return nil
+			}
 		}
 
 		return event
 	})
 
 	tvInstructions := tview.NewTextView().SetTextAlign(tview.AlignCenter)
-	tvInstructions.SetText("(enter) Join room - (esc) Quit")
+	tvInstructions.SetText(roomFinderInstructions(keys))
+
+	page.tvStatus.SetTextAlign(tview.AlignCenter)
 
 	grid := tview.NewGrid()
 
-	grid.SetRows(2, 1, 1, 0, 1, 1, 2)
+	grid.SetRows(2, 1, 1, 1, 1, 0, 1, 1, 2, 2)
 	grid.SetColumns(0, 76, 0)
 
 	grid.AddItem(tvHeader, 1, 1, 1, 1, 0, 0, false)
-	grid.AddItem(page.table, 3, 1, 1, 1, 0, 0, true)
-	grid.AddItem(tvInstructions, 5, 1, 1, 1, 0, 0, false)
+	grid.AddItem(page.searchInput, 3, 1, 1, 1, 0, 0, false)
+	grid.AddItem(page.table, 5, 1, 1, 1, 0, 0, true)
+	grid.AddItem(page.tvStatus, 6, 1, 1, 1, 0, 0, false)
+	grid.AddItem(tvInstructions, 8, 1, 1, 1, 0, 0, false)
 
-	applyTheme := func() {
-		theme := appContext.GetTheme()
+	themes := appContext.GetThemeManager()
 
-		if page.currentThemeCode != theme.Code {
-			page.currentThemeCode = theme.Code
-			grid.SetBackgroundColor(theme.BackgroundColor)
-			page.table.SetBordersColor(theme.BorderColor)
-			page.table.SetBorderColor(theme.BorderColor)
-			page.table.SetTitleColor(theme.TitleColor)
-			page.table.SetBackgroundColor(theme.BackgroundColor)
-			page.table.SetSelectedStyle(theme.DropdownListSelectedStyle)
-			tvHeader.SetBackgroundColor(theme.BackgroundColor)
-			tvHeader.SetTextColor(theme.TitleColor)
-			tvInstructions.SetBackgroundColor(theme.BackgroundColor)
-			tvInstructions.SetTextColor(theme.InfoColor)
-		}
-	}
-
-	applyTheme()
+	themeBackground(themes, grid)
+	themeTextView(themes, tvHeader, headerTextColor)
+	themeTable(themes, page.table)
+	themeSearchInput(themes, page.searchInput)
+	themeTextView(themes, page.tvStatus, statusTextColor)
+	themeTextView(themes, tvInstructions, instructionsTextColor)
 
 	nav.Register(ROOM_FINDER_PAGE, grid, true, false,
-		func(_ interface{}) {
-			applyTheme()
-			page.onPageLoad(appContext, nav)
+		func(_ PageParameters) {
+			pageContext, cancel = appContext.GenerateUserSessionBoundContextWithCancel()
+			page.onPageLoad(app, appContext, nav, pageContext, refresh)
 		},
 		func() {
+			cancel()
 			page.onPageClose()
 		})
 }
 
 // onPageLoad is called when the room finder page is navigated to
-func (page *RoomFinderPage) onPageLoad(appContext *state.ApplicationContext, nav *PageNavigator) {
+func (page *RoomFinderPage) onPageLoad(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator, pageContext context.Context, refresh func()) {
 	accessToken, ok := appContext.GetAccessToken()
 
 	if !ok {
-		log.Printf("Valid user authentication information not found. Redirecting to login page.")
-		nav.NavigateTo(LOGIN_PAGE, nil)
+		slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+		nav.RedirectToLogin()
 		return
 	}
 
+	runAsync(app, nav, pageContext, "Loading rooms...", func(ctx context.Context) chat.BroChatClientContentResult[[]chat.Room] {
+		return page.brochatClient.GetRooms(accessToken)
+	}, func(getRoomsResult chat.BroChatClientContentResult[[]chat.Room]) {
+		if err := apperr.FromChatResult(getRoomsResult.BroChatClientResult); err != nil {
+			nav.AlertError(ROOM_FINDER_PAGE_ALERT_ERR, "The public rooms could not be retrieved.", err, func() {
+				page.onPageLoad(app, appContext, nav, pageContext, refresh)
+			})
+			return
+		}
+
+		page.allRooms = getRoomsResult.Content
+		refresh()
+		app.SetFocus(page.table)
+	})
+}
+
+// onPageClose is called when the room finder page is navigated away from
+func (page *RoomFinderPage) onPageClose() {
+	page.allRooms = make([]chat.Room, 0)
+	page.publicRooms = make(map[int]chat.Room, 0)
+	page.sortOrder = ROOM_SORT_BY_NAME
+	page.pageIndex = 0
+	page.searchInput.SetText("")
+	page.table.Clear()
+
+	page.countsMu.Lock()
+	page.memberCounts = make(map[string]int, 0)
+	page.countsMu.Unlock()
+}
+
+// filteredRooms returns the rooms which match the search text, in the current sort order
+func (page *RoomFinderPage) filteredRooms(brochatUser chat.User) []chat.Room {
+	search := strings.ToLower(strings.TrimSpace(page.searchInput.GetText()))
+
+	rooms := make([]chat.Room, 0, len(page.allRooms))
+
+	for _, room := range page.allRooms {
+		if search == "" ||
+			strings.Contains(strings.ToLower(room.Name), search) ||
+			strings.Contains(strings.ToLower(room.Owner.Username), search) {
+			rooms = append(rooms, room)
+		}
+	}
+
+	sort.SliceStable(rooms, func(i, j int) bool {
+		a, b := rooms[i], rooms[j]
+
+		if page.sortOrder == ROOM_SORT_BY_OWNER && !strings.EqualFold(a.Owner.Username, b.Owner.Username) {
+			return strings.ToLower(a.Owner.Username) < strings.ToLower(b.Owner.Username)
+		}
+
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+
+	return rooms
+}
+
+// pageCount returns the number of pages needed to show the given number of rooms
+func (page *RoomFinderPage) pageCount(roomCount int) int {
+	if roomCount == 0 {
+		return 1
+	}
+
+	return (roomCount + roomFinderPageSize - 1) / roomFinderPageSize
+}
+
+// isMember returns true if the user is already a member of the room
+func (page *RoomFinderPage) isMember(brochatUser chat.User, room chat.Room) bool {
+	for _, userRoom := range brochatUser.Rooms {
+		if userRoom.Id == room.Id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// populateTable shows the current page of rooms which match the search text. Returns the rooms shown.
+// The selected room stays selected if it is still shown, so redrawing the table once the member counts arrive does not move the selection.
+func (page *RoomFinderPage) populateTable(appContext *state.ApplicationContext) []chat.Room {
 	thm := appContext.GetTheme()
+	brochatUser := appContext.GetBrochatUser()
 
-	page.table.SetCell(0, 0, tview.NewTableCell("Name").
-		SetTextColor(thm.ForgroundColor).
-		SetAlign(tview.AlignCenter).
-		SetExpansion(1).
-		SetSelectable(false).
-		SetAttributes(tcell.AttrBold|tcell.AttrUnderline))
+	rooms := page.filteredRooms(brochatUser)
+	pageCount := page.pageCount(len(rooms))
 
-	page.table.SetCell(0, 1, tview.NewTableCell("Owner").
-		SetTextColor(thm.ForgroundColor).
-		SetAlign(tview.AlignCenter).
-		SetSelectable(false).
-		SetAttributes(tcell.AttrBold|tcell.AttrUnderline))
+	if page.pageIndex >= pageCount {
+		page.pageIndex = pageCount - 1
+	}
 
-	getRoomsResult := page.brochatClient.GetRooms(accessToken)
+	start := page.pageIndex * roomFinderPageSize
+	end := min(start+roomFinderPageSize, len(rooms))
 
-	err := getRoomsResult.Err()
+	selectedRoomId := ""
 
-	if err != nil {
-		nav.Alert(ROOM_FINDER_PAGE_ALERT_ERR, fmt.Sprintf("An error occurred while retrieving public rooms: %s", err.Error()))
-		return
+	if row, _ := page.table.GetSelection(); row > 0 {
+		if room, ok := page.publicRooms[row]; ok {
+			selectedRoomId = room.Id
+		}
+	}
+
+	page.table.Clear()
+	page.publicRooms = make(map[int]chat.Room, 0)
+	selectedRow := 0
+
+	page.setTableHeader(thm)
+
+	page.countsMu.Lock()
+	counts := make(map[string]int, len(page.memberCounts))
+
+	for id, count := range page.memberCounts {
+		counts[id] = count
 	}
 
-	rooms := getRoomsResult.Content
+	page.countsMu.Unlock()
 
-	for i, rel := range rooms {
+	for i, room := range rooms[start:end] {
 		row := i + 1
 
-		page.table.SetCell(row, 0, tview.NewTableCell(rel.Name).SetTextColor(tcell.ColorWhite).SetAlign(tview.AlignCenter))
-		page.table.SetCell(row, 1, tview.NewTableCell(rel.Owner.Username).SetTextColor(tcell.ColorGreen).SetAlign(tview.AlignCenter))
+		memberCount := "…"
+
+		if count, ok := counts[room.Id]; ok && count >= 0 {
+			memberCount = strconv.Itoa(count)
+		} else if ok {
+			memberCount = "?"
+		}
+
+		joined := ""
+		nameColor := thm.ForgroundColor
 
-		page.publicRooms[row] = rel
+		if page.isMember(brochatUser, room) {
+			joined = "Joined"
+			nameColor = thm.HighlightColor
+		}
+
+		page.table.SetCell(row, 0, tview.NewTableCell(room.Name).SetTextColor(nameColor).SetAlign(tview.AlignCenter))
+		page.table.SetCell(row, 1, tview.NewTableCell(room.Owner.Username).SetTextColor(thm.InfoColorTwo).SetAlign(tview.AlignCenter))
+		page.table.SetCell(row, 2, tview.NewTableCell(memberCount).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignCenter))
+		page.table.SetCell(row, 3, tview.NewTableCell(joined).SetTextColor(thm.HighlightColor).SetAlign(tview.AlignCenter))
+
+		page.publicRooms[row] = room
+
+		if room.Id == selectedRoomId {
+			selectedRow = row
+		}
 	}
+
+	if selectedRow > 0 {
+		page.table.Select(selectedRow, 0)
+	} else {
+		page.table.Select(1, 0)
+		page.table.ScrollToBeginning()
+	}
+
+	page.tvStatus.SetText(fmt.Sprintf("%d rooms - Page %d of %d - Sorted by %s", len(rooms), page.pageIndex+1, pageCount, page.sortOrder))
+
+	return rooms[start:end]
 }
 
-// onPageClose is called when the room finder page is navigated away from
-func (page *RoomFinderPage) onPageClose() {
-	page.publicRooms = make(map[int]chat.Room, 0)
-	page.table.Clear()
+// setTableHeader sets the header row of the room table
+func (page *RoomFinderPage) setTableHeader(thm theme.Theme) {
+	headers := []string{"Name", "Owner", "Members", ""}
+
+	for col, header := range headers {
+		cell := tview.NewTableCell(header).
+			SetTextColor(thm.ForgroundColor).
+			SetAlign(tview.AlignCenter).
+			SetSelectable(false).
+			SetAttributes(tcell.AttrBold | tcell.AttrUnderline)
+
+		if col == 0 {
+			cell.SetExpansion(1)
+		}
+
+		page.table.SetCell(0, col, cell)
+	}
+}
+
+// loadMemberCounts retrieves the member count of each of the rooms shown which has not yet been counted.
+// Only the rooms on the current page are counted, as each count is a separate request. The table is redrawn once all counts have been retrieved.
+func (page *RoomFinderPage) loadMemberCounts(app *tview.Application, appContext *state.ApplicationContext, pageContext context.Context, rooms []chat.Room) {
+	accessToken, ok := appContext.GetAccessToken()
+
+	if !ok {
+		return
+	}
+
+	page.countsMu.Lock()
+	pending := make([]chat.Room, 0, len(rooms))
+
+	for _, room := range rooms {
+		if _, ok := page.memberCounts[room.Id]; !ok {
+			pending = append(pending, room)
+		}
+	}
+
+	page.countsMu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	go func() {
+		var wg sync.WaitGroup
+		work := make(chan chat.Room)
+
+		for i := 0; i < roomFinderMemberCountWorkers; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				for room := range work {
+					result := page.brochatClient.GetChannel(accessToken, room.ChannelId)
+
+					if err := result.Err(); err != nil {
+						slog.Error("An error occurred while retrieving the member count of a room", "room_id", room.Id, "err", err)
+
+						// Record the failure so the count is not requested again while the page is open
+						page.countsMu.Lock()
+						page.memberCounts[room.Id] = -1
+						page.countsMu.Unlock()
+
+						continue
+					}
+
+					page.countsMu.Lock()
+					page.memberCounts[room.Id] = len(result.Content.Users)
+					page.countsMu.Unlock()
+				}
+			}()
+		}
+
+	dispatch:
+		for _, room := range pending {
+			select {
+			case <-pageContext.Done():
+				break dispatch
+			case work <- room:
+			}
+		}
+
+		close(work)
+		wg.Wait()
+
+		if pageContext.Err() != nil {
+			return
+		}
+
+		app.QueueUpdateDraw(func() {
+			page.populateTable(appContext)
+		})
+	}()
 }
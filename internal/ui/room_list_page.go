@@ -2,8 +2,16 @@ package ui
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
 
 	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/apperr"
+	"github.com/dmars8047/broterm/internal/chatext"
+	"github.com/dmars8047/broterm/internal/config"
+	"github.com/dmars8047/broterm/internal/keymap"
 	"github.com/dmars8047/broterm/internal/state"
 	"github.com/dmars8047/broterm/internal/theme"
 	"github.com/gdamore/tcell/v2"
@@ -15,27 +23,69 @@ const ROOM_LIST_PAGE PageSlug = "roomlist"
 const (
 	ROOM_LIST_PAGE_ALERT_INFO = "home:roomlist:alert:info"
 	ROOM_LIST_PAGE_ALERT_ERR  = "home:roomlist:alert:err"
+	ROOM_LIST_PAGE_CONFIRM    = "home:roomlist:confirm"
 )
 
+// roomListInstructions returns the instructions for the room list using the keys bound in the keymap
+func roomListInstructions(keys *keymap.Keymap) string {
+	return strings.Join([]string{
+		keys.Hint(keymap.ACTION_CREATE_ROOM, "Create a Room"),
+		keys.Hint(keymap.ACTION_FIND_ROOM, "Find a Room"),
+		keys.Hint(keymap.ACTION_ADMINISTER_ROOM, "Administer"),
+		keys.Hint(keymap.ACTION_BACK, "Quit"),
+	}, " - ") + "\n" + strings.Join([]string{
+		keys.Hint(keymap.ACTION_LEAVE_ROOM, "Leave"),
+		keys.Hint(keymap.ACTION_MUTE_ROOM, "Mute"),
+		keys.Hint(keymap.ACTION_PIN_ROOM, "Pin"),
+		keys.Hint(keymap.ACTION_ARCHIVE_ROOM, "Archive"),
+		keys.Hint(keymap.ACTION_VIEW_ARCHIVED, "View Archived"),
+	}, " - ")
+}
+
+// roomListArchivedInstructions returns the instructions for the archived room list using the keys bound in the keymap
+func roomListArchivedInstructions(keys *keymap.Keymap) string {
+	return strings.Join([]string{
+		keys.Hint(keymap.ACTION_ARCHIVE_ROOM, "Unarchive"),
+		keys.Hint(keymap.ACTION_LEAVE_ROOM, "Leave"),
+		keys.Hint(keymap.ACTION_VIEW_ARCHIVED, "View Rooms"),
+		keys.Hint(keymap.ACTION_BACK, "Quit"),
+	}, " - ")
+}
+
 type RoomListPage struct {
-	brochatClient    *chat.BroChatClient
-	feedClient       *state.FeedClient
-	table            *tview.Table
-	userRooms        map[int]chat.Room
-	currentThemeCode string
+	brochatClient  *chat.BroChatClient
+	chatextClient  *chatext.Client
+	feedClient     *state.FeedClient
+	table          *tview.Table
+	tvInstructions *tview.TextView
+	keys           *keymap.Keymap
+	userRooms      map[int]chat.Room
+	preferences    *config.RoomPreferences
+	showArchived   bool
 }
 
-func NewRoomListPage(brochatClient *chat.BroChatClient, feedClient *state.FeedClient) *RoomListPage {
+func NewRoomListPage(brochatClient *chat.BroChatClient, chatextClient *chatext.Client, feedClient *state.FeedClient) *RoomListPage {
+	preferences, err := config.LoadRoomPreferences()
+
+	if err != nil {
+		slog.Warn("Error loading room preferences", "err", err)
+	}
+
 	return &RoomListPage{
-		brochatClient:    brochatClient,
-		feedClient:       feedClient,
-		table:            tview.NewTable(),
-		userRooms:        make(map[int]chat.Room, 0),
-		currentThemeCode: "NOT_SET",
+		brochatClient:  brochatClient,
+		chatextClient:  chatextClient,
+		feedClient:     feedClient,
+		table:          tview.NewTable(),
+		tvInstructions: tview.NewTextView(),
+		userRooms:      make(map[int]chat.Room, 0),
+		preferences:    preferences,
 	}
 }
 
 func (page *RoomListPage) Setup(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
+	keys := appContext.GetKeymap()
+	page.keys = keys
+
 	tvHeader := tview.NewTextView().SetTextAlign(tview.AlignCenter)
 	tvHeader.SetText("Room List")
 
@@ -53,27 +103,76 @@ func (page *RoomListPage) Setup(app *tview.Application, appContext *state.Applic
 		nav.NavigateTo(CHAT_PAGE, ChatPageParameters{
 			channel_id: room.ChannelId,
 			title:      room.Name,
-			returnPage: ROOM_LIST_PAGE,
 		})
 	})
 
+	vimNavigation := vimTableNavigation(app, appContext, nav, page.table, 2, nil)
+
 	page.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyRune {
-			switch event.Rune() {
-			case 'f':
+		if vimNavigation(event) {
+			return nil
+		}
+
+		if action, ok := keys.Match(event,
+			keymap.ACTION_FIND_ROOM, keymap.ACTION_CREATE_ROOM, keymap.ACTION_ADMINISTER_ROOM, keymap.ACTION_LEAVE_ROOM,
+			keymap.ACTION_MUTE_ROOM, keymap.ACTION_PIN_ROOM, keymap.ACTION_ARCHIVE_ROOM, keymap.ACTION_VIEW_ARCHIVED); ok {
+			switch action {
+			case keymap.ACTION_FIND_ROOM:
 				nav.NavigateTo(ROOM_FINDER_PAGE, nil)
 				page.userRooms = make(map[int]chat.Room, 0)
 				page.table.Clear()
-			case 'n':
+			case keymap.ACTION_CREATE_ROOM:
 				nav.NavigateTo(ROOM_EDITOR_PAGE, nil)
 				page.userRooms = make(map[int]chat.Room, 0)
 				page.table.Clear()
+			case keymap.ACTION_ADMINISTER_ROOM:
+				row, _ := page.table.GetSelection()
+				room, ok := page.userRooms[row]
+
+				if !ok {
+					return event
+				}
+
+				if room.Owner.Id != appContext.GetBrochatUser().Id {
+					nav.Alert(ROOM_LIST_PAGE_ALERT_INFO, "Only the owner of a room can administer it.")
+					return event
+				}
+
+				nav.NavigateTo(ROOM_ADMIN_PAGE, RoomAdminPageParameters{
+					room: room,
+				})
+				page.userRooms = make(map[int]chat.Room, 0)
+				page.table.Clear()
+			case keymap.ACTION_LEAVE_ROOM:
+				row, _ := page.table.GetSelection()
+				room, ok := page.userRooms[row]
+
+				if !ok {
+					return event
+				}
+
+				page.leaveRoom(app, room, appContext, nav)
+				return nil
+			case keymap.ACTION_MUTE_ROOM:
+				page.togglePreference(page.preferences.ToggleMuted, appContext, nav)
+				return nil
+			case keymap.ACTION_PIN_ROOM:
+				page.togglePreference(page.preferences.TogglePinned, appContext, nav)
+				return nil
+			case keymap.ACTION_ARCHIVE_ROOM:
+				page.togglePreference(page.preferences.ToggleArchived, appContext, nav)
+				return nil
+			case keymap.ACTION_VIEW_ARCHIVED:
+				page.showArchived = !page.showArchived
+				page.table.Select(1, 0)
+				page.populateTable(appContext.GetBrochatUser(), appContext.GetTheme())
+				return nil
 			}
-		} else if event.Key() == tcell.KeyEscape {
-			nav.NavigateTo(HOME_PAGE, nil)
+		} else if keys.Is(event, keymap.ACTION_BACK) {
+			nav.Back()
 			page.userRooms = make(map[int]chat.Room, 0)
 			page.table.Clear()
-		} else if event.Key() == tcell.KeyTab {
+		} else if keys.Is(event, keymap.ACTION_NEXT) {
 			// Change the selected row to the next row
 			row, _ := page.table.GetSelection()
 			if row+1 >= page.table.GetRowCount() {
@@ -83,7 +182,7 @@ func (page *RoomListPage) Setup(app *tview.Application, appContext *state.Applic
 			}
 
 			page.table.Select(row, 0)
-		} else if event.Key() == tcell.KeyBacktab {
+		} else if keys.Is(event, keymap.ACTION_PREVIOUS) {
 			// Change the selected row to the previous row
 			row, _ := page.table.GetSelection()
 
@@ -99,12 +198,13 @@ func (page *RoomListPage) Setup(app *tview.Application, appContext *state.Applic
 		return event
 	})
 
-	tvInstructions := tview.NewTextView().SetTextAlign(tview.AlignCenter)
-	tvInstructions.SetText("(n) Create a Room - (f) Find a Room - (esc) Quit")
+	tvInstructions := page.tvInstructions
+	tvInstructions.SetTextAlign(tview.AlignCenter)
+	tvInstructions.SetText(roomListInstructions(keys))
 
 	grid := tview.NewGrid()
 
-	grid.SetRows(2, 1, 1, 0, 1, 1, 2)
+	grid.SetRows(2, 1, 1, 0, 1, 2, 2)
 	grid.SetColumns(0, 76, 0)
 
 	grid.AddItem(tvHeader, 1, 1, 1, 1, 0, 0, false)
@@ -114,29 +214,15 @@ func (page *RoomListPage) Setup(app *tview.Application, appContext *state.Applic
 	var pageContext context.Context
 	var cancel context.CancelFunc
 
-	applyTheme := func() {
-		theme := appContext.GetTheme()
-
-		if page.currentThemeCode != theme.Code {
-			page.currentThemeCode = theme.Code
-			grid.SetBackgroundColor(theme.BackgroundColor)
-			page.table.SetBordersColor(theme.BorderColor)
-			page.table.SetBorderColor(theme.BorderColor)
-			page.table.SetTitleColor(theme.TitleColor)
-			page.table.SetBackgroundColor(theme.BackgroundColor)
-			page.table.SetSelectedStyle(theme.DropdownListSelectedStyle)
-			tvHeader.SetBackgroundColor(theme.BackgroundColor)
-			tvHeader.SetTextColor(theme.TitleColor)
-			tvInstructions.SetBackgroundColor(theme.BackgroundColor)
-			tvInstructions.SetTextColor(theme.InfoColor)
-		}
-	}
+	themes := appContext.GetThemeManager()
 
-	applyTheme()
+	themeBackground(themes, grid)
+	themeTextView(themes, tvHeader, headerTextColor)
+	themeTable(themes, page.table)
+	themeTextView(themes, tvInstructions, instructionsTextColor)
 
 	nav.Register(ROOM_LIST_PAGE, grid, true, false,
-		func(_ interface{}) {
-			applyTheme()
+		func(_ PageParameters) {
 			pageContext, cancel = appContext.GenerateUserSessionBoundContextWithCancel()
 			page.onPageLoad(app, appContext, pageContext)
 		},
@@ -147,6 +233,7 @@ func (page *RoomListPage) Setup(app *tview.Application, appContext *state.Applic
 }
 
 func (page *RoomListPage) onPageLoad(app *tview.Application, appContext *state.ApplicationContext, pageContext context.Context) {
+	page.showArchived = false
 	page.populateTable(appContext.GetBrochatUser(), appContext.GetTheme())
 
 	// Create a go routine to monitor for changes to the user's rooms via a user profile update event
@@ -167,6 +254,68 @@ func (page *RoomListPage) onPageLoad(app *tview.Application, appContext *state.A
 			}
 		}
 	}()
+
+	// Create a go routine to highlight rooms which receive messages while the user is not in them via a chat notification
+	go func() {
+		subId, chatNotificationChannel := page.feedClient.SubscribeToChatNotifications()
+		defer page.feedClient.UnsubscribeFromChatNotifications(subId)
+
+		for {
+			select {
+			case <-pageContext.Done():
+				return
+			case notification, ok := <-chatNotificationChannel:
+				if !ok {
+					return
+				}
+
+				if !page.isListedChannel(appContext.GetBrochatUser(), notification.ChannelId) {
+					continue
+				}
+
+				app.QueueUpdateDraw(func() {
+					page.populateTable(appContext.GetBrochatUser(), appContext.GetTheme())
+				})
+			}
+		}
+	}()
+
+	// Create a go routine to monitor for membership changes to the listed rooms via a channel updated event
+	go func() {
+		subId, channelUpdatedChannel := page.feedClient.SubscribeToChannelUpdates()
+		defer page.feedClient.UnsubscribeFromChannelUpdates(subId)
+
+		for {
+			select {
+			case <-pageContext.Done():
+				return
+			case channelId := <-channelUpdatedChannel:
+				if !page.isListedChannel(appContext.GetBrochatUser(), channelId) {
+					continue
+				}
+
+				accessToken, ok := appContext.GetAccessToken()
+
+				if !ok {
+					continue
+				}
+
+				brochatUser := appContext.GetBrochatUser()
+				result := page.brochatClient.GetUser(accessToken, brochatUser.Id)
+
+				if err := result.Err(); err != nil {
+					slog.Error("An error occurred while refreshing the user's rooms after a channel update", "err", err)
+					continue
+				}
+
+				appContext.SetBrochatUser(result.Content)
+
+				app.QueueUpdateDraw(func() {
+					page.populateTable(appContext.GetBrochatUser(), appContext.GetTheme())
+				})
+			}
+		}
+	}()
 }
 
 func (page *RoomListPage) onPageClose() {
@@ -174,7 +323,107 @@ func (page *RoomListPage) onPageClose() {
 	page.table.Clear()
 }
 
+// isListedChannel returns true if the channel belongs to one of the user's rooms
+func (page *RoomListPage) isListedChannel(brochatUser chat.User, channelId string) bool {
+	for _, room := range brochatUser.Rooms {
+		if room.ChannelId == channelId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// leaveRoom confirms that the user wants to leave the room and then removes them from it.
+// The row is removed when the membership change is reflected back through the feed.
+func (page *RoomListPage) leaveRoom(app *tview.Application, room chat.Room, appContext *state.ApplicationContext, nav *PageNavigator) {
+	brochatUser := appContext.GetBrochatUser()
+
+	if room.Owner.Id == brochatUser.Id {
+		nav.Alert(ROOM_LIST_PAGE_ALERT_INFO, "The owner of a room cannot leave it. Transfer ownership or delete the room from the administration page.")
+		return
+	}
+
+	var leave func()
+
+	leave = func() {
+		accessToken, ok := appContext.GetAccessToken()
+
+		if !ok {
+			slog.Warn("Valid user authentication information not found. Redirecting to login page.")
+			nav.RedirectToLogin()
+			return
+		}
+
+		runSessionAsync(app, appContext, nav, fmt.Sprintf("Leaving '%s'...", room.Name), func(ctx context.Context) chat.BroChatClientResult {
+			return page.chatextClient.LeaveRoom(ctx, accessToken, room.Id)
+		}, func(result chat.BroChatClientResult) {
+			if err := apperr.FromChatResult(result); err != nil {
+				nav.AlertError(ROOM_LIST_PAGE_ALERT_ERR, fmt.Sprintf("The room '%s' could not be left.", room.Name), err, leave)
+				return
+			}
+
+			page.preferences.Forget(room.Id)
+
+			if err := page.preferences.Save(); err != nil {
+				slog.Error("Error saving room preferences", "err", err)
+			}
+		})
+	}
+
+	nav.Confirm(ROOM_LIST_PAGE_CONFIRM, fmt.Sprintf("Leave the room '%s'?", room.Name), leave)
+}
+
+// togglePreference toggles a client side preference for the selected room, saves the preferences and redraws the table
+func (page *RoomListPage) togglePreference(toggle func(roomId string) bool, appContext *state.ApplicationContext, nav *PageNavigator) {
+	row, _ := page.table.GetSelection()
+	room, ok := page.userRooms[row]
+
+	if !ok {
+		return
+	}
+
+	toggle(room.Id)
+
+	if err := page.preferences.Save(); err != nil {
+		slog.Error("Error saving room preferences", "err", err)
+		nav.Alert(ROOM_LIST_PAGE_ALERT_ERR, "Your room preferences could not be saved.")
+	}
+
+	page.populateTable(appContext.GetBrochatUser(), appContext.GetTheme())
+}
+
+// sortedRooms returns the rooms to display. Pinned rooms are listed first. Archived rooms are only listed when viewing the archive.
+func (page *RoomListPage) sortedRooms(brochatUser chat.User) []chat.Room {
+	rooms := make([]chat.Room, 0, len(brochatUser.Rooms))
+
+	for _, room := range brochatUser.Rooms {
+		if page.preferences.IsArchived(room.Id) == page.showArchived {
+			rooms = append(rooms, room)
+		}
+	}
+
+	sort.SliceStable(rooms, func(i, j int) bool {
+		return page.preferences.IsPinned(rooms[i].Id) && !page.preferences.IsPinned(rooms[j].Id)
+	})
+
+	return rooms
+}
+
 func (page *RoomListPage) populateTable(brochatUser chat.User, thm theme.Theme) {
+	selectedRow, _ := page.table.GetSelection()
+
+	page.table.Clear()
+	page.userRooms = make(map[int]chat.Room, 0)
+
+	if page.showArchived {
+		page.table.SetTitle(" Archived Rooms ")
+		page.tvInstructions.SetText(roomListArchivedInstructions(page.keys))
+	} else {
+		page.table.SetTitle("")
+		page.tvInstructions.SetText(roomListInstructions(page.keys))
+	}
+
 	page.table.SetCell(0, 0, tview.NewTableCell("Name").
 		SetTextColor(thm.ForgroundColor).
 		SetAlign(tview.AlignCenter).
@@ -188,12 +437,44 @@ func (page *RoomListPage) populateTable(brochatUser chat.User, thm theme.Theme)
 		SetSelectable(false).
 		SetAttributes(tcell.AttrBold|tcell.AttrUnderline))
 
-	for i, rel := range brochatUser.Rooms {
+	page.table.SetCell(0, 2, tview.NewTableCell("Status").
+		SetTextColor(thm.ForgroundColor).
+		SetAlign(tview.AlignCenter).
+		SetSelectable(false).
+		SetAttributes(tcell.AttrBold|tcell.AttrUnderline))
+
+	for i, room := range page.sortedRooms(brochatUser) {
 		row := i + 1
 
-		page.table.SetCell(row, 0, tview.NewTableCell(rel.Name).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignCenter))
-		page.table.SetCell(row, 1, tview.NewTableCell(rel.Owner.Username).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignCenter))
+		flags := make([]string, 0, 2)
+
+		if page.preferences.IsPinned(room.Id) {
+			flags = append(flags, "Pinned")
+		}
+
+		nameColor := thm.ForgroundColor
+		var nameAttributes tcell.AttrMask
+
+		// Muted rooms are never highlighted for unread messages
+		if page.preferences.IsMuted(room.Id) {
+			flags = append(flags, "Muted")
+			nameColor = thm.InfoColorTwo
+		} else if page.feedClient.IsChannelUnread(room.ChannelId) {
+			flags = append(flags, "Unread")
+			nameColor = thm.HighlightColor
+			nameAttributes = tcell.AttrBold
+		}
+
+		page.table.SetCell(row, 0, tview.NewTableCell(room.Name).SetTextColor(nameColor).SetAttributes(nameAttributes).SetAlign(tview.AlignCenter))
+		page.table.SetCell(row, 1, tview.NewTableCell(room.Owner.Username).SetTextColor(thm.ForgroundColor).SetAlign(tview.AlignCenter))
+		page.table.SetCell(row, 2, tview.NewTableCell(strings.Join(flags, ", ")).SetTextColor(thm.InfoColorTwo).SetAlign(tview.AlignCenter))
 
-		page.userRooms[row] = rel
+		page.userRooms[row] = room
 	}
+
+	if selectedRow < 1 || selectedRow >= page.table.GetRowCount() {
+		selectedRow = 1
+	}
+
+	page.table.Select(selectedRow, 0)
 }
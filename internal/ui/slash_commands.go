@@ -0,0 +1,546 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dmars8047/brolib/chat"
+	"github.com/dmars8047/broterm/internal/config"
+	"github.com/dmars8047/broterm/internal/state"
+	"github.com/dmars8047/broterm/internal/theme"
+	"github.com/rivo/tview"
+)
+
+// serverMacros are the slash commands which are handled by the BroChat server rather than the client.
+// They are listed here so that they can be included in help text and completion.
+var serverMacros = []SlashCommand{
+	{Name: "roll", Usage: "<dice>", Description: "Roll dice (server macro)"},
+	{Name: "flip", Description: "Flip a coin (server macro)"},
+	{Name: "llm-prompt", Usage: "<prompt>", Description: "Ask the LLM a question (server macro)"},
+	{Name: "wiki", Usage: "<topic>", Description: "Look up a topic on wikipedia (server macro)"},
+}
+
+// slashCommandContext is the state made available to a slash command when it is run or completed.
+type slashCommandContext struct {
+	app        *tview.Application
+	appContext *state.ApplicationContext
+	nav        *PageNavigator
+	page       *ChatPage
+	channel    chat.Channel
+	params     ChatPageParameters
+}
+
+// SlashCommand is a client side command which can be entered in the chat composer.
+type SlashCommand struct {
+	// Name is the name of the command without the leading slash.
+	Name string
+	// Usage describes the arguments the command accepts. Example: "<room>"
+	Usage string
+	// Description is a short description of what the command does.
+	Description string
+	// MinArgs is the minimum number of arguments the command requires.
+	MinArgs int
+	// complete returns the possible values for the argument currently being typed.
+	complete func(ctx *slashCommandContext, argIndex int) []string
+	// run executes the command.
+	run func(ctx *slashCommandContext, args []string) error
+}
+
+// HelpLine returns a single line describing the command and its usage.
+func (cmd SlashCommand) HelpLine() string {
+	if cmd.Usage == "" {
+		return fmt.Sprintf("/%s - %s", cmd.Name, cmd.Description)
+	}
+
+	return fmt.Sprintf("/%s %s - %s", cmd.Name, cmd.Usage, cmd.Description)
+}
+
+// SlashCommandRegistry holds the client side slash commands available in the chat composer.
+type SlashCommandRegistry struct {
+	commands map[string]SlashCommand
+}
+
+// NewSlashCommandRegistry creates a new, empty slash command registry.
+func NewSlashCommandRegistry() *SlashCommandRegistry {
+	return &SlashCommandRegistry{
+		commands: make(map[string]SlashCommand),
+	}
+}
+
+// Register adds a command to the registry. Registering a command with an existing name replaces it.
+func (registry *SlashCommandRegistry) Register(cmd SlashCommand) {
+	registry.commands[strings.ToLower(cmd.Name)] = cmd
+}
+
+// Lookup returns the command with the provided name (without the leading slash).
+func (registry *SlashCommandRegistry) Lookup(name string) (SlashCommand, bool) {
+	cmd, ok := registry.commands[strings.ToLower(name)]
+	return cmd, ok
+}
+
+// Commands returns all registered commands sorted by name.
+func (registry *SlashCommandRegistry) Commands() []SlashCommand {
+	cmds := make([]SlashCommand, 0, len(registry.commands))
+
+	for _, cmd := range registry.commands {
+		cmds = append(cmds, cmd)
+	}
+
+	sort.Slice(cmds, func(i, j int) bool {
+		return cmds[i].Name < cmds[j].Name
+	})
+
+	return cmds
+}
+
+// Help returns the help text for every client side command followed by the server macros.
+func (registry *SlashCommandRegistry) Help() string {
+	var builder strings.Builder
+
+	builder.WriteString("Available commands:")
+
+	for _, cmd := range registry.Commands() {
+		builder.WriteString("\n  " + cmd.HelpLine())
+	}
+
+	for _, cmd := range serverMacros {
+		builder.WriteString("\n  " + cmd.HelpLine())
+	}
+
+	return builder.String()
+}
+
+// Execute attempts to run the text as a client side command.
+// The returned bool will be false if the text is not a client side command, in which case it should be sent to the server.
+func (registry *SlashCommandRegistry) Execute(ctx *slashCommandContext, text string) (bool, error) {
+	name, args, ok := ParseSlashCommand(text)
+
+	if !ok {
+		return false, nil
+	}
+
+	cmd, ok := registry.Lookup(name)
+
+	if !ok {
+		return false, nil
+	}
+
+	if len(args) < cmd.MinArgs {
+		return true, fmt.Errorf("usage: %s", cmd.HelpLine())
+	}
+
+	return true, cmd.run(ctx, args)
+}
+
+// Complete returns the possible completions for the text currently in the composer.
+// Each completion is the full composer text which should replace the current text.
+func (registry *SlashCommandRegistry) Complete(ctx *slashCommandContext, text string) []string {
+	if !strings.HasPrefix(text, "/") {
+		return nil
+	}
+
+	completions := make([]string, 0)
+
+	// Complete the command name if no argument has been started yet
+	if !strings.Contains(text, " ") {
+		prefix := strings.ToLower(strings.TrimPrefix(text, "/"))
+
+		names := make([]string, 0)
+
+		for _, cmd := range registry.Commands() {
+			names = append(names, cmd.Name)
+		}
+
+		for _, cmd := range serverMacros {
+			names = append(names, cmd.Name)
+		}
+
+		for _, name := range names {
+			if strings.HasPrefix(name, prefix) {
+				completions = append(completions, "/"+name+" ")
+			}
+		}
+
+		return completions
+	}
+
+	name, args, _ := ParseSlashCommand(text)
+
+	cmd, ok := registry.Lookup(name)
+
+	if !ok || cmd.complete == nil {
+		return completions
+	}
+
+	// If the text ends with a space then a new argument is being started
+	if strings.HasSuffix(text, " ") {
+		args = append(args, "")
+	}
+
+	argIndex := len(args) - 1
+	argPrefix := strings.ToLower(args[argIndex])
+	base := "/" + cmd.Name
+
+	for _, arg := range args[:argIndex] {
+		base += " " + quoteSlashCommandArg(arg)
+	}
+
+	for _, candidate := range cmd.complete(ctx, argIndex) {
+		if strings.HasPrefix(strings.ToLower(candidate), argPrefix) {
+			completions = append(completions, base+" "+quoteSlashCommandArg(candidate)+" ")
+		}
+	}
+
+	return completions
+}
+
+// ParseSlashCommand splits composer text into a command name and its arguments.
+// Arguments are separated by whitespace unless surrounded by double quotes. Example: /join "Bro Zone"
+// The returned bool will be false if the text does not begin with a slash.
+func ParseSlashCommand(text string) (string, []string, bool) {
+	text = strings.TrimLeft(text, " ")
+
+	if !strings.HasPrefix(text, "/") || len(text) < 2 {
+		return "", nil, false
+	}
+
+	tokens := make([]string, 0)
+	var current strings.Builder
+	inQuotes := false
+	hasToken := false
+
+	for _, r := range text[1:] {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			hasToken = true
+		case (r == ' ' || r == '\t' || r == '\n') && !inQuotes:
+			if hasToken {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				hasToken = false
+			}
+		default:
+			current.WriteRune(r)
+			hasToken = true
+		}
+	}
+
+	if hasToken {
+		tokens = append(tokens, current.String())
+	}
+
+	if len(tokens) == 0 || tokens[0] == "" {
+		return "", nil, false
+	}
+
+	return strings.ToLower(tokens[0]), tokens[1:], true
+}
+
+// quoteSlashCommandArg wraps an argument in quotes if it contains whitespace.
+func quoteSlashCommandArg(arg string) string {
+	if strings.ContainsAny(arg, " \t") {
+		return `"` + arg + `"`
+	}
+
+	return arg
+}
+
+// joinPublicRoom joins the public room with the given name, ignoring case, and returns it
+func joinPublicRoom(brochatClient *chat.BroChatClient, accessToken, roomName string) (chat.Room, error) {
+	getRoomsResult := brochatClient.GetRooms(accessToken)
+
+	if err := getRoomsResult.Err(); err != nil {
+		return chat.Room{}, fmt.Errorf("an error occurred while retrieving public rooms: %s", err.Error())
+	}
+
+	for _, room := range getRoomsResult.Content {
+		if !strings.EqualFold(room.Name, roomName) {
+			continue
+		}
+
+		joinRoomResult := brochatClient.JoinRoom(accessToken, room.Id)
+
+		if err := joinRoomResult.Err(); err != nil {
+			if len(joinRoomResult.ErrorDetails) > 0 {
+				return chat.Room{}, errors.New(joinRoomResult.ErrorDetails[0])
+			}
+
+			if joinRoomResult.ResponseCode == chat.BROCHAT_RESPONSE_CODE_FORBIDDEN_ERROR {
+				return chat.Room{}, errors.New(FORBIDDEN_OPERATION_ERROR_MESSAGE)
+			}
+
+			return chat.Room{}, fmt.Errorf("an error occurred while joining room: %s", err.Error())
+		}
+
+		return room, nil
+	}
+
+	return chat.Room{}, fmt.Errorf("no room named '%s' was found", roomName)
+}
+
+// newDefaultSlashCommandRegistry creates a registry containing the built in client side commands.
+func newDefaultSlashCommandRegistry(brochatClient *chat.BroChatClient) *SlashCommandRegistry {
+	registry := NewSlashCommandRegistry()
+
+	registry.Register(SlashCommand{
+		Name:        "join",
+		Usage:       "<room>",
+		Description: "Open a room, joining it first if it is public",
+		MinArgs:     1,
+		complete: func(ctx *slashCommandContext, argIndex int) []string {
+			if argIndex != 0 {
+				return nil
+			}
+
+			names := make([]string, 0)
+
+			for _, room := range ctx.appContext.GetBrochatUser().Rooms {
+				names = append(names, room.Name)
+			}
+
+			return names
+		},
+		run: func(ctx *slashCommandContext, args []string) error {
+			roomName := strings.Join(args, " ")
+
+			for _, room := range ctx.appContext.GetBrochatUser().Rooms {
+				if strings.EqualFold(room.Name, roomName) {
+					ctx.nav.NavigateTo(CHAT_PAGE, ChatPageParameters{
+						channel_id: room.ChannelId,
+						title:      room.Name,
+					})
+					return nil
+				}
+			}
+
+			accessToken, ok := ctx.appContext.GetAccessToken()
+
+			if !ok {
+				return errors.New("valid user authentication information not found")
+			}
+
+			// The room is joined in the background. Errors are written to the chat history once the request completes.
+			runSessionAsync(ctx.app, ctx.appContext, ctx.nav, fmt.Sprintf("Joining '%s'...", roomName), func(context.Context) asyncResult[chat.Room] {
+				room, err := joinPublicRoom(brochatClient, accessToken, roomName)
+				return asyncResult[chat.Room]{value: room, err: err}
+			}, func(response asyncResult[chat.Room]) {
+				if response.err != nil {
+					ctx.page.writeSystemMessage(ctx.appContext.GetTheme(), response.err.Error())
+					return
+				}
+
+				ctx.nav.NavigateTo(CHAT_PAGE, ChatPageParameters{
+					channel_id: response.value.ChannelId,
+					title:      response.value.Name,
+				})
+			})
+
+			return nil
+		},
+	})
+
+	registry.Register(SlashCommand{
+		Name:        "dm",
+		Usage:       "<user>",
+		Description: "Open a direct message conversation with a friend",
+		MinArgs:     1,
+		complete: func(ctx *slashCommandContext, argIndex int) []string {
+			if argIndex != 0 {
+				return nil
+			}
+
+			names := make([]string, 0)
+
+			for _, rel := range ctx.appContext.GetBrochatUser().Relationships {
+				if rel.Type == chat.RELATIONSHIP_TYPE_FRIEND {
+					names = append(names, rel.Username)
+				}
+			}
+
+			return names
+		},
+		run: func(ctx *slashCommandContext, args []string) error {
+			for _, rel := range ctx.appContext.GetBrochatUser().Relationships {
+				if rel.Type == chat.RELATIONSHIP_TYPE_FRIEND && strings.EqualFold(rel.Username, args[0]) {
+					ctx.nav.NavigateTo(CHAT_PAGE, ChatPageParameters{
+						channel_id: rel.DirectMessageChannelId,
+					})
+					return nil
+				}
+			}
+
+			return fmt.Errorf("'%s' is not in your friends list", args[0])
+		},
+	})
+
+	registry.Register(SlashCommand{
+		Name:        "leave",
+		Description: "Close this conversation and go back",
+		run: func(ctx *slashCommandContext, _ []string) error {
+			ctx.nav.Back()
+			return nil
+		},
+	})
+
+	registry.Register(SlashCommand{
+		Name:        "theme",
+		Usage:       "<name>",
+		Description: "Switch the theme for this session",
+		MinArgs:     1,
+		complete: func(_ *slashCommandContext, argIndex int) []string {
+			if argIndex != 0 {
+				return nil
+			}
+
+			return theme.Codes()
+		},
+		run: func(ctx *slashCommandContext, args []string) error {
+			themeCode := strings.ToLower(args[0])
+
+			found := false
+
+			for _, code := range theme.Codes() {
+				if code == themeCode {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				return fmt.Errorf("unknown theme '%s'. Available themes: %s", args[0], strings.Join(theme.Codes(), ", "))
+			}
+
+			// Every page is re-themed by the theme manager
+			ctx.appContext.SetTheme(themeCode)
+
+			// The conversation is reloaded so that the history is redrawn with the new theme
+			ctx.nav.Redirect(CHAT_PAGE, ctx.params)
+
+			return nil
+		},
+	})
+
+	registry.Register(SlashCommand{
+		Name:        "clear",
+		Description: "Clear the messages shown on screen",
+		run: func(ctx *slashCommandContext, _ []string) error {
+			ctx.page.textView.Clear()
+			return nil
+		},
+	})
+
+	registry.Register(SlashCommand{
+		Name:        "export",
+		Usage:       "[file]",
+		Description: "Save the loaded conversation to a text file",
+		run: func(ctx *slashCommandContext, args []string) error {
+			var exportPath string
+
+			if len(args) > 0 {
+				exportPath = args[0]
+			} else {
+				homeDir, err := os.UserHomeDir()
+
+				if err != nil {
+					return fmt.Errorf("could not determine home directory: %s", err.Error())
+				}
+
+				exportDir := filepath.Join(homeDir, config.DEFAULT_CONFIG_DIRECTORY_NAME, config.EXPORT_DIRECTORY_NAME)
+
+				if err := os.MkdirAll(exportDir, os.ModePerm); err != nil {
+					return fmt.Errorf("could not create export directory: %s", err.Error())
+				}
+
+				fileName := fmt.Sprintf("chat_%s_%s.txt", ctx.channel.Id, time.Now().Format("2006_01_02_150405"))
+				exportPath = filepath.Join(exportDir, fileName)
+			}
+
+			transcript := ctx.page.textView.GetText(true)
+
+			if err := os.WriteFile(exportPath, []byte(transcript), 0644); err != nil {
+				return fmt.Errorf("could not export conversation: %s", err.Error())
+			}
+
+			ctx.page.writeSystemMessage(ctx.appContext.GetTheme(), fmt.Sprintf("Conversation exported to %s", exportPath))
+
+			return nil
+		},
+	})
+
+	registry.Register(SlashCommand{
+		Name:        "whois",
+		Usage:       "<user>",
+		Description: "Show information about a member of this conversation",
+		MinArgs:     1,
+		complete: func(ctx *slashCommandContext, argIndex int) []string {
+			if argIndex != 0 {
+				return nil
+			}
+
+			names := make([]string, 0, len(ctx.channel.Users))
+
+			for _, u := range ctx.channel.Users {
+				names = append(names, u.Username)
+			}
+
+			return names
+		},
+		run: func(ctx *slashCommandContext, args []string) error {
+			for _, u := range ctx.channel.Users {
+				if !strings.EqualFold(u.Username, args[0]) {
+					continue
+				}
+
+				relationship := "None"
+
+				brochatUser := ctx.appContext.GetBrochatUser()
+
+				if u.Id == brochatUser.Id {
+					relationship = "You"
+				}
+
+				for _, rel := range brochatUser.Relationships {
+					if rel.UserId != u.Id {
+						continue
+					}
+
+					switch {
+					case rel.Type == chat.RELATIONSHIP_TYPE_FRIEND:
+						relationship = "Friend"
+					case rel.Type&chat.RELATIONSHIP_TYPE_FRIEND_REQUEST_RECIEVED != 0:
+						relationship = "Friend request recieved"
+					case rel.Type&chat.RELATIONSHIP_TYPE_FRIENDSHIP_REQUESTED != 0:
+						relationship = "Friend request sent"
+					}
+				}
+
+				ctx.nav.Alert(CHAT_PAGE_ALERT_INFO, fmt.Sprintf("%s\n\nLast Active: %s\nRelationship: %s",
+					u.Username,
+					u.LastOnlineUtc.Local().Format("Jan 2, 2006 3:04 PM"),
+					relationship))
+
+				return nil
+			}
+
+			return fmt.Errorf("'%s' is not a member of this conversation", args[0])
+		},
+	})
+
+	registry.Register(SlashCommand{
+		Name:        "help",
+		Description: "List the available commands",
+		run: func(ctx *slashCommandContext, _ []string) error {
+			ctx.page.writeSystemMessage(ctx.appContext.GetTheme(), registry.Help())
+			return nil
+		},
+	})
+
+	return registry
+}
@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"github.com/dmars8047/broterm/internal/theme"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// The helpers in this file register primitives with the theme manager so that they are re-themed whenever the theme changes.
+// Each helper styles a primitive for the role it plays on a page. Pages with primitives that do not fit one of the roles register their own styler.
+
+// Text colors for the roles a text view can play on a page
+var (
+	headerTextColor       = func(thm theme.Theme) tcell.Color { return thm.TitleColor }
+	instructionsTextColor = func(thm theme.Theme) tcell.Color { return thm.InfoColor }
+	statusTextColor       = func(thm theme.Theme) tcell.Color { return thm.InfoColorTwo }
+	foregroundTextColor   = func(thm theme.Theme) tcell.Color { return thm.ForgroundColor }
+	highlightTextColor    = func(thm theme.Theme) tcell.Color { return thm.HighlightColor }
+)
+
+// backgroundSetter is implemented by primitives which embed a box and do not override SetBackgroundColor, such as grids and flexes
+type backgroundSetter interface {
+	SetBackgroundColor(color tcell.Color) *tview.Box
+}
+
+// themeBackground gives a layout primitive the theme's background color
+func themeBackground(themes *theme.Manager, primitive backgroundSetter) {
+	themes.Register(func(thm theme.Theme) {
+		primitive.SetBackgroundColor(thm.BackgroundColor)
+	})
+}
+
+// themeTextView gives a text view the theme's background color and the text color for its role
+func themeTextView(themes *theme.Manager, textView *tview.TextView, textColor func(theme.Theme) tcell.Color) {
+	themes.Register(func(thm theme.Theme) {
+		textView.SetBackgroundColor(thm.BackgroundColor)
+		textView.SetTextColor(textColor(thm))
+	})
+}
+
+// themeForm styles a form along with the drop down lists of any drop downs in the form
+func themeForm(themes *theme.Manager, form *tview.Form) {
+	themes.Register(func(thm theme.Theme) {
+		form.SetBackgroundColor(thm.AccentColor)
+		form.SetFieldBackgroundColor(thm.AccentColorTwo)
+		form.SetFieldTextColor(thm.ForgroundColor)
+		form.SetLabelColor(thm.HighlightColor)
+		form.SetButtonStyle(thm.ButtonStyle)
+		form.SetButtonActivatedStyle(thm.ActivatedButtonStyle)
+		form.SetBorderColor(thm.BorderColor)
+		form.SetTitleColor(thm.TitleColor)
+
+		for i := 0; i < form.GetFormItemCount(); i++ {
+			if dropDown, ok := form.GetFormItem(i).(*tview.DropDown); ok {
+				dropDown.SetListStyles(thm.DropdownListUnselectedStyle, thm.DropdownListSelectedStyle)
+			}
+		}
+	})
+}
+
+// themeTable styles a table with a border and a selectable row
+func themeTable(themes *theme.Manager, table *tview.Table) {
+	themes.Register(func(thm theme.Theme) {
+		table.SetBordersColor(thm.BorderColor)
+		table.SetBorderColor(thm.BorderColor)
+		table.SetTitleColor(thm.TitleColor)
+		table.SetBackgroundColor(thm.BackgroundColor)
+		table.SetSelectedStyle(thm.DropdownListSelectedStyle)
+	})
+}
+
+// themeButton styles a stand alone button
+func themeButton(themes *theme.Manager, button *tview.Button) {
+	themes.Register(func(thm theme.Theme) {
+		button.SetStyle(thm.ButtonStyle)
+		button.SetActivatedStyle(thm.ActivatedButtonStyle)
+	})
+}
+
+// themeSearchInput styles a stand alone input field which sits on the page background
+func themeSearchInput(themes *theme.Manager, input *tview.InputField) {
+	themes.Register(func(thm theme.Theme) {
+		styleSearchInput(input, thm)
+	})
+}
+
+// styleSearchInput styles an input field which sits on the page background, including its border if it has one
+func styleSearchInput(input *tview.InputField, thm theme.Theme) {
+	input.SetBackgroundColor(thm.BackgroundColor)
+	input.SetLabelColor(thm.HighlightColor)
+	input.SetFieldBackgroundColor(thm.AccentColorTwo)
+	input.SetFieldTextColor(thm.ForgroundColor)
+	input.SetPlaceholderStyle(tcell.StyleDefault.Background(thm.AccentColorTwo).Foreground(thm.InfoColorTwo))
+	input.SetBorderColor(thm.BorderColor)
+	input.SetTitleColor(thm.TitleColor)
+}
+
+// styleModal styles an alert or confirmation modal
+func styleModal(modal *tview.Modal, thm theme.Theme) {
+	modal.SetBackgroundColor(thm.BackgroundColor)
+	modal.SetTextColor(thm.ForgroundColor)
+	modal.SetButtonStyle(thm.ButtonStyle)
+	modal.SetButtonActivatedStyle(thm.ActivatedButtonStyle)
+	modal.SetBorderColor(thm.BorderColor)
+	modal.SetBorderStyle(thm.TextAreaTextStyle)
+	modal.SetTitleColor(thm.TitleColor)
+}
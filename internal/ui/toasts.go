@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dmars8047/broterm/internal/state"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	// toastDuration is how long a toast is shown for
+	toastDuration = 3 * time.Second
+	// maxToasts is the number of toasts shown at once. The oldest toast is dropped to make room for a new one.
+	maxToasts = 3
+	// maxToastWidth is the widest a toast can be, including its border
+	maxToastWidth = 60
+)
+
+// toast is a short lived notification
+type toast struct {
+	message   string
+	expiresAt time.Time
+}
+
+// toastArea draws transient notifications in the top right corner of the screen.
+// Toasts are drawn over the pages after every draw so they never take focus or need to be dismissed.
+type toastArea struct {
+	app        *tview.Application
+	appContext *state.ApplicationContext
+	mu         sync.Mutex
+	toasts     []toast
+}
+
+// newToastArea creates a toast area and has the application draw it after every draw
+func newToastArea(app *tview.Application, appContext *state.ApplicationContext) *toastArea {
+	area := &toastArea{
+		app:        app,
+		appContext: appContext,
+		toasts:     make([]toast, 0),
+	}
+
+	app.SetAfterDrawFunc(area.draw)
+
+	return area
+}
+
+// show adds a toast which is removed once the toast duration has passed
+func (area *toastArea) show(message string) {
+	area.mu.Lock()
+
+	area.toasts = append(area.toasts, toast{
+		message:   message,
+		expiresAt: time.Now().Add(toastDuration),
+	})
+
+	if len(area.toasts) > maxToasts {
+		area.toasts = area.toasts[len(area.toasts)-maxToasts:]
+	}
+
+	area.mu.Unlock()
+
+	// Redraw once the toast has expired so that it disappears without waiting for another event
+	time.AfterFunc(toastDuration, func() {
+		area.app.QueueUpdateDraw(func() {})
+	})
+}
+
+// draw draws the toasts which have not expired, newest at the bottom
+func (area *toastArea) draw(screen tcell.Screen) {
+	now := time.Now()
+
+	area.mu.Lock()
+
+	current := make([]toast, 0, len(area.toasts))
+
+	for _, t := range area.toasts {
+		if now.Before(t.expiresAt) {
+			current = append(current, t)
+		}
+	}
+
+	area.toasts = current
+
+	area.mu.Unlock()
+
+	if len(current) == 0 {
+		return
+	}
+
+	screenWidth, _ := screen.Size()
+	thm := area.appContext.GetTheme()
+	y := 1
+
+	for _, t := range current {
+		text := tview.Escape(t.message)
+		width := min(tview.TaggedStringWidth(text)+4, maxToastWidth, screenWidth)
+
+		textView := tview.NewTextView().SetText(text).SetWrap(false)
+		textView.SetBorder(true)
+		textView.SetBorderPadding(0, 0, 1, 1)
+		textView.SetBackgroundColor(thm.AccentColor)
+		textView.SetTextColor(thm.ForgroundColor)
+		textView.SetBorderColor(thm.BorderColor)
+		textView.SetRect(screenWidth-width-1, y, width, 3)
+		textView.Draw(screen)
+
+		y += 3
+	}
+}
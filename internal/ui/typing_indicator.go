@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// typingNotificationInterval is the minimum amount of time between typing notifications sent to the server.
+	typingNotificationInterval = 3 * time.Second
+	// typingIndicatorExpiry is how long a user is shown as typing after their last typing event.
+	typingIndicatorExpiry = 5 * time.Second
+)
+
+// typingTracker keeps track of which members of a channel are currently composing a message.
+type typingTracker struct {
+	mu      sync.Mutex
+	typists map[string]typist
+}
+
+// typist is a channel member who is currently composing a message.
+type typist struct {
+	username  string
+	expiresAt time.Time
+}
+
+// newTypingTracker creates a new, empty typing tracker.
+func newTypingTracker() *typingTracker {
+	return &typingTracker{
+		typists: make(map[string]typist),
+	}
+}
+
+// Touch marks the user as typing. The user will be considered typing until the expiry elapses without another touch.
+func (tracker *typingTracker) Touch(userId, username string, now time.Time) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	tracker.typists[userId] = typist{
+		username:  username,
+		expiresAt: now.Add(typingIndicatorExpiry),
+	}
+}
+
+// Remove marks the user as no longer typing. Returns true if the user was previously typing.
+func (tracker *typingTracker) Remove(userId string) bool {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	_, ok := tracker.typists[userId]
+	delete(tracker.typists, userId)
+
+	return ok
+}
+
+// Prune removes every typist whose indicator has expired. Returns true if any typist was removed.
+func (tracker *typingTracker) Prune(now time.Time) bool {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	removed := false
+
+	for userId, t := range tracker.typists {
+		if now.After(t.expiresAt) {
+			delete(tracker.typists, userId)
+			removed = true
+		}
+	}
+
+	return removed
+}
+
+// Reset removes all typists.
+func (tracker *typingTracker) Reset() {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	clear(tracker.typists)
+}
+
+// Text returns the indicator text to display. Example: "alice and bob are typing…"
+// An empty string is returned if no one is typing.
+func (tracker *typingTracker) Text() string {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	usernames := make([]string, 0, len(tracker.typists))
+
+	for _, t := range tracker.typists {
+		usernames = append(usernames, t.username)
+	}
+
+	sort.Strings(usernames)
+
+	switch len(usernames) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("%s is typing…", usernames[0])
+	case 2:
+		return fmt.Sprintf("%s and %s are typing…", usernames[0], usernames[1])
+	case 3:
+		return fmt.Sprintf("%s, %s and %s are typing…", usernames[0], usernames[1], usernames[2])
+	default:
+		return "Several people are typing…"
+	}
+}
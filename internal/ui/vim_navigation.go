@@ -0,0 +1,200 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/dmars8047/broterm/internal/keymap"
+	"github.com/dmars8047/broterm/internal/state"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const TABLE_SEARCH_PROMPT = "table:search:prompt"
+
+// vimMotion is a movement made with the vim navigation keys
+type vimMotion int
+
+const (
+	VIM_MOTION_NONE vimMotion = iota
+	// VIM_MOTION_PENDING is returned for the first press of the top key. The event is handled but nothing moves.
+	VIM_MOTION_PENDING
+	VIM_MOTION_DOWN
+	VIM_MOTION_UP
+	VIM_MOTION_TOP
+	VIM_MOTION_BOTTOM
+	VIM_MOTION_HALF_PAGE_DOWN
+	VIM_MOTION_HALF_PAGE_UP
+)
+
+// vimKeys resolves key events to vim motions while vim navigation is enabled.
+// The top motion is only made when the top key is pressed twice in a row, as with gg in vim,
+// so each primitive which can be navigated needs its own vimKeys.
+type vimKeys struct {
+	appContext *state.ApplicationContext
+	topPending bool
+}
+
+// motion returns the motion the key event is bound to
+func (vim *vimKeys) motion(event *tcell.EventKey) vimMotion {
+	if !vim.appContext.IsVimModeEnabled() {
+		vim.topPending = false
+		return VIM_MOTION_NONE
+	}
+
+	action, ok := vim.appContext.GetKeymap().Match(event,
+		keymap.ACTION_VIM_DOWN, keymap.ACTION_VIM_UP, keymap.ACTION_VIM_TOP, keymap.ACTION_VIM_BOTTOM,
+		keymap.ACTION_VIM_HALF_PAGE_DOWN, keymap.ACTION_VIM_HALF_PAGE_UP)
+
+	topPending := vim.topPending
+	vim.topPending = false
+
+	if !ok {
+		return VIM_MOTION_NONE
+	}
+
+	switch action {
+	case keymap.ACTION_VIM_DOWN:
+		return VIM_MOTION_DOWN
+	case keymap.ACTION_VIM_UP:
+		return VIM_MOTION_UP
+	case keymap.ACTION_VIM_TOP:
+		if topPending {
+			return VIM_MOTION_TOP
+		}
+
+		vim.topPending = true
+		return VIM_MOTION_PENDING
+	case keymap.ACTION_VIM_BOTTOM:
+		return VIM_MOTION_BOTTOM
+	case keymap.ACTION_VIM_HALF_PAGE_DOWN:
+		return VIM_MOTION_HALF_PAGE_DOWN
+	case keymap.ACTION_VIM_HALF_PAGE_UP:
+		return VIM_MOTION_HALF_PAGE_UP
+	}
+
+	return VIM_MOTION_NONE
+}
+
+// vimTableNavigation returns an input handler which moves the selected row of the table with the vim navigation keys
+// and searches the table with the search key. The handler returns true if it handled the event.
+// The first row of the table is expected to be a header and is never selected.
+// rowHeight is the number of lines each row takes up, which is 2 for tables with borders.
+// search is called when the search key is pressed. If search is nil the user is prompted for text to find in the table instead.
+func vimTableNavigation(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator, table *tview.Table, rowHeight int, search func()) func(event *tcell.EventKey) bool {
+	vim := &vimKeys{appContext: appContext}
+
+	return func(event *tcell.EventKey) bool {
+		if !appContext.IsVimModeEnabled() {
+			return false
+		}
+
+		if appContext.GetKeymap().Is(event, keymap.ACTION_SEARCH) {
+			if search != nil {
+				search()
+			} else {
+				nav.Prompt(app, TABLE_SEARCH_PROMPT, "Search", func(text string) {
+					selectNextMatchingRow(table, text)
+				})
+			}
+
+			return true
+		}
+
+		motion := vim.motion(event)
+
+		if motion == VIM_MOTION_NONE {
+			return false
+		}
+
+		rowCount := table.GetRowCount()
+
+		if motion == VIM_MOTION_PENDING || rowCount <= 1 {
+			return true
+		}
+
+		row, _ := table.GetSelection()
+		_, _, _, height := table.GetInnerRect()
+		halfPage := max(1, height/rowHeight/2)
+
+		switch motion {
+		case VIM_MOTION_DOWN:
+			row++
+		case VIM_MOTION_UP:
+			row--
+		case VIM_MOTION_TOP:
+			row = 1
+		case VIM_MOTION_BOTTOM:
+			row = rowCount - 1
+		case VIM_MOTION_HALF_PAGE_DOWN:
+			row += halfPage
+		case VIM_MOTION_HALF_PAGE_UP:
+			row -= halfPage
+		}
+
+		table.Select(min(max(row, 1), rowCount-1), 0)
+		return true
+	}
+}
+
+// vimTextViewNavigation returns an input handler which scrolls the text view with the vim navigation keys.
+// The handler returns true if it handled the event.
+func vimTextViewNavigation(appContext *state.ApplicationContext, textView *tview.TextView) func(event *tcell.EventKey) bool {
+	vim := &vimKeys{appContext: appContext}
+
+	return func(event *tcell.EventKey) bool {
+		motion := vim.motion(event)
+
+		if motion == VIM_MOTION_NONE {
+			return false
+		}
+
+		row, _ := textView.GetScrollOffset()
+		_, _, _, height := textView.GetInnerRect()
+		halfPage := max(1, height/2)
+
+		switch motion {
+		case VIM_MOTION_DOWN:
+			textView.ScrollTo(row+1, 0)
+		case VIM_MOTION_UP:
+			textView.ScrollTo(max(row-1, 0), 0)
+		case VIM_MOTION_TOP:
+			textView.ScrollToBeginning()
+		case VIM_MOTION_BOTTOM:
+			textView.ScrollToEnd()
+		case VIM_MOTION_HALF_PAGE_DOWN:
+			textView.ScrollTo(row+halfPage, 0)
+		case VIM_MOTION_HALF_PAGE_UP:
+			textView.ScrollTo(max(row-halfPage, 0), 0)
+		}
+
+		return true
+	}
+}
+
+// selectNextMatchingRow selects the next row after the selected row with a cell containing the text, ignoring case.
+// The search wraps around to the first row below the header. Returns false if no row contains the text.
+func selectNextMatchingRow(table *tview.Table, text string) bool {
+	text = strings.ToLower(strings.TrimSpace(text))
+	rowCount := table.GetRowCount()
+
+	if text == "" || rowCount <= 1 {
+		return false
+	}
+
+	selected, _ := table.GetSelection()
+
+	for i := 1; i < rowCount; i++ {
+		row := 1 + (max(selected, 1)-1+i)%(rowCount-1)
+
+		for col := 0; col < table.GetColumnCount(); col++ {
+			cell := table.GetCell(row, col)
+
+			if strings.Contains(strings.ToLower(cell.Text), text) {
+				table.Select(row, 0)
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -1,6 +1,9 @@
 package ui
 
 import (
+	"fmt"
+
+	"github.com/dmars8047/broterm/internal/keymap"
 	"github.com/dmars8047/broterm/internal/state"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -10,14 +13,12 @@ const WELCOME_PAGE PageSlug = "welcome"
 
 // WelcomePage is the welcome page
 type WelcomePage struct {
-	currentThemeCode   string
 	applicationVersion string
 }
 
 // NewWelcomePage creates a new instance of the welcome page
 func NewWelcomePage(applicationVersion string) *WelcomePage {
 	return &WelcomePage{
-		currentThemeCode:   "NOT_SET",
 		applicationVersion: applicationVersion,
 	}
 }
@@ -27,6 +28,11 @@ type WelcomePageParams struct {
 	redirectMessage string
 }
 
+// Page returns the page the parameters are for
+func (WelcomePageParams) Page() PageSlug {
+	return WELCOME_PAGE
+}
+
 // Setup configures the welcome page and registers it with the page navigator
 func (page *WelcomePage) Setup(app *tview.Application, appContext *state.ApplicationContext, nav *PageNavigator) {
 	grid := tview.NewGrid()
@@ -75,8 +81,9 @@ CC |  CC\ HH |  HH |AA  __AA | TT |TT\
 	})
 
 	buttonGrid := tview.NewGrid()
+	keys := appContext.GetKeymap()
+
 	buttonGrid.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		key := event.Key()
 
 		goRight := func() {
 			if loginButton.HasFocus() {
@@ -102,28 +109,18 @@ CC |  CC\ HH |  HH |AA  __AA | TT |TT\
 			}
 		}
 
-		// vim movement keys
-		if key == tcell.KeyRune {
-			switch event.Rune() {
-			case 'l':
-				goRight()
-			case 'h':
-				goLeft()
-			}
-		}
-
-		if key == tcell.KeyTab || key == tcell.KeyRight {
+		if keys.Is(event, keymap.ACTION_MENU_RIGHT) {
 			goRight()
-		} else if key == tcell.KeyBacktab || key == tcell.KeyLeft {
+		} else if keys.Is(event, keymap.ACTION_MENU_LEFT) {
 			goLeft()
-		} else if key == tcell.KeyEscape {
+		} else if keys.Is(event, keymap.ACTION_BACK) {
 			app.Stop()
 		}
 		return event
 	})
 
 	tvInstructions := tview.NewTextView().SetTextAlign(tview.AlignCenter)
-	tvInstructions.SetText("Navigate with Tab and Shift+Tab")
+	tvInstructions.SetText(fmt.Sprintf("Navigate with %s and %s", keys.Label(keymap.ACTION_MENU_RIGHT), keys.Label(keymap.ACTION_MENU_LEFT)))
 
 	tvVersionNumber := tview.NewTextView().SetTextAlign(tview.AlignCenter)
 	tvVersionNumber.SetText("Version - " + page.applicationVersion)
@@ -141,47 +138,20 @@ CC |  CC\ HH |  HH |AA  __AA | TT |TT\
 		AddItem(buttonGrid, 2, 1, 1, 2, 0, 0, true).
 		AddItem(tvVersionNumber, 4, 1, 1, 2, 0, 0, false)
 
-	applyTheme := func() {
-		theme := appContext.GetTheme()
-
-		if page.currentThemeCode != theme.Code {
-			page.currentThemeCode = theme.Code
-			grid.SetBackgroundColor(theme.BackgroundColor)
-			logoBro.SetBackgroundColor(theme.BackgroundColor)
-			logoBro.SetTextColor(tcell.ColorWhite)
-			logoChat.SetBackgroundColor(theme.BackgroundColor)
-			logoChat.SetTextColor(theme.HighlightColor)
-
-			loginButton.SetActivatedStyle(theme.ActivatedButtonStyle)
-			loginButton.SetStyle(theme.ButtonStyle)
+	themes := appContext.GetThemeManager()
 
-			registrationButton.SetActivatedStyle(theme.ActivatedButtonStyle)
-			registrationButton.SetStyle(theme.ButtonStyle)
+	themeBackground(themes, grid)
+	themeTextView(themes, logoBro, foregroundTextColor)
+	themeTextView(themes, logoChat, highlightTextColor)
+	themeButton(themes, loginButton)
+	themeButton(themes, registrationButton)
+	themeButton(themes, configButton)
+	themeButton(themes, exitButton)
+	themeTextView(themes, tvInstructions, instructionsTextColor)
+	themeTextView(themes, tvVersionNumber, statusTextColor)
 
-			configButton.SetActivatedStyle(theme.ActivatedButtonStyle)
-			configButton.SetStyle(theme.ButtonStyle)
-
-			exitButton.SetActivatedStyle(theme.ActivatedButtonStyle)
-			exitButton.SetStyle(theme.ButtonStyle)
-
-			tvInstructions.SetBackgroundColor(theme.BackgroundColor)
-			tvVersionNumber.SetBackgroundColor(theme.BackgroundColor)
-
-			tvInstructions.SetTextColor(theme.InfoColor)
-			tvVersionNumber.SetTextColor(theme.InfoColorTwo)
-
-			theme.ApplyGlobals()
-			nav.Pages.SetBackgroundColor(theme.BackgroundColor)
-		}
-	}
-
-	applyTheme()
-
-	nav.Register(WELCOME_PAGE, grid, true, true, func(param interface{}) {
-		applyTheme()
+	nav.Register(WELCOME_PAGE, grid, true, true, func(param PageParameters) {
 		if param != nil {
-			theme := appContext.GetTheme()
-
 			welcomPageParameters := param.(WelcomePageParams)
 			if welcomPageParameters.isRedirect {
 				modal := tview.NewModal()
@@ -192,13 +162,7 @@ CC |  CC\ HH |  HH |AA  __AA | TT |TT\
 						app.SetFocus(loginButton)
 					})
 
-				modal.SetBackgroundColor(theme.BackgroundColor)
-				modal.SetTextColor(theme.ForgroundColor)
-				modal.SetButtonStyle(theme.ButtonStyle)
-				modal.SetButtonActivatedStyle(theme.ActivatedButtonStyle)
-				modal.SetBorderColor(theme.BorderColor)
-				modal.SetBorderStyle(theme.TextAreaTextStyle)
-				modal.SetTitleColor(theme.TitleColor)
+				styleModal(modal, appContext.GetTheme())
 
 				grid.AddItem(modal, 3, 1, 1, 2, 0, 0, true)
 				app.SetFocus(modal)